@@ -0,0 +1,144 @@
+// cmd/flood-api/backend.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/oliverslade/flood-api/internal/config"
+	"github.com/oliverslade/flood-api/internal/health"
+	"github.com/oliverslade/flood-api/internal/metrics"
+	"github.com/oliverslade/flood-api/internal/repository"
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+	"github.com/oliverslade/flood-api/internal/repository/postgres"
+	"github.com/oliverslade/flood-api/internal/repository/sqlite"
+	"github.com/oliverslade/flood-api/migrations"
+)
+
+// repositories are the stores the API serves from, ingest is only set for Postgres.
+// checks must pass before the API reports itself ready.
+type repositories struct {
+	river    repository.RiverRepository
+	rainfall repository.RainfallRepository
+	station  repository.StationRepository
+	ingest   repository.IngestRepository
+	checks   []health.Check
+	close    func() error
+	// partitions creates the reading partitions for the coming months, nil unless the backend partitions them
+	partitions func(ctx context.Context, now time.Time) error
+}
+
+// openBackend connects to the storage backend named in storage, sizing the pool of Postgres by pool.
+// A SQLite file is opened read only unless writable is set.
+func openBackend(ctx context.Context, storage config.Storage, pool config.Pool, writable bool) (repositories, error) {
+	switch storage.Backend {
+	case config.BackendPostgres:
+		db, err := openPostgres(ctx, storage.DatabaseURL, storage.Migrate, pool)
+		if err != nil {
+			return repositories{}, err
+		}
+		if err := metrics.RegisterDBStats(db, config.BackendPostgres); err != nil {
+			db.Close()
+			return repositories{}, err
+		}
+		return repositories{
+			river:    postgres.NewRiverRepo(db),
+			rainfall: postgres.NewRainfallRepo(db),
+			station:  postgres.NewStationRepo(db),
+			ingest:   postgres.NewIngestRepo(db),
+			checks: []health.Check{
+				health.Ping(db),
+				{Name: "migrations", Run: func(ctx context.Context) error { return migrations.CheckVersion(ctx, db) }},
+				health.HasRows(db, "public.stationnames"),
+			},
+			close: db.Close,
+			partitions: func(ctx context.Context, now time.Time) error {
+				return postgres.CreatePartitions(ctx, db, now)
+			},
+		}, nil
+	case config.BackendSQLite:
+		if storage.SQLitePath == "" {
+			return repositories{}, errors.New("-sqlite is required with -backend=sqlite")
+		}
+		db, err := sqlite.Open(ctx, storage.SQLitePath, !writable)
+		if err != nil {
+			return repositories{}, fmt.Errorf("sqlite open: %w", err)
+		}
+		if err := metrics.RegisterDBStats(db, config.BackendSQLite); err != nil {
+			db.Close()
+			return repositories{}, err
+		}
+		return repositories{
+			river:    sqlite.NewRiverRepo(db),
+			rainfall: sqlite.NewRainfallRepo(db),
+			station:  sqlite.NewStationRepo(db),
+			checks:   []health.Check{health.Ping(db), health.HasRows(db, "stationnames")},
+			close:    db.Close,
+		}, nil
+	case config.BackendMemory:
+		data := inmemory.Fixtures()
+		if storage.FixturesPath != "" {
+			var err error
+			if data, err = inmemory.LoadDataset(storage.FixturesPath); err != nil {
+				return repositories{}, fmt.Errorf("fixtures: %w", err)
+			}
+		}
+		store := inmemory.NewStore(data)
+		station := inmemory.NewStationRepoFrom(store)
+		return repositories{
+			river:    inmemory.NewRiverRepoFrom(store),
+			rainfall: inmemory.NewRainfallRepoFrom(store),
+			station:  station,
+			checks: []health.Check{{Name: "stationnames", Run: func(ctx context.Context) error {
+				stations, err := station.ListStations(ctx)
+				if err == nil && len(stations) == 0 {
+					err = errors.New("stationnames is empty")
+				}
+				return err
+			}}},
+			close: func() error { return nil },
+		}, nil
+	default:
+		return repositories{}, fmt.Errorf("unknown backend %q, want postgres, sqlite or memory", storage.Backend)
+	}
+}
+
+// openPostgres connects to Postgres and applies any pending migrations when migrate is set
+func openPostgres(ctx context.Context, dbURL string, migrate bool, pool config.Pool) (*sql.DB, error) {
+	if dbURL == "" {
+		return nil, errors.New("DATABASE_URL is required")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("db open: %w", err)
+	}
+
+	// Connection pooling
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("db ping: %w", err)
+	}
+
+	if migrate {
+		if err := migrateUp(dbURL); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
+		if err := postgres.PartitionReadings(ctx, db, time.Now()); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("partition: %w", err)
+		}
+	}
+	return db, nil
+}
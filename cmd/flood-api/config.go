@@ -0,0 +1,29 @@
+// cmd/flood-api/config.go
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/oliverslade/flood-api/internal/config"
+)
+
+const configUsage = "usage: flood-api config print [flags]"
+
+// runConfig handles the config subcommand, printing the settings the server would run with to
+// stdout, with secrets redacted. It takes the same flags as the server.
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	cfg, err := config.Load(args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return cfg.Print(os.Stdout)
+}
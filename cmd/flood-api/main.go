@@ -2,70 +2,249 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"flag"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	_ "github.com/lib/pq"
+	"github.com/go-chi/chi/v5/middleware"
 
 	"github.com/oliverslade/flood-api/internal/api"
-	"github.com/oliverslade/flood-api/internal/repository/postgres"
+	"github.com/oliverslade/flood-api/internal/config"
+	"github.com/oliverslade/flood-api/internal/health"
+	"github.com/oliverslade/flood-api/internal/ingest"
+	"github.com/oliverslade/flood-api/internal/metrics"
+	"github.com/oliverslade/flood-api/internal/repository"
+	"github.com/oliverslade/flood-api/internal/tracing"
 )
 
 func main() {
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		slog.Error("DATABASE_URL is required")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(os.Args[2:]); err != nil {
+				slog.Error("migrate", "err", err)
+				os.Exit(1)
+			}
+			return
+		case "config":
+			if err := runConfig(os.Args[2:]); err != nil {
+				slog.Error("config", "err", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	// Settings come from a config file, the environment and flags, see the config package
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		slog.Error("config", "err", err)
 		os.Exit(1)
 	}
+	addr := ":" + strconv.Itoa(cfg.Server.Port)
 
-	port := flag.String("port", "9001", "TCP port to listen on")
-	flag.Parse()
-	addr := ":" + *port
+	// The first SIGTERM or SIGINT starts a graceful shutdown, a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
-	db, err := sql.Open("postgres", dbURL)
+	// Spans are exported wherever OTEL_TRACES_EXPORTER says, or nowhere when it is unset
+	shutdownTracing, err := tracing.Setup(context.Background(), "flood-api")
 	if err != nil {
-		slog.Error("db open", "err", err)
+		slog.Error("tracing", "err", err)
 		os.Exit(1)
 	}
-	defer db.Close()
+	defer shutdownTracing(context.Background())
 
-	// Connection pooling
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute) 
-	db.SetConnMaxIdleTime(30 * time.Second)
-
-	if err := db.Ping(); err != nil {
-		slog.Error("db ping", "err", err)
+	repos, err := openBackend(context.Background(), cfg.Storage, cfg.Pool, cfg.API.WriteToken != "")
+	if err != nil {
+		slog.Error("backend", "backend", cfg.Storage.Backend, "err", err)
 		os.Exit(1)
 	}
+	defer repos.close()
+	slog.Info("Serving", "backend", cfg.Storage.Backend)
 
-	riverRepo := postgres.NewRiverRepo(db)
-	riverHandler := api.NewRiverHandler(riverRepo, slog.Default())
+	// Background work stops as soon as shutdown begins, and must finish before the database is closed
+	var workers sync.WaitGroup
+	defer workers.Wait()
 
-	rainfallRepo := postgres.NewRainfallRepo(db)
-	rainfallHandler := api.NewRainfallHandler(rainfallRepo, slog.Default())
+	// Optionally keep the database topped up from Defra alongside serving it
+	if cfg.Ingest.Interval > 0 {
+		client := ingest.NewClient(cfg.Ingest.URL, &http.Client{Timeout: 30 * time.Second})
+		ingester := ingest.New(client, repos.ingest, cfg.Ingest.Gauges, ingest.DefaultLookback, slog.Default())
+		slog.Info("Ingesting", "url", cfg.Ingest.URL, "interval", cfg.Ingest.Interval)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			ingester.Run(ctx, cfg.Ingest.Interval)
+		}()
+	}
+	// Ingestion refreshes the rollups itself, this catches readings written any other way
+	if cfg.Ingest.RollupInterval > 0 {
+		slog.Info("Refreshing rollups", "interval", cfg.Ingest.RollupInterval)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			refreshRollups(ctx, repos.ingest, cfg.Ingest.RollupInterval)
+		}()
+	}
+
+	// Partitions for the coming months are made ahead of time, as creating them takes locks writes would wait on
+	if repos.partitions != nil {
+		if err := repos.partitions(context.Background(), time.Now()); err != nil {
+			slog.Error("partitions", "err", err)
+			os.Exit(1)
+		}
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			createPartitions(ctx, repos.partitions, partitionInterval)
+		}()
+	}
+
+	riverHandler := api.NewRiverHandler(repos.river, slog.Default())
+	rainfallHandler := api.NewRainfallHandler(repos.rainfall, slog.Default())
+	pagination := api.Pagination{DefaultPageSize: cfg.API.DefaultPageSize, MaxPageSize: cfg.API.MaxPageSize}
+	riverHandler.Pagination, rainfallHandler.Pagination = pagination, pagination
+	stationHandler := api.NewStationHandler(repos.station, slog.Default())
 
 	router := chi.NewRouter()
-	router.Use(api.TimeoutMiddleware) // Add 5s timeout to all requests
-	router.Get("/river", riverHandler.GetReadings)
-	router.Get("/rainfall/{station}", rainfallHandler.GetReadingsByStation)
+	router.Use(tracing.Middleware)
+	router.Use(metrics.Middleware)
+	router.Handle("/metrics", metrics.Handler())
+	// Probes skip the request timeout, as each check is bounded by its own
+	ready := health.NewReady(repos.checks...)
+	router.Get("/healthz", health.Live)
+	router.Method(http.MethodGet, "/readyz", ready)
+	router.Group(func(r chi.Router) {
+		r.Use(api.Timeout(cfg.Server.RequestTimeout)) // Time out every request except exports
+		r.Get("/river", riverHandler.GetReadings)
+		r.Get("/river/aggregate", riverHandler.GetAggregates)
+		r.Get("/river/latest", riverHandler.GetLatestReading)
+		r.Get("/river/{gauge}", riverHandler.GetReadings)
+		r.Get("/river/{gauge}/aggregate", riverHandler.GetAggregates)
+		r.Get("/river/{gauge}/latest", riverHandler.GetLatestReading)
+		r.Get("/rainfall", rainfallHandler.GetReadingsByStations)
+		r.Get("/rainfall/latest", rainfallHandler.GetLatestReadings)
+		r.Get("/rainfall/{station}", rainfallHandler.GetReadingsByStation)
+		r.Get("/rainfall/{station}/aggregate", rainfallHandler.GetAggregatesByStation)
+		r.Get("/rainfall/{station}/latest", rainfallHandler.GetLatestReadingByStation)
+		r.Get("/stations", stationHandler.ListStations)
+		r.Get("/stations/{station}", stationHandler.GetStation)
+	})
+	// Exports stream the whole series for as long as it takes, gzipped when the client accepts it
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.Compress(5, "application/json", "text/csv", "application/x-ndjson"))
+		r.Get("/export/river", riverHandler.ExportReadings)
+		r.Get("/export/river/{gauge}", riverHandler.ExportReadings)
+		r.Get("/export/rainfall/{station}", rainfallHandler.ExportReadingsByStation)
+	})
+	// Writes are only served when there is a token to authenticate them with
+	if writeToken := cfg.API.WriteToken; writeToken != "" {
+		router.Group(func(r chi.Router) {
+			r.Use(api.Timeout(cfg.Server.RequestTimeout))
+			r.Use(api.RequireBearerToken(writeToken))
+			r.Post("/river/readings", riverHandler.PostReadings)
+			r.Post("/river/{gauge}/readings", riverHandler.PostReadings)
+			r.Post("/rainfall/{station}/readings", rainfallHandler.PostReadingsByStation)
+		})
+	} else {
+		slog.Warn("API_WRITE_TOKEN is not set, write endpoints are disabled")
+	}
+
+	// Requests only see their contexts cancelled once draining has given up on them
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 
 	slog.Info("Listening", "addr", addr)
 	server := &http.Server{
 		Addr:         addr,
 		Handler:      router,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		BaseContext:  func(net.Listener) context.Context { return requestCtx },
 	}
 
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe() }()
+
+	select {
+	case err := <-serveErr:
 		slog.Error("listen", "err", err)
+		stop()
+	case <-ctx.Done():
+		stop()
+		drain(server, ready, cancelRequests, cfg.Server.DrainDelay, cfg.Server.DrainTimeout)
+	}
+	slog.Info("Stopped")
+}
+
+// drain takes the server out of rotation and lets the requests in flight finish. Readiness fails for
+// delay before the listener closes, so load balancers have stopped sending requests by then. Requests
+// still running after timeout, such as long exports, have their contexts cancelled and are cut off.
+func drain(server *http.Server, ready *health.Ready, cancelRequests context.CancelFunc, delay, timeout time.Duration) {
+	slog.Info("Draining", "delay", delay, "timeout", timeout)
+	ready.Drain()
+	time.Sleep(delay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("Requests still running after the drain timeout, cancelling them", "err", err)
+		cancelRequests()
+		server.Close()
+	}
+}
+
+// partitionInterval is how often the partitions for the coming months are checked, well inside
+// the PartitionMonthsAhead they are made in advance
+const partitionInterval = 24 * time.Hour
+
+// createPartitions creates the partitions for the coming months on every interval until ctx is cancelled
+func createPartitions(ctx context.Context, create func(context.Context, time.Time) error, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := create(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			slog.Error("Creating partitions failed", "err", err)
+		}
+	}
+}
+
+// refreshRollups brings the rainfall rollups up to date on every interval until ctx is cancelled
+func refreshRollups(ctx context.Context, repo repository.IngestRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := repo.RefreshRollups(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Rollup refresh failed", "err", err)
+		}
 	}
 }
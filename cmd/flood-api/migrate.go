@@ -0,0 +1,185 @@
+// cmd/flood-api/migrate.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/config"
+	"github.com/oliverslade/flood-api/internal/repository/postgres"
+	"github.com/oliverslade/flood-api/internal/repository/sqlite"
+	"github.com/oliverslade/flood-api/migrations"
+)
+
+const migrateUsage = "usage: flood-api migrate up | down [flags] [steps] | status | version | force [flags] <version> | rebuild-rollups"
+
+// runMigrate handles the migrate subcommand, printing results to stdout. It takes the server's flags
+// between the command and its argument, and only needs the storage settings to be valid.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	command := args[0]
+	cfg, args, err := config.LoadStorage(args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch cfg.Storage.Backend {
+	case config.BackendPostgres:
+	case config.BackendSQLite:
+		if command != "up" {
+			return fmt.Errorf("migrate %s needs the postgres backend, only up is supported with sqlite", command)
+		}
+		if err := sqlite.Migrate(context.Background(), cfg.Storage.SQLitePath); err != nil {
+			return err
+		}
+		fmt.Println("Migrated", cfg.Storage.SQLitePath)
+		return nil
+	default:
+		return fmt.Errorf("migrate needs the postgres or sqlite backend, not %s", cfg.Storage.Backend)
+	}
+	dbURL := cfg.Storage.DatabaseURL
+
+	migrator, err := migrations.New(dbURL)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch command {
+	case "up":
+		if err := migrator.Up(); err != nil {
+			return err
+		}
+		if err := partitionReadings(dbURL); err != nil {
+			return err
+		}
+		return printVersion(migrator)
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			if steps, err = strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("steps must be a number: %w", err)
+			}
+		}
+		if err := migrator.Down(steps); err != nil {
+			return err
+		}
+		return printVersion(migrator)
+	case "status":
+		list, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		_, dirty, err := migrator.Version()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "version\tname\tstate")
+		for _, m := range list {
+			state := "pending"
+			if m.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", m.Version, m.Name, state)
+		}
+		w.Flush()
+		if dirty {
+			fmt.Println("The last migration failed part way through, fix the database then force its version")
+		}
+		return nil
+	case "version":
+		return printVersion(migrator)
+	case "force":
+		if len(args) == 0 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("version must be a number: %w", err)
+		}
+		if err := migrator.Force(version); err != nil {
+			return err
+		}
+		return printVersion(migrator)
+	case "rebuild-rollups":
+		return rebuildRollups(dbURL)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// migrateUp applies pending migrations when the server starts
+func migrateUp(dbURL string) error {
+	migrator, err := migrations.New(dbURL)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err := migrator.Up(); err != nil {
+		return err
+	}
+	version, _, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	slog.Info("Database migrated", "version", version)
+	return nil
+}
+
+// partitionReadings moves readings into monthly partitions, which migration 009 can't do itself
+func partitionReadings(dbURL string) error {
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := postgres.PartitionReadings(context.Background(), db, time.Now()); err != nil {
+		return fmt.Errorf("partition: %w", err)
+	}
+	return nil
+}
+
+// rebuildRollups recomputes the rainfall rollups from scratch, for readings loaded with plain SQL
+// such as a restored dump, which don't mark their hours for a refresh
+func rebuildRollups(dbURL string) error {
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := postgres.RebuildRollups(context.Background(), db); err != nil {
+		return fmt.Errorf("rebuild rollups: %w", err)
+	}
+	fmt.Println("Rebuilt rainfall rollups")
+	return nil
+}
+
+func printVersion(migrator *migrations.Migrator) error {
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		fmt.Printf("%d (dirty)\n", version)
+		return nil
+	}
+	fmt.Println(version)
+	return nil
+}
@@ -0,0 +1,102 @@
+// cmd/flood-import/main.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	_ "github.com/lib/pq"
+
+	"github.com/oliverslade/flood-api/internal/constants"
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/importer"
+	"github.com/oliverslade/flood-api/internal/repository/postgres"
+)
+
+func main() {
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		slog.Error("DATABASE_URL is required")
+		os.Exit(1)
+	}
+
+	sqlitePath := flag.String("sqlite", "", "Path of the challenge SQLite database to import")
+	stationsPath := flag.String("stations", "", "Path of a stationnames CSV file to import")
+	rainfallPath := flag.String("rainfall", "", "Path of a rainfalls CSV file to import")
+	riverPath := flag.String("river", "", "Path of a riverlevels CSV file to import")
+	gauge := flag.String("gauge", constants.DefaultRiverGauge, "River gauge the imported river levels belong to")
+	flag.Parse()
+
+	csvGiven := *stationsPath != "" || *rainfallPath != "" || *riverPath != ""
+	if (*sqlitePath == "") == !csvGiven {
+		slog.Error("Give either -sqlite or at least one of -stations, -rainfall and -river")
+		os.Exit(1)
+	}
+
+	var src importer.Source = &importer.CSVSource{
+		StationsPath:    *stationsPath,
+		RainfallPath:    *rainfallPath,
+		RiverLevelsPath: *riverPath,
+	}
+	if *sqlitePath != "" {
+		sqliteSrc, err := importer.OpenSQLite(*sqlitePath)
+		if err != nil {
+			slog.Error("sqlite open", "err", err)
+			os.Exit(1)
+		}
+		src = sqliteSrc
+	}
+	defer src.Close()
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		slog.Error("db open", "err", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		slog.Error("db ping", "err", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, err := importer.New(postgres.NewImportRepo(db), slog.Default()).Import(ctx, src, *gauge)
+	printReport(report)
+	if err != nil {
+		slog.Error("import", "err", err)
+		os.Exit(1)
+	}
+
+	if report.Rainfall.Imported > 0 {
+		if _, err := postgres.RefreshRollups(ctx, db); err != nil {
+			slog.Error("refresh rollups", "err", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func printReport(report importer.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "table\timported\tskipped\trejected\t")
+	for _, row := range []struct {
+		table  string
+		result domain.ImportResult
+	}{
+		{"stationnames", report.Stations},
+		{"rainfalls", report.Rainfall},
+		{"riverlevels", report.RiverLevels},
+	} {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t\n", row.table, row.result.Imported, row.result.Skipped, row.result.Rejected)
+	}
+	w.Flush()
+}
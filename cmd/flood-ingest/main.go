@@ -0,0 +1,65 @@
+// cmd/flood-ingest/main.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/oliverslade/flood-api/internal/config"
+	"github.com/oliverslade/flood-api/internal/constants"
+	"github.com/oliverslade/flood-api/internal/ingest"
+	"github.com/oliverslade/flood-api/internal/repository/postgres"
+)
+
+func main() {
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		slog.Error("DATABASE_URL is required")
+		os.Exit(1)
+	}
+
+	var gauges config.GaugeList
+	baseURL := flag.String("url", constants.DefraBaseURL, "Base URL of the Defra flood monitoring API")
+	interval := flag.Duration("interval", 0, "Poll on this interval until interrupted, or poll once and exit when 0")
+	lookback := flag.Duration("lookback", ingest.DefaultLookback, "How far back to fetch for a station or gauge with no readings yet")
+	flag.Var(&gauges, "gauge", "River gauge to poll as name=station-reference, repeatable")
+	flag.Parse()
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		slog.Error("db open", "err", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		slog.Error("db ping", "err", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client := ingest.NewClient(*baseURL, &http.Client{Timeout: 30 * time.Second})
+	ingester := ingest.New(client, postgres.NewIngestRepo(db), gauges, *lookback, slog.Default())
+
+	if *interval > 0 {
+		slog.Info("Polling", "url", *baseURL, "interval", *interval)
+		ingester.Run(ctx, *interval)
+		return
+	}
+
+	if _, err := ingester.RunOnce(ctx); err != nil {
+		slog.Error("ingest", "err", err)
+		os.Exit(1)
+	}
+}
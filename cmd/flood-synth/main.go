@@ -0,0 +1,140 @@
+// cmd/flood-synth/main.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+	"github.com/oliverslade/flood-api/internal/repository/postgres"
+	"github.com/oliverslade/flood-api/internal/repository/sqlite"
+	"github.com/oliverslade/flood-api/internal/synth"
+)
+
+const (
+	targetPostgres = "postgres"
+	targetSQLite   = "sqlite"
+	targetFixtures = "fixtures"
+)
+
+func main() {
+	defaults := synth.DefaultConfig()
+
+	target := flag.String("target", targetPostgres, "Where to write the readings: postgres (DATABASE_URL), sqlite or fixtures")
+	sqlitePath := flag.String("sqlite", "", "Path of the SQLite database to write with -target=sqlite, created if it doesn't exist")
+	fixturesPath := flag.String("fixtures", "", "Path to write fixtures to with -target=fixtures, a JSON file if it ends in .json and otherwise a directory of CSV files")
+	start := flag.String("start", defaults.Start.Format(time.DateOnly), "Date of the first reading (YYYY-MM-DD)")
+	years := flag.Int("years", 3, "Number of years of readings to generate")
+	interval := flag.Duration("interval", defaults.Interval, "Time between readings")
+	seed := flag.Uint64("seed", defaults.Seed, "Seed for the weather, the same seed always generates the same readings")
+	flag.Parse()
+
+	cfg := defaults
+	startDate, err := time.Parse(time.DateOnly, *start)
+	if err != nil {
+		slog.Error("-start must be a date in YYYY-MM-DD format", "start", *start)
+		os.Exit(1)
+	}
+	if *years < 1 {
+		slog.Error("-years must be at least 1")
+		os.Exit(1)
+	}
+	cfg.Start = startDate
+	cfg.End = startDate.AddDate(*years, 0, 0)
+	cfg.Interval = *interval
+	cfg.Seed = *seed
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	begun := time.Now()
+	if err := run(ctx, cfg, *target, *sqlitePath, *fixturesPath); err != nil {
+		slog.Error("synth", "err", err)
+		os.Exit(1)
+	}
+	slog.Info("Generated readings", "target", *target, "stations", len(cfg.Stations), "gauges", len(cfg.Catchments),
+		"from", cfg.Start.Format(time.DateOnly), "to", cfg.End.Format(time.DateOnly), "took", time.Since(begun).Round(time.Millisecond))
+}
+
+// run generates the readings into the target
+func run(ctx context.Context, cfg synth.Config, target, sqlitePath, fixturesPath string) error {
+	switch target {
+	case targetPostgres:
+		dbURL := os.Getenv("DATABASE_URL")
+		if dbURL == "" {
+			return errors.New("DATABASE_URL is required with -target=postgres")
+		}
+		db, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			return fmt.Errorf("db open: %w", err)
+		}
+		defer db.Close()
+
+		if err := addGauges(ctx, db, "INSERT INTO rivergauges (id, name, river) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", cfg); err != nil {
+			return err
+		}
+		w := synth.NewImportWriter(postgres.NewImportRepo(db))
+		if err := synth.Generate(ctx, cfg, w); err != nil {
+			return err
+		}
+		slog.Info("Imported readings", "new", w.Imported)
+		_, err = postgres.RefreshRollups(ctx, db)
+		return err
+	case targetSQLite:
+		if sqlitePath == "" {
+			return errors.New("-sqlite is required with -target=sqlite")
+		}
+		if err := sqlite.Migrate(ctx, sqlitePath); err != nil {
+			return fmt.Errorf("sqlite migrate: %w", err)
+		}
+		db, err := sqlite.Open(ctx, sqlitePath, false)
+		if err != nil {
+			return fmt.Errorf("sqlite open: %w", err)
+		}
+		defer db.Close()
+
+		if err := addGauges(ctx, db, "INSERT OR IGNORE INTO rivergauges (id, name, river) VALUES (?, ?, ?)", cfg); err != nil {
+			return err
+		}
+		// the challenge table has no unique id, so only stations it doesn't have are added
+		for _, station := range cfg.Stations {
+			_, err := db.ExecContext(ctx, "INSERT INTO stationnames (id, name) SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM stationnames WHERE id = ?)", station.ID, station.Name, station.ID)
+			if err != nil {
+				return fmt.Errorf("adding station %s: %w", station.Name, err)
+			}
+		}
+		return synth.Generate(ctx, cfg, synth.RepositoryWriter{River: sqlite.NewRiverRepo(db), Rainfall: sqlite.NewRainfallRepo(db)})
+	case targetFixtures:
+		if fixturesPath == "" {
+			return errors.New("-fixtures is required with -target=fixtures")
+		}
+		var w synth.DatasetWriter
+		if err := synth.Generate(ctx, cfg, &w); err != nil {
+			return err
+		}
+		return inmemory.WriteDataset(fixturesPath, w.Dataset)
+	default:
+		return fmt.Errorf("unknown target %q, want postgres, sqlite or fixtures", target)
+	}
+}
+
+// addGauges adds any catchment gauge the database doesn't have yet, using query's id, name and river placeholders
+func addGauges(ctx context.Context, db *sql.DB, query string, cfg synth.Config) error {
+	for _, catchment := range cfg.Catchments {
+		gauge := catchment.Gauge
+		if _, err := db.ExecContext(ctx, query, gauge.ID, gauge.Name, gauge.River); err != nil {
+			return fmt.Errorf("adding gauge %s: %w", gauge.Name, err)
+		}
+	}
+	return nil
+}
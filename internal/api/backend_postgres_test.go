@@ -0,0 +1,71 @@
+//go:build integration
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+	"github.com/oliverslade/flood-api/internal/repository/postgres"
+	"github.com/oliverslade/flood-api/internal/repository/postgres/postgrestest"
+	"github.com/oliverslade/flood-api/internal/repository/repositorytest"
+	"github.com/oliverslade/flood-api/migrations"
+)
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+func init() {
+	backends = append(backends, backend{name: "postgres", open: openPostgresBackend})
+}
+
+// TestMain stops the shared Postgres container once the handler tests have run
+func TestMain(m *testing.M) {
+	code := m.Run()
+	postgrestest.Cleanup()
+	os.Exit(code)
+}
+
+// openPostgresBackend empties the shared test database and seeds it with the fixtures, so the
+// handler tests must not run in parallel
+func openPostgresBackend(t *testing.T) repositorytest.Repositories {
+	db := postgrestest.GetTestDB(t)
+
+	migrateOnce.Do(func() {
+		migrator, err := migrations.New(postgrestest.GetTestDBConnString())
+		if err != nil {
+			migrateErr = err
+			return
+		}
+		defer migrator.Close()
+		migrateErr = migrator.Up()
+	})
+	require.NoError(t, migrateErr)
+
+	ctx := context.Background()
+	for _, query := range []string{
+		"DELETE FROM rainfalls",
+		"DELETE FROM riverlevels",
+		"DELETE FROM rivergauges",
+		"DELETE FROM stationnames",
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	repos := repositorytest.Repositories{
+		River:    postgres.NewRiverRepo(db),
+		Rainfall: postgres.NewRainfallRepo(db),
+		Station:  postgres.NewStationRepo(db),
+	}
+	repositorytest.Load(t, db, func(n int) string { return fmt.Sprintf("$%d", n) }, repos, inmemory.Fixtures())
+	return repos
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/repository"
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+	"github.com/oliverslade/flood-api/internal/repository/repositorytest"
+	"github.com/oliverslade/flood-api/internal/repository/sqlite"
+)
+
+// backend creates repositories holding the in-memory fixtures, so the handler tests can be run
+// against every storage backend. Each call returns a fresh copy of the fixtures.
+type backend struct {
+	name string
+	open func(t *testing.T) repositorytest.Repositories
+}
+
+func (b backend) river(t *testing.T) repository.RiverRepository {
+	return b.open(t).River
+}
+
+func (b backend) rainfall(t *testing.T) repository.RainfallRepository {
+	return b.open(t).Rainfall
+}
+
+func (b backend) station(t *testing.T) repository.StationRepository {
+	return b.open(t).Station
+}
+
+// backends is extended with Postgres when the integration tests are built
+var backends = []backend{
+	{name: "memory", open: func(t *testing.T) repositorytest.Repositories {
+		store := inmemory.NewStore(inmemory.Fixtures())
+		return repositorytest.Repositories{
+			River:    inmemory.NewRiverRepoFrom(store),
+			Rainfall: inmemory.NewRainfallRepoFrom(store),
+			Station:  inmemory.NewStationRepoFrom(store),
+		}
+	}},
+	{name: "sqlite", open: openSQLiteBackend},
+}
+
+// forEachBackend runs fn as a subtest per backend
+func forEachBackend(t *testing.T, fn func(t *testing.T, b backend)) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			fn(t, b)
+		})
+	}
+}
+
+func openSQLiteBackend(t *testing.T) repositorytest.Repositories {
+	path := filepath.Join(t.TempDir(), "flood.db")
+	require.NoError(t, sqlite.Migrate(context.Background(), path))
+	db, err := sqlite.Open(context.Background(), path, false)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	repos := repositorytest.Repositories{
+		River:    sqlite.NewRiverRepo(db),
+		Rainfall: sqlite.NewRainfallRepo(db),
+		Station:  sqlite.NewStationRepo(db),
+	}
+	repositorytest.Load(t, db, func(int) string { return "?" }, repos, inmemory.Fixtures())
+	return repos
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// exportFlushEvery is how many readings are written between flushes to the client
+const exportFlushEvery = 500
+
+// readingStream writes readings in the negotiated format as they arrive. Nothing is sent until the
+// first reading, so errors raised before then, like an unknown station, still get a proper status.
+type readingStream[T csvRecorder] struct {
+	w         http.ResponseWriter
+	rc        *http.ResponseController
+	format    string
+	csvHeader []string
+	csv       *csv.Writer
+	started   bool
+	written   int
+}
+
+func newReadingStream[T csvRecorder](w http.ResponseWriter, format string, csvHeader []string) *readingStream[T] {
+	s := newPageStream[T](w, format, csvHeader)
+	// exports can run far longer than the server's WriteTimeout, so lift it for this response only
+	_ = s.rc.SetWriteDeadline(time.Time{})
+	return s
+}
+
+// newPageStream writes a page of readings, which is capped in size so keeps the server's WriteTimeout
+func newPageStream[T csvRecorder](w http.ResponseWriter, format string, csvHeader []string) *readingStream[T] {
+	return &readingStream[T]{
+		w:         w,
+		rc:        http.NewResponseController(w),
+		format:    format,
+		csvHeader: csvHeader,
+	}
+}
+
+func (s *readingStream[T]) begin() error {
+	s.started = true
+	s.w.Header().Set("Content-Type", formatContentTypes[s.format])
+
+	switch s.format {
+	case formatCSV:
+		s.csv = csv.NewWriter(s.w)
+		return s.csv.Write(s.csvHeader)
+	case formatNDJSON:
+		return nil
+	default:
+		_, err := io.WriteString(s.w, `{"readings":[`)
+		return err
+	}
+}
+
+// write sends one reading, flushing every exportFlushEvery readings. Writes block while the
+// client is slow to read, which holds back the repository from fetching more.
+func (s *readingStream[T]) write(reading T) error {
+	if !s.started {
+		if err := s.begin(); err != nil {
+			return err
+		}
+	}
+
+	switch s.format {
+	case formatCSV:
+		if err := s.csv.Write(reading.CSVRecord()); err != nil {
+			return err
+		}
+	case formatNDJSON:
+		if err := json.NewEncoder(s.w).Encode(reading); err != nil {
+			return err
+		}
+	default:
+		b, err := json.Marshal(reading)
+		if err != nil {
+			return err
+		}
+		if s.written > 0 {
+			b = append([]byte{','}, b...)
+		}
+		if _, err := s.w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	s.written++
+	if s.written%exportFlushEvery == 0 {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *readingStream[T]) flush() error {
+	if s.csv != nil {
+		s.csv.Flush()
+		if err := s.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// close finishes the document, writing an empty one if there were no readings
+func (s *readingStream[T]) close() error {
+	if !s.started {
+		if err := s.begin(); err != nil {
+			return err
+		}
+	}
+	if s.format == formatJSON {
+		if _, err := io.WriteString(s.w, "]}\n"); err != nil {
+			return err
+		}
+	}
+	return s.flush()
+}
+
+// fail reports an error from filling the stream. Until the first reading is sent the client still
+// gets a status: 404 with notFound for an unknown gauge or station, 400 naming any unknown stations
+// or otherwise 500. After that the status is already out, so the connection is aborted to stop the
+// client taking the cut short response for a complete one.
+func (s *readingStream[T]) fail(span trace.Span, logger *slog.Logger, notFound string, err error) {
+	if !s.started {
+		var unknown *domain.UnknownStationsError
+		switch {
+		case err == domain.ErrNotFound:
+			logger.Warn(notFound, "error", err)
+			http.Error(s.w, notFound, http.StatusNotFound)
+		case errors.As(err, &unknown):
+			logger.Warn("Unknown stations", "stations", unknown.Names)
+			s.w.Header().Set("Content-Type", "application/json")
+			s.w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(s.w).Encode(map[string]string{"error": "Unknown station: " + strings.Join(unknown.Names, ", ")})
+		default:
+			logger.Error("Error streaming readings", "error", err, "format", s.format)
+			failSpan(span, err)
+			http.Error(s.w, "Internal server error when streaming readings", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	logger.Error("Streaming readings aborted", "error", err, "format", s.format, "written", s.written)
+	failSpan(span, err)
+	panic(http.ErrAbortHandler)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Response formats supported by the readings endpoints
+const (
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+var formatContentTypes = map[string]string{
+	formatJSON:   "application/json",
+	formatCSV:    "text/csv; charset=utf-8",
+	formatNDJSON: "application/x-ndjson",
+}
+
+var mediaTypeFormats = map[string]string{
+	"application/json":     formatJSON,
+	"text/csv":             formatCSV,
+	"application/x-ndjson": formatNDJSON,
+	"application/*":        formatJSON,
+	"*/*":                  formatJSON,
+}
+
+// NegotiateFormat picks the response format from the format query override or the Accept header.
+// Clients that accept none of the supported types get JSON rather than a 406.
+func NegotiateFormat(r *http.Request) (string, string) {
+	if formatParam := r.URL.Query().Get("format"); formatParam != "" {
+		if _, ok := formatContentTypes[formatParam]; !ok {
+			return "", "Format must be one of json, csv or ndjson"
+		}
+		return formatParam, ""
+	}
+
+	best, bestQ := formatJSON, 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		format, ok := mediaTypeFormats[mediaType]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if qParam, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(qParam, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+
+	return best, ""
+}
+
+type csvRecorder interface {
+	CSVRecord() []string
+}
@@ -2,24 +2,58 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/oliverslade/flood-api/internal/constants"
 	"github.com/oliverslade/flood-api/internal/domain"
 )
 
-// adds a 5s timeout to all requests
-func TimeoutMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-		defer cancel()
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+const dateLayout = "2006-01-02"
+
+// Timeout cancels the context of every request after d
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireBearerToken only lets through requests with an Authorization: Bearer header carrying token
+func RequireBearerToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="flood-api"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-func ParsePaginationParams(r *http.Request) (domain.PaginationParams, string) {
+// Pagination is the page size served when none is asked for, and the most that can be asked for
+type Pagination struct {
+	DefaultPageSize int
+	MaxPageSize     int
+}
+
+// DefaultPagination is what handlers serve until told otherwise
+var DefaultPagination = Pagination{DefaultPageSize: constants.DefaultPageSize, MaxPageSize: constants.MaxPageSize}
+
+func ParsePaginationParams(r *http.Request, pagination Pagination) (domain.PaginationParams, string) {
 	q := r.URL.Query()
 
 	page := 1
@@ -31,31 +65,210 @@ func ParsePaginationParams(r *http.Request) (domain.PaginationParams, string) {
 		page = p
 	}
 
-	pageSize := constants.DefaultPageSize
+	pageSize := pagination.DefaultPageSize
 	if pageSizeParam := q.Get("pagesize"); pageSizeParam != "" {
 		ps, err := strconv.Atoi(pageSizeParam)
 		if err != nil || ps <= 0 {
 			return domain.PaginationParams{}, "Page size must be a positive integer"
 		}
-		if ps > constants.MaxPageSize {
-			ps = constants.MaxPageSize
+		if ps > pagination.MaxPageSize {
+			ps = pagination.MaxPageSize
 		}
 		pageSize = ps
 	}
 
-	return domain.PaginationParams{Page: page, PageSize: pageSize}, ""
+	var after *time.Time
+	if cursorParam := q.Get("cursor"); cursorParam != "" {
+		if q.Get("page") != "" {
+			return domain.PaginationParams{}, "Use either page or cursor, not both"
+		}
+		c, err := decodeCursor(cursorParam)
+		if err != nil {
+			return domain.PaginationParams{}, "Cursor is invalid"
+		}
+		after = &c
+	}
+
+	return domain.PaginationParams{Page: page, PageSize: pageSize, After: after}, ""
+}
+
+// ParseStationNames reads the repeatable station param, dropping duplicates.
+// A * selects every station, which is returned as nil names.
+func ParseStationNames(r *http.Request) ([]string, string) {
+	values := r.URL.Query()["station"]
+	if len(values) == 0 {
+		return nil, "At least one station is required"
+	}
+
+	seen := map[string]bool{}
+	names := []string{}
+	for _, name := range values {
+		if name == "*" {
+			return nil, ""
+		}
+		if name == "" {
+			return nil, "Station must not be empty"
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names, ""
+}
+
+// ParseAggregateParams reads the required bucket interval and aggregate function
+func ParseAggregateParams(r *http.Request) (string, string, string) {
+	q := r.URL.Query()
+
+	interval := q.Get("interval")
+	switch interval {
+	case domain.IntervalHour, domain.IntervalDay, domain.IntervalWeek, domain.IntervalMonth:
+	default:
+		return "", "", "Interval must be one of hour, day, week or month"
+	}
+
+	fn := q.Get("fn")
+	switch fn {
+	case domain.AggregateSum, domain.AggregateMean, domain.AggregateMax, domain.AggregateMin:
+	default:
+		return "", "", "Fn must be one of sum, mean, max or min"
+	}
+
+	return interval, fn, ""
 }
 
-func ParseStartDate(r *http.Request) (*time.Time, string) {
-	startDateParam := r.URL.Query().Get("start")
-	if startDateParam == "" {
-		return nil, ""
+// latestReading pairs a reading with how many seconds ago it was taken
+type latestReading struct {
+	Reading    interface{} `json:"reading"`
+	AgeSeconds int64       `json:"age_seconds"`
+}
+
+func newLatestReading(reading interface{}, timestamp, now time.Time) latestReading {
+	return latestReading{
+		Reading:    reading,
+		AgeSeconds: int64(now.Sub(timestamp) / time.Second),
 	}
+}
 
-	startDate, err := time.Parse("2006-01-02", startDateParam)
+// cursors are opaque to clients, the payload is the timestamp of the last reading seen
+func encodeCursor(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(t.UTC().Format(time.RFC3339Nano)))
+}
+
+func decodeCursor(cursor string) (time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
 	if err != nil {
-		return nil, "Start date must be in format YYYY-MM-DD"
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, string(raw))
+}
+
+// ParseDateRange reads the optional time bounds of a readings query.
+// Bounds are given either as dates (start/end, YYYY-MM-DD) or as RFC 3339
+// datetimes (from/to). The lower bound is inclusive and the upper bound is
+// exclusive, so end=2023-01-07 covers the whole of the 7th.
+func ParseDateRange(r *http.Request) (*time.Time, *time.Time, string) {
+	q := r.URL.Query()
+
+	if q.Get("start") != "" && q.Get("from") != "" {
+		return nil, nil, "Use either start or from, not both"
+	}
+	if q.Get("end") != "" && q.Get("to") != "" {
+		return nil, nil, "Use either end or to, not both"
+	}
+
+	var startDate, endDate *time.Time
+
+	if startParam := q.Get("start"); startParam != "" {
+		start, err := time.Parse(dateLayout, startParam)
+		if err != nil {
+			return nil, nil, "Start date must be in format YYYY-MM-DD"
+		}
+		startDate = &start
+	}
+
+	if fromParam := q.Get("from"); fromParam != "" {
+		from, err := time.Parse(time.RFC3339, fromParam)
+		if err != nil {
+			return nil, nil, "From must be an RFC 3339 datetime"
+		}
+		from = from.UTC()
+		startDate = &from
+	}
+
+	if endParam := q.Get("end"); endParam != "" {
+		end, err := time.Parse(dateLayout, endParam)
+		if err != nil {
+			return nil, nil, "End date must be in format YYYY-MM-DD"
+		}
+		// the end date is inclusive, so the exclusive bound is midnight the day after
+		end = end.AddDate(0, 0, 1)
+		endDate = &end
+	}
+
+	if toParam := q.Get("to"); toParam != "" {
+		to, err := time.Parse(time.RFC3339, toParam)
+		if err != nil {
+			return nil, nil, "To must be an RFC 3339 datetime"
+		}
+		to = to.UTC()
+		endDate = &to
+	}
+
+	if startDate != nil && endDate != nil && !startDate.Before(*endDate) {
+		return nil, nil, "Start of date range must be before its end"
+	}
+
+	return startDate, endDate, ""
+}
+
+// maxReadingsBodyBytes comfortably fits a full batch of readings
+const maxReadingsBodyBytes = 1 << 20
+
+type readingsBody struct {
+	Readings []struct {
+		Timestamp string   `json:"timestamp"`
+		Level     *float64 `json:"level"`
+	} `json:"readings"`
+}
+
+// ParseReadingsBody decodes a {"readings": [{"timestamp", "level"}]} batch to write, validating every reading
+func ParseReadingsBody(w http.ResponseWriter, r *http.Request) ([]domain.Measurement, string) {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReadingsBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	var body readingsBody
+	if err := decoder.Decode(&body); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, "Request body is too large"
+		}
+		return nil, "Request body must be JSON with a readings array"
+	}
+
+	if len(body.Readings) == 0 {
+		return nil, "At least one reading is required"
+	}
+	if len(body.Readings) > constants.MaxWriteBatchSize {
+		return nil, fmt.Sprintf("At most %d readings can be written at once", constants.MaxWriteBatchSize)
+	}
+
+	readings := make([]domain.Measurement, 0, len(body.Readings))
+	for i, reading := range body.Readings {
+		timestamp, err := time.Parse(time.RFC3339, reading.Timestamp)
+		if err != nil {
+			return nil, fmt.Sprintf("Timestamp of readings[%d] must be an RFC 3339 datetime", i)
+		}
+		if reading.Level == nil {
+			return nil, fmt.Sprintf("Level of readings[%d] is required", i)
+		}
+		if *reading.Level < 0 {
+			return nil, fmt.Sprintf("Level of readings[%d] must not be negative", i)
+		}
+		readings = append(readings, domain.Measurement{Timestamp: timestamp.UTC(), Level: *reading.Level})
 	}
 
-	return &startDate, ""
+	return readings, ""
 }
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// pageMeta holds the opt-in totals for a paginated response
+type pageMeta struct {
+	Total      int64
+	TotalPages int64
+}
+
+func newPageMeta(total int64, pageSize int) *pageMeta {
+	return &pageMeta{
+		Total:      total,
+		TotalPages: (total + int64(pageSize) - 1) / int64(pageSize),
+	}
+}
+
+// ParseCountFlag reports whether the client asked for total counts, which cost an extra query
+func ParseCountFlag(r *http.Request) (bool, string) {
+	countParam := r.URL.Query().Get("count")
+	if countParam == "" {
+		return false, ""
+	}
+
+	withCount, err := strconv.ParseBool(countParam)
+	if err != nil {
+		return false, "Count must be true or false"
+	}
+
+	return withCount, ""
+}
+
+// addPageMeta adds the pagination totals to a response envelope
+func addPageMeta(response map[string]interface{}, pagination domain.PaginationParams, meta *pageMeta) {
+	response["total"] = meta.Total
+	response["pagesize"] = pagination.PageSize
+	response["total_pages"] = meta.TotalPages
+	// a cursor has no page number
+	if pagination.After == nil {
+		response["page"] = pagination.Page
+	}
+}
+
+// setLinkHeader emits RFC 8288 links to the neighbouring pages of the request.
+// Without counted totals the last page is unknown, so next is only a hint that the current page was full.
+func setLinkHeader(w http.ResponseWriter, r *http.Request, pagination domain.PaginationParams, pageFull bool, nextCursor string, meta *pageMeta) {
+	links := []string{formatLink(pageURL(r, 1), "first")}
+
+	if pagination.After != nil {
+		if pageFull {
+			links = append(links, formatLink(cursorURL(r, nextCursor), "next"))
+		}
+	} else {
+		if pagination.Page > 1 {
+			links = append(links, formatLink(pageURL(r, pagination.Page-1), "prev"))
+		}
+		hasNext := pageFull
+		if meta != nil {
+			hasNext = int64(pagination.Page) < meta.TotalPages
+		}
+		if hasNext {
+			links = append(links, formatLink(pageURL(r, pagination.Page+1), "next"))
+		}
+	}
+
+	if meta != nil {
+		lastPage := meta.TotalPages
+		if lastPage < 1 {
+			lastPage = 1
+		}
+		links = append(links, formatLink(pageURL(r, int(lastPage)), "last"))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// pageLinks returns the start callback of a streamed page, which sets the Link header from the
+// page's bounds before its first reading is written. CSV and NDJSON have no envelope to carry a
+// next_cursor or totals, so the Link header is the only pagination they get.
+func pageLinks(w http.ResponseWriter, r *http.Request, pagination domain.PaginationParams, meta *pageMeta) func(domain.PageBounds) error {
+	return func(bounds domain.PageBounds) error {
+		var cursor string
+		if bounds.Count > 0 && bounds.Count == pagination.PageSize {
+			cursor = encodeCursor(bounds.Last)
+		}
+		setLinkHeader(w, r, pagination, cursor != "", cursor, meta)
+		return nil
+	}
+}
+
+func formatLink(target, rel string) string {
+	return fmt.Sprintf("<%s>; rel=%q", target, rel)
+}
+
+// pageURL returns the request URL moved to an offset page, keeping the other query params
+func pageURL(r *http.Request, page int) string {
+	q := r.URL.Query()
+	q.Del("cursor")
+	q.Set("page", strconv.Itoa(page))
+	return r.URL.Path + "?" + q.Encode()
+}
+
+// cursorURL returns the request URL moved to a keyset cursor, keeping the other query params
+func cursorURL(r *http.Request, cursor string) string {
+	q := r.URL.Query()
+	q.Del("page")
+	q.Set("cursor", cursor)
+	return r.URL.Path + "?" + q.Encode()
+}
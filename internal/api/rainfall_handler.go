@@ -2,39 +2,66 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/oliverslade/flood-api/internal/domain"
 	"github.com/oliverslade/flood-api/internal/repository"
+	"github.com/oliverslade/flood-api/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type RainfallHandler struct {
 	repo   repository.RainfallRepository
 	logger *slog.Logger
+	now    func() time.Time
+	// Pagination sizes the pages of readings, DefaultPagination unless changed before serving
+	Pagination Pagination
 }
 
 func NewRainfallHandler(repo repository.RainfallRepository, logger *slog.Logger) *RainfallHandler {
 	return &RainfallHandler{
-		repo:   repo,
-		logger: logger,
+		repo:       repo,
+		logger:     logger,
+		now:        time.Now,
+		Pagination: DefaultPagination,
 	}
 }
 
 func (h *RainfallHandler) GetReadingsByStation(w http.ResponseWriter, r *http.Request) {
 	stationName := chi.URLParam(r, "station")
+	r, span := startSpan(r, "RainfallHandler.GetReadingsByStation", tracing.StationKey.String(stationName))
+	defer span.End()
 
-	pagination, errMsg := ParsePaginationParams(r)
+	pagination, errMsg := ParsePaginationParams(r, h.Pagination)
 	if errMsg != "" {
 		h.logger.Warn("Invalid pagination params", "error", errMsg)
 		h.returnBadRequest(w, errMsg)
 		return
 	}
+	span.SetAttributes(paginationAttributes(pagination)...)
 
-	startDate, errMsg := ParseStartDate(r)
+	format, errMsg := NegotiateFormat(r)
 	if errMsg != "" {
-		h.logger.Warn("Invalid start date", "error", errMsg)
+		h.logger.Warn("Invalid response format", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	withCount, errMsg := ParseCountFlag(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid count flag", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	startDate, endDate, errMsg := ParseDateRange(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid date range", "error", errMsg)
 		h.returnBadRequest(w, errMsg)
 		return
 	}
@@ -44,9 +71,16 @@ func (h *RainfallHandler) GetReadingsByStation(w http.ResponseWriter, r *http.Re
 		GetReadingsParams: domain.GetReadingsParams{
 			Pagination: pagination,
 			StartDate:  startDate,
+			EndDate:    endDate,
 		},
 	}
 
+	w.Header().Set("Vary", "Accept")
+	if format != formatJSON {
+		h.streamReadingsByStation(w, r, span, params, format, withCount)
+		return
+	}
+
 	readings, err := h.repo.GetReadingsByStation(r.Context(), params)
 	if err != nil {
 		if err == domain.ErrNotFound {
@@ -55,20 +89,389 @@ func (h *RainfallHandler) GetReadingsByStation(w http.ResponseWriter, r *http.Re
 			return
 		}
 		h.logger.Error("Error fetching readings", "error", err)
+		failSpan(span, err)
 		http.Error(w, "Internal server error when getting readings", http.StatusInternalServerError)
 		return
 	}
 
+	span.SetAttributes(tracing.RowCountKey.Int(len(readings)))
 	response := map[string]interface{}{
 		"readings": readings,
 	}
 
+	// a full page means there may be more readings, so hand out a cursor to seek past it
+	var cursor string
+	if n := len(readings); n > 0 && n == pagination.PageSize {
+		cursor = encodeCursor(readings[n-1].Timestamp)
+		response["next_cursor"] = cursor
+	}
+
+	var meta *pageMeta
+	if withCount {
+		total, err := h.repo.CountReadingsByStation(r.Context(), params)
+		if err != nil {
+			h.logger.Error("Error counting readings", "error", err)
+			failSpan(span, err)
+			http.Error(w, "Internal server error when counting readings", http.StatusInternalServerError)
+			return
+		}
+		meta = newPageMeta(total, pagination.PageSize)
+		addPageMeta(response, pagination, meta)
+	}
+
+	setLinkHeader(w, r, pagination, cursor != "", cursor, meta)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Error encoding response", "error", err, "format", format)
+	}
+}
+
+// streamReadingsByStation writes a page of readings as CSV or NDJSON as the repository reads them.
+// The totals are counted first so the Link header can point at the last page before any reading is sent.
+func (h *RainfallHandler) streamReadingsByStation(w http.ResponseWriter, r *http.Request, span trace.Span, params domain.GetRainfallParams, format string, withCount bool) {
+	var meta *pageMeta
+	if withCount {
+		total, err := h.repo.CountReadingsByStation(r.Context(), params)
+		if err != nil {
+			if err == domain.ErrNotFound {
+				h.logger.Warn("Station not found", "station", params.StationName)
+				http.Error(w, "Station not found", http.StatusNotFound)
+				return
+			}
+			h.logger.Error("Error counting readings", "error", err)
+			failSpan(span, err)
+			http.Error(w, "Internal server error when counting readings", http.StatusInternalServerError)
+			return
+		}
+		meta = newPageMeta(total, params.Pagination.PageSize)
+	}
+
+	stream := newPageStream[domain.RainfallReading](w, format, domain.RainfallReadingCSVHeader)
+	err := h.repo.StreamReadingsPageByStation(r.Context(), params, pageLinks(w, r, params.Pagination, meta), stream.write)
+	if err == nil {
+		err = stream.close()
+	}
+	span.SetAttributes(tracing.RowCountKey.Int(stream.written))
+	if err != nil {
+		stream.fail(span, h.logger, "Station not found", err)
+	}
+}
+
+// GetReadingsByStations returns readings from several stations merged in chronological order
+func (h *RainfallHandler) GetReadingsByStations(w http.ResponseWriter, r *http.Request) {
+	stationNames, errMsg := ParseStationNames(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid stations", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+	r, span := startSpan(r, "RainfallHandler.GetReadingsByStations", tracing.StationKey.StringSlice(stationNames))
+	defer span.End()
+
+	pagination, errMsg := ParsePaginationParams(r, h.Pagination)
+	if errMsg != "" {
+		h.logger.Warn("Invalid pagination params", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+	span.SetAttributes(paginationAttributes(pagination)...)
+	// stations share timestamps, so a timestamp cursor can't mark a position in the merged series
+	if pagination.After != nil {
+		errMsg = "Cursor is not supported across several stations, use page instead"
+		h.logger.Warn("Invalid pagination params", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	format, errMsg := NegotiateFormat(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid response format", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	withCount, errMsg := ParseCountFlag(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid count flag", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	startDate, endDate, errMsg := ParseDateRange(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid date range", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	params := domain.GetRainfallStationsParams{
+		StationNames: stationNames,
+		GetReadingsParams: domain.GetReadingsParams{
+			Pagination: pagination,
+			StartDate:  startDate,
+			EndDate:    endDate,
+		},
+	}
+
+	w.Header().Set("Vary", "Accept")
+	if format != formatJSON {
+		h.streamReadingsByStations(w, r, span, params, format, withCount)
+		return
+	}
+
+	readings, err := h.repo.GetReadingsByStations(r.Context(), params)
+	if err != nil {
+		var unknown *domain.UnknownStationsError
+		if errors.As(err, &unknown) {
+			h.logger.Warn("Unknown stations", "stations", unknown.Names)
+			h.returnBadRequest(w, "Unknown station: "+strings.Join(unknown.Names, ", "))
+			return
+		}
+		h.logger.Error("Error fetching readings", "error", err)
+		failSpan(span, err)
+		http.Error(w, "Internal server error when getting readings", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetAttributes(tracing.RowCountKey.Int(len(readings)))
+	response := map[string]interface{}{
+		"readings": readings,
+	}
+
+	var meta *pageMeta
+	if withCount {
+		total, err := h.repo.CountReadingsByStations(r.Context(), params)
+		if err != nil {
+			h.logger.Error("Error counting readings", "error", err)
+			failSpan(span, err)
+			http.Error(w, "Internal server error when counting readings", http.StatusInternalServerError)
+			return
+		}
+		meta = newPageMeta(total, pagination.PageSize)
+		addPageMeta(response, pagination, meta)
+	}
+
+	pageFull := len(readings) > 0 && len(readings) == pagination.PageSize
+	setLinkHeader(w, r, pagination, pageFull, "", meta)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Error encoding response", "error", err, "format", format)
+	}
+}
+
+// streamReadingsByStations writes a page of merged readings as CSV or NDJSON like streamReadingsByStation
+func (h *RainfallHandler) streamReadingsByStations(w http.ResponseWriter, r *http.Request, span trace.Span, params domain.GetRainfallStationsParams, format string, withCount bool) {
+	var meta *pageMeta
+	if withCount {
+		total, err := h.repo.CountReadingsByStations(r.Context(), params)
+		if err != nil {
+			var unknown *domain.UnknownStationsError
+			if errors.As(err, &unknown) {
+				h.logger.Warn("Unknown stations", "stations", unknown.Names)
+				h.returnBadRequest(w, "Unknown station: "+strings.Join(unknown.Names, ", "))
+				return
+			}
+			h.logger.Error("Error counting readings", "error", err)
+			failSpan(span, err)
+			http.Error(w, "Internal server error when counting readings", http.StatusInternalServerError)
+			return
+		}
+		meta = newPageMeta(total, params.Pagination.PageSize)
+	}
+
+	stream := newPageStream[domain.RainfallReading](w, format, domain.RainfallReadingCSVHeader)
+	err := h.repo.StreamReadingsPageByStations(r.Context(), params, pageLinks(w, r, params.Pagination, meta), stream.write)
+	if err == nil {
+		err = stream.close()
+	}
+	span.SetAttributes(tracing.RowCountKey.Int(stream.written))
+	if err != nil {
+		stream.fail(span, h.logger, "Station not found", err)
+	}
+}
+
+// ExportReadingsByStation streams every rainfall reading for a station within the optional date range, with no page size cap
+func (h *RainfallHandler) ExportReadingsByStation(w http.ResponseWriter, r *http.Request) {
+	stationName := chi.URLParam(r, "station")
+	r, span := startSpan(r, "RainfallHandler.ExportReadingsByStation", tracing.StationKey.String(stationName))
+	defer span.End()
+
+	format, errMsg := NegotiateFormat(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid response format", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	startDate, endDate, errMsg := ParseDateRange(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid date range", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	params := domain.ExportRainfallParams{
+		StationName: stationName,
+		ExportParams: domain.ExportParams{
+			StartDate: startDate,
+			EndDate:   endDate,
+		},
+	}
+
+	w.Header().Set("Vary", "Accept")
+	stream := newReadingStream[domain.RainfallReading](w, format, domain.RainfallReadingCSVHeader)
+	err := h.repo.StreamReadingsByStation(r.Context(), params, stream.write)
+	if err == nil {
+		err = stream.close()
+	}
+	span.SetAttributes(tracing.RowCountKey.Int(stream.written))
+	if err != nil {
+		stream.fail(span, h.logger, "Station not found", err)
+	}
+}
+
+func (h *RainfallHandler) GetAggregatesByStation(w http.ResponseWriter, r *http.Request) {
+	stationName := chi.URLParam(r, "station")
+	r, span := startSpan(r, "RainfallHandler.GetAggregatesByStation", tracing.StationKey.String(stationName))
+	defer span.End()
+
+	interval, fn, errMsg := ParseAggregateParams(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid aggregate params", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	startDate, endDate, errMsg := ParseDateRange(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid date range", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	params := domain.GetRainfallAggregateParams{
+		StationName: stationName,
+		AggregateParams: domain.AggregateParams{
+			Interval:  interval,
+			Func:      fn,
+			StartDate: startDate,
+			EndDate:   endDate,
+		},
+	}
+
+	buckets, err := h.repo.GetAggregatesByStation(r.Context(), params)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			h.logger.Warn("Station not found", "station", stationName)
+			http.Error(w, "Station not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Error aggregating readings", "error", err)
+		failSpan(span, err)
+		http.Error(w, "Internal server error when aggregating readings", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetAttributes(tracing.RowCountKey.Int(len(buckets)))
+	response := map[string]interface{}{
+		"station":  stationName,
+		"interval": interval,
+		"fn":       fn,
+		"buckets":  buckets,
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		h.logger.Error("Error encoding response", "error", err)
 	}
 }
 
+func (h *RainfallHandler) GetLatestReadingByStation(w http.ResponseWriter, r *http.Request) {
+	stationName := chi.URLParam(r, "station")
+	r, span := startSpan(r, "RainfallHandler.GetLatestReadingByStation", tracing.StationKey.String(stationName))
+	defer span.End()
+
+	reading, err := h.repo.GetLatestReadingByStation(r.Context(), stationName)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			h.logger.Warn("No readings found for station", "station", stationName)
+			http.Error(w, "No readings found for station", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Error fetching latest reading", "error", err)
+		failSpan(span, err)
+		http.Error(w, "Internal server error when getting latest reading", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newLatestReading(reading, reading.Timestamp, h.now())); err != nil {
+		h.logger.Error("Error encoding response", "error", err)
+	}
+}
+
+func (h *RainfallHandler) GetLatestReadings(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "RainfallHandler.GetLatestReadings")
+	defer span.End()
+
+	readings, err := h.repo.GetLatestReadings(r.Context())
+	if err != nil {
+		h.logger.Error("Error fetching latest readings", "error", err)
+		failSpan(span, err)
+		http.Error(w, "Internal server error when getting latest readings", http.StatusInternalServerError)
+		return
+	}
+
+	now := h.now()
+	span.SetAttributes(tracing.RowCountKey.Int(len(readings)))
+
+	latest := make([]latestReading, len(readings))
+	for i, reading := range readings {
+		latest[i] = newLatestReading(reading, reading.Timestamp, now)
+	}
+
+	response := map[string]interface{}{
+		"readings": latest,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Error encoding response", "error", err)
+	}
+}
+
+// PostReadingsByStation upserts a batch of rainfall readings for a station, so a retried batch has the same effect as one
+func (h *RainfallHandler) PostReadingsByStation(w http.ResponseWriter, r *http.Request) {
+	stationName := chi.URLParam(r, "station")
+	r, span := startSpan(r, "RainfallHandler.PostReadingsByStation", tracing.StationKey.String(stationName))
+	defer span.End()
+
+	readings, errMsg := ParseReadingsBody(w, r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid readings", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	if err := h.repo.UpsertReadingsByStation(r.Context(), stationName, readings); err != nil {
+		if err == domain.ErrNotFound {
+			h.logger.Warn("Station not found", "station", stationName)
+			http.Error(w, "Station not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Error writing readings", "error", err)
+		failSpan(span, err)
+		http.Error(w, "Internal server error when writing readings", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Readings written", "station", stationName, "count", len(readings))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"station": stationName, "upserted": len(readings)}); err != nil {
+		h.logger.Error("Error encoding response", "error", err)
+	}
+}
+
 func (h *RainfallHandler) returnBadRequest(w http.ResponseWriter, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusBadRequest)
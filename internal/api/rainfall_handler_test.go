@@ -8,11 +8,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/oliverslade/flood-api/internal/domain"
-	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+	"github.com/oliverslade/flood-api/internal/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -23,138 +25,825 @@ func (m *mockRainfallErrorRepo) GetReadingsByStation(ctx context.Context, params
 	return nil, fmt.Errorf("repository error")
 }
 
+func (m *mockRainfallErrorRepo) StreamReadingsPageByStation(ctx context.Context, params domain.GetRainfallParams, start func(domain.PageBounds) error, fn func(domain.RainfallReading) error) error {
+	return fmt.Errorf("repository error")
+}
+
+func (m *mockRainfallErrorRepo) CountReadingsByStation(ctx context.Context, params domain.GetRainfallParams) (int64, error) {
+	return 0, fmt.Errorf("repository error")
+}
+
+func (m *mockRainfallErrorRepo) GetReadingsByStations(ctx context.Context, params domain.GetRainfallStationsParams) ([]domain.RainfallReading, error) {
+	return nil, fmt.Errorf("repository error")
+}
+
+func (m *mockRainfallErrorRepo) StreamReadingsPageByStations(ctx context.Context, params domain.GetRainfallStationsParams, start func(domain.PageBounds) error, fn func(domain.RainfallReading) error) error {
+	return fmt.Errorf("repository error")
+}
+
+func (m *mockRainfallErrorRepo) CountReadingsByStations(ctx context.Context, params domain.GetRainfallStationsParams) (int64, error) {
+	return 0, fmt.Errorf("repository error")
+}
+
+func (m *mockRainfallErrorRepo) GetAggregatesByStation(ctx context.Context, params domain.GetRainfallAggregateParams) ([]domain.AggregateBucket, error) {
+	return nil, fmt.Errorf("repository error")
+}
+
+func (m *mockRainfallErrorRepo) GetLatestReadingByStation(ctx context.Context, stationName string) (domain.RainfallReading, error) {
+	return domain.RainfallReading{}, fmt.Errorf("repository error")
+}
+
+func (m *mockRainfallErrorRepo) GetLatestReadings(ctx context.Context) ([]domain.RainfallReading, error) {
+	return nil, fmt.Errorf("repository error")
+}
+
+func (m *mockRainfallErrorRepo) StreamReadingsByStation(ctx context.Context, params domain.ExportRainfallParams, fn func(domain.RainfallReading) error) error {
+	return fmt.Errorf("repository error")
+}
+
+func (m *mockRainfallErrorRepo) UpsertReadingsByStation(ctx context.Context, stationName string, readings []domain.Measurement) error {
+	return fmt.Errorf("repository error")
+}
+
 func TestRainfallHandler_GetReadingsByStation(t *testing.T) {
-	t.Run("returns readings successfully for valid station", func(t *testing.T) {
-		repo := inmemory.NewRainfallRepo()
-		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRainfallHandler(repo, logger)
+	forEachBackend(t, func(t *testing.T, b backend) {
+		t.Run("returns readings successfully for valid station", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
 
-		router := chi.NewRouter()
-		router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
 
-		req, err := http.NewRequest("GET", "/rainfall/catcleugh", nil)
-		require.NoError(t, err)
+			var response struct {
+				Readings []struct {
+					Timestamp string  `json:"timestamp"`
+					Station   string  `json:"station"`
+					Level     float64 `json:"level"`
+				} `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
 
-		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+			require.Len(t, response.Readings, 3)
+			assert.Equal(t, "2024-01-01T09:00:00Z", response.Readings[0].Timestamp)
+			assert.Equal(t, "catcleugh", response.Readings[0].Station)
+			assert.Equal(t, 2.1, response.Readings[0].Level)
+		})
 
-		assert.Equal(t, http.StatusOK, rr.Code)
-		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		t.Run("returns 404 for non-existent station", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
 
-		var response struct {
-			Readings []struct {
-				Timestamp string  `json:"timestamp"`
-				Station   string  `json:"station"`
-				Level     float64 `json:"level"`
-			} `json:"readings"`
-		}
-		err = json.Unmarshal(rr.Body.Bytes(), &response)
-		require.NoError(t, err)
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/non-existent", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Station not found")
+		})
+
+		t.Run("returns paginated readings", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh?page=2&pagesize=1", nil)
+			require.NoError(t, err)
 
-		require.Len(t, response.Readings, 3)
-		assert.Equal(t, "2024-01-01T09:00:00Z", response.Readings[0].Timestamp)
-		assert.Equal(t, "catcleugh", response.Readings[0].Station)
-		assert.Equal(t, 2.1, response.Readings[0].Level)
-	})
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
 
-	t.Run("returns 404 for non-existent station", func(t *testing.T) {
-		repo := inmemory.NewRainfallRepo()
-		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRainfallHandler(repo, logger)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Readings []struct {
+					Timestamp string  `json:"timestamp"`
+					Station   string  `json:"station"`
+					Level     float64 `json:"level"`
+				} `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
 
-		router := chi.NewRouter()
-		router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+			require.Len(t, response.Readings, 1)
+			assert.Equal(t, "2024-01-02T10:00:00Z", response.Readings[0].Timestamp)
+			assert.Equal(t, 2.2, response.Readings[0].Level)
+		})
 
-		req, err := http.NewRequest("GET", "/rainfall/non-existent", nil)
-		require.NoError(t, err)
+		t.Run("validates invalid page parameter", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
 
-		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
 
-		assert.Equal(t, http.StatusNotFound, rr.Code)
-		assert.Contains(t, rr.Body.String(), "Station not found")
-	})
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh?page=-1", nil)
+			require.NoError(t, err)
 
-	t.Run("returns paginated readings", func(t *testing.T) {
-		repo := inmemory.NewRainfallRepo()
-		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRainfallHandler(repo, logger)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
 
-		router := chi.NewRouter()
-		router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
 
-		req, err := http.NewRequest("GET", "/rainfall/catcleugh?page=2&pagesize=1", nil)
-		require.NoError(t, err)
+		t.Run("validates invalid start date format", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
 
-		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
 
-		assert.Equal(t, http.StatusOK, rr.Code)
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh?start=invalid", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+
+		t.Run("filters readings by date range", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
 
-		var response struct {
-			Readings []struct {
-				Timestamp string  `json:"timestamp"`
-				Station   string  `json:"station"`
-				Level     float64 `json:"level"`
-			} `json:"readings"`
-		}
-		err = json.Unmarshal(rr.Body.Bytes(), &response)
-		require.NoError(t, err)
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh?start=2024-01-02&end=2024-01-02", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Readings []struct {
+					Timestamp string  `json:"timestamp"`
+					Station   string  `json:"station"`
+					Level     float64 `json:"level"`
+				} `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			require.Len(t, response.Readings, 1)
+			assert.Equal(t, "2024-01-02T10:00:00Z", response.Readings[0].Timestamp)
+			assert.Equal(t, 2.2, response.Readings[0].Level)
+		})
+
+		t.Run("validates date range is well ordered", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh?from=2024-01-03T00:00:00Z&to=2024-01-01T00:00:00Z", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+
+		t.Run("returns next cursor for a full page", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh?pagesize=2", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var first struct {
+				NextCursor string `json:"next_cursor"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &first)
+			require.NoError(t, err)
+			require.NotEmpty(t, first.NextCursor)
+
+			req, err = http.NewRequest("GET", "/rainfall/catcleugh?pagesize=2&cursor="+first.NextCursor, nil)
+			require.NoError(t, err)
 
-		require.Len(t, response.Readings, 1)
-		assert.Equal(t, "2024-01-02T10:00:00Z", response.Readings[0].Timestamp)
-		assert.Equal(t, 2.2, response.Readings[0].Level)
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var second struct {
+				Readings []struct {
+					Timestamp string  `json:"timestamp"`
+					Station   string  `json:"station"`
+					Level     float64 `json:"level"`
+				} `json:"readings"`
+				NextCursor *string `json:"next_cursor"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &second)
+			require.NoError(t, err)
+
+			require.Len(t, second.Readings, 1)
+			assert.Equal(t, "2024-01-03T11:00:00Z", second.Readings[0].Timestamp)
+			assert.Nil(t, second.NextCursor)
+		})
+
+		t.Run("counts readings within the date range", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh?start=2024-01-02&count=true", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Total      int64 `json:"total"`
+				Page       int   `json:"page"`
+				PageSize   int   `json:"pagesize"`
+				TotalPages int64 `json:"total_pages"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(2), response.Total)
+			assert.Equal(t, 1, response.Page)
+			assert.Equal(t, 12, response.PageSize)
+			assert.Equal(t, int64(1), response.TotalPages)
+
+			link := rr.Header().Get("Link")
+			assert.Contains(t, link, `rel="first"`)
+			assert.Contains(t, link, `rel="last"`)
+			assert.NotContains(t, link, `rel="next"`)
+		})
+
+		t.Run("returns CSV with a station column", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/haltwhistle?format=csv", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+			assert.Equal(t, "timestamp,level,station\n"+
+				"2024-01-01T09:00:00Z,1.5,haltwhistle\n"+
+				"2024-01-01T10:00:00Z,1.6,haltwhistle\n", rr.Body.String())
+		})
+
+		t.Run("returns NDJSON when requested in the Accept header", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh", nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", "application/x-ndjson")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+			lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+			require.Len(t, lines, 3)
+			assert.Equal(t, `{"timestamp":"2024-01-01T09:00:00Z","level":2.1,"station":"catcleugh"}`, lines[0])
+		})
+
+		t.Run("handles repository errors gracefully", func(t *testing.T) {
+			repo := &mockRainfallErrorRepo{}
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Internal server error")
+		})
 	})
+}
+
+func TestRainfallHandler_GetAggregatesByStation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		t.Run("returns monthly maximum for a station", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}/aggregate", handler.GetAggregatesByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh/aggregate?interval=month&fn=max", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Station string `json:"station"`
+				Buckets []struct {
+					Start string  `json:"start"`
+					Value float64 `json:"value"`
+					Count int64   `json:"count"`
+				} `json:"buckets"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			assert.Equal(t, "catcleugh", response.Station)
+			require.Len(t, response.Buckets, 1)
+			assert.Equal(t, "2024-01-01T00:00:00Z", response.Buckets[0].Start)
+			assert.Equal(t, 2.3, response.Buckets[0].Value)
+			assert.Equal(t, int64(3), response.Buckets[0].Count)
+		})
+
+		t.Run("returns hourly minimum for a station", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}/aggregate", handler.GetAggregatesByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/haltwhistle/aggregate?interval=hour&fn=min", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Buckets []struct {
+					Start string  `json:"start"`
+					Value float64 `json:"value"`
+					Count int64   `json:"count"`
+				} `json:"buckets"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			require.Len(t, response.Buckets, 2)
+			assert.Equal(t, "2024-01-01T09:00:00Z", response.Buckets[0].Start)
+			assert.Equal(t, 1.5, response.Buckets[0].Value)
+			assert.Equal(t, "2024-01-01T10:00:00Z", response.Buckets[1].Start)
+			assert.Equal(t, 1.6, response.Buckets[1].Value)
+		})
+
+		t.Run("returns 404 for non-existent station", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}/aggregate", handler.GetAggregatesByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/non-existent/aggregate?interval=day&fn=sum", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+		})
+
+		t.Run("validates invalid interval", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}/aggregate", handler.GetAggregatesByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh/aggregate?interval=minute&fn=sum", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	})
+}
 
-	t.Run("validates invalid page parameter", func(t *testing.T) {
-		repo := inmemory.NewRainfallRepo()
-		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRainfallHandler(repo, logger)
+func TestRainfallHandler_GetLatestReadings(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		now := func() time.Time { return time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC) }
+
+		t.Run("returns the most recent reading for a station", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+			handler.now = now
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}/latest", handler.GetLatestReadingByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/catcleugh/latest", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Reading struct {
+					Timestamp string  `json:"timestamp"`
+					Station   string  `json:"station"`
+					Level     float64 `json:"level"`
+				} `json:"reading"`
+				AgeSeconds int64 `json:"age_seconds"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			assert.Equal(t, "2024-01-03T11:00:00Z", response.Reading.Timestamp)
+			assert.Equal(t, "catcleugh", response.Reading.Station)
+			assert.Equal(t, 2.3, response.Reading.Level)
+			assert.Equal(t, int64(3600), response.AgeSeconds)
+		})
+
+		t.Run("returns 404 for a station without readings", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}/latest", handler.GetLatestReadingByStation)
+
+			for _, station := range []string{"alston", "non-existent"} {
+				req, err := http.NewRequest("GET", "/rainfall/"+station+"/latest", nil)
+				require.NoError(t, err)
+
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+
+				assert.Equal(t, http.StatusNotFound, rr.Code, station)
+			}
+		})
+
+		t.Run("returns the most recent reading of every station", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+			handler.now = now
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/latest", handler.GetLatestReadings)
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/rainfall/latest", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Readings []struct {
+					Reading struct {
+						Timestamp string  `json:"timestamp"`
+						Station   string  `json:"station"`
+						Level     float64 `json:"level"`
+					} `json:"reading"`
+					AgeSeconds int64 `json:"age_seconds"`
+				} `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			require.Len(t, response.Readings, 2)
+			assert.Equal(t, "catcleugh", response.Readings[0].Reading.Station)
+			assert.Equal(t, int64(3600), response.Readings[0].AgeSeconds)
+			assert.Equal(t, "haltwhistle", response.Readings[1].Reading.Station)
+			assert.Equal(t, "2024-01-01T10:00:00Z", response.Readings[1].Reading.Timestamp)
+		})
+
+		t.Run("handles repository errors gracefully", func(t *testing.T) {
+			repo := &mockRainfallErrorRepo{}
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/latest", handler.GetLatestReadings)
+
+			req, err := http.NewRequest("GET", "/rainfall/latest", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		})
+	})
+}
 
-		router := chi.NewRouter()
-		router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+func TestRainfallHandler_ExportReadingsByStation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		t.Run("streams every reading for the station as NDJSON", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
 
-		req, err := http.NewRequest("GET", "/rainfall/catcleugh?page=-1", nil)
-		require.NoError(t, err)
+			router := chi.NewRouter()
+			router.Get("/export/rainfall/{station}", handler.ExportReadingsByStation)
 
-		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+			req, err := http.NewRequest("GET", "/export/rainfall/catcleugh?format=ndjson", nil)
+			require.NoError(t, err)
 
-		assert.Equal(t, http.StatusBadRequest, rr.Code)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+			lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+			require.Len(t, lines, 3)
+			assert.Equal(t, `{"timestamp":"2024-01-03T11:00:00Z","level":2.3,"station":"catcleugh"}`, lines[2])
+		})
+
+		t.Run("returns 404 for unknown station", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/export/rainfall/{station}", handler.ExportReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/export/rainfall/nonexistent", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Station not found")
+		})
+
+		t.Run("validates date range", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/export/rainfall/{station}", handler.ExportReadingsByStation)
+
+			req, err := http.NewRequest("GET", "/export/rainfall/catcleugh?start=2024-01-03&end=2024-01-01", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
 	})
+}
+
+func TestRainfallHandler_GetReadingsByStations(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		t.Run("merges readings from several stations in chronological order", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall", handler.GetReadingsByStations)
+
+			req, err := http.NewRequest("GET", "/rainfall?station=haltwhistle&station=catcleugh&count=true", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Readings []domain.RainfallReading `json:"readings"`
+				Total    int64                    `json:"total"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			require.Len(t, response.Readings, 5)
+			assert.Equal(t, int64(5), response.Total)
+
+			// catcleugh and haltwhistle both read at 09:00, ties are broken by station ID
+			stations := make([]string, len(response.Readings))
+			for i, reading := range response.Readings {
+				stations[i] = reading.StationName
+			}
+			assert.Equal(t, []string{"catcleugh", "haltwhistle", "haltwhistle", "catcleugh", "catcleugh"}, stations)
+		})
+
+		t.Run("selects every station with a wildcard", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall", handler.GetReadingsByStations)
+
+			req, err := http.NewRequest("GET", "/rainfall?station=*&start=2024-01-02", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
 
-	t.Run("validates invalid start date format", func(t *testing.T) {
-		repo := inmemory.NewRainfallRepo()
-		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRainfallHandler(repo, logger)
+			assert.Equal(t, http.StatusOK, rr.Code)
 
-		router := chi.NewRouter()
-		router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+			var response struct {
+				Readings []domain.RainfallReading `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Len(t, response.Readings, 2)
+		})
 
-		req, err := http.NewRequest("GET", "/rainfall/catcleugh?start=invalid", nil)
-		require.NoError(t, err)
+		t.Run("lists unknown stations", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
 
-		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+			router := chi.NewRouter()
+			router.Get("/rainfall", handler.GetReadingsByStations)
 
-		assert.Equal(t, http.StatusBadRequest, rr.Code)
+			req, err := http.NewRequest("GET", "/rainfall?station=catcleugh&station=nowhere&station=elsewhere", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), "nowhere, elsewhere")
+		})
+
+		t.Run("validates parameters", func(t *testing.T) {
+			repo := b.rainfall(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall", handler.GetReadingsByStations)
+
+			for _, query := range []string{
+				"",
+				"?station=",
+				"?station=catcleugh&cursor=MjAyNC0wMS0wMVQwOTowMDowMFo",
+				"?station=catcleugh&page=0",
+			} {
+				req, err := http.NewRequest("GET", "/rainfall"+query, nil)
+				require.NoError(t, err)
+
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+			}
+		})
+
+		t.Run("handles repository errors gracefully", func(t *testing.T) {
+			repo := &mockRainfallErrorRepo{}
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall", handler.GetReadingsByStations)
+
+			req, err := http.NewRequest("GET", "/rainfall?station=catcleugh", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		})
 	})
+}
+
+func TestRainfallHandler_PostReadingsByStation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		newRouter := func(repo repository.RainfallRepository) *chi.Mux {
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRainfallHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+			router.Post("/rainfall/{station}/readings", handler.PostReadingsByStation)
+			return router
+		}
+
+		post := func(router http.Handler, path, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest("POST", path, strings.NewReader(body))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		t.Run("upserts readings so a retried batch is stored once", func(t *testing.T) {
+			router := newRouter(b.rainfall(t))
+			body := `{"readings":[{"timestamp":"2024-01-01T10:00:00Z","level":1.7},{"timestamp":"2024-01-01T11:00:00Z","level":0}]}`
+
+			for attempt := 0; attempt < 2; attempt++ {
+				rr := post(router, "/rainfall/haltwhistle/readings", body)
+				assert.Equal(t, http.StatusOK, rr.Code)
+				assert.JSONEq(t, `{"station":"haltwhistle","upserted":2}`, rr.Body.String())
+			}
+
+			req, err := http.NewRequest("GET", "/rainfall/haltwhistle", nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Readings []domain.RainfallReading `json:"readings"`
+			}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+			require.Len(t, response.Readings, 3)
+			assert.Equal(t, 1.7, response.Readings[1].Level, "existing timestamp should take the new level")
+			assert.Equal(t, 0.0, response.Readings[2].Level, "zero rainfall is a valid level")
+		})
+
+		t.Run("returns 404 for unknown station", func(t *testing.T) {
+			rr := post(newRouter(b.rainfall(t)), "/rainfall/nonexistent/readings", `{"readings":[{"timestamp":"2024-01-03T09:00:00Z","level":1.0}]}`)
 
-	t.Run("handles repository errors gracefully", func(t *testing.T) {
-		repo := &mockRainfallErrorRepo{}
-		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRainfallHandler(repo, logger)
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Station not found")
+		})
 
-		router := chi.NewRouter()
-		router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+		t.Run("rejects negative levels", func(t *testing.T) {
+			rr := post(newRouter(b.rainfall(t)), "/rainfall/catcleugh/readings", `{"readings":[{"timestamp":"2024-01-03T09:00:00Z","level":-1}]}`)
 
-		req, err := http.NewRequest("GET", "/rainfall/catcleugh", nil)
-		require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Level of readings[0] must not be negative")
+		})
 
-		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+		t.Run("returns 500 when the repository fails", func(t *testing.T) {
+			rr := post(newRouter(&mockRainfallErrorRepo{}), "/rainfall/catcleugh/readings", `{"readings":[{"timestamp":"2024-01-03T09:00:00Z","level":1.0}]}`)
 
-		assert.Equal(t, http.StatusInternalServerError, rr.Code)
-		assert.Contains(t, rr.Body.String(), "Internal server error")
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		})
 	})
 }
@@ -4,60 +4,318 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/oliverslade/flood-api/internal/constants"
 	"github.com/oliverslade/flood-api/internal/domain"
 	"github.com/oliverslade/flood-api/internal/repository"
+	"github.com/oliverslade/flood-api/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type RiverHandler struct {
 	repo   repository.RiverRepository
 	logger *slog.Logger
+	now    func() time.Time
+	// Pagination sizes the pages of readings, DefaultPagination unless changed before serving
+	Pagination Pagination
 }
 
 func NewRiverHandler(repo repository.RiverRepository, logger *slog.Logger) *RiverHandler {
 	return &RiverHandler{
-		repo:   repo,
-		logger: logger,
+		repo:       repo,
+		logger:     logger,
+		now:        time.Now,
+		Pagination: DefaultPagination,
 	}
 }
 
 func (h *RiverHandler) GetReadings(w http.ResponseWriter, r *http.Request) {
-	pagination, errMsg := ParsePaginationParams(r)
+	gauge := gaugeName(r)
+	r, span := startSpan(r, "RiverHandler.GetReadings", tracing.GaugeKey.String(gauge))
+	defer span.End()
+
+	pagination, errMsg := ParsePaginationParams(r, h.Pagination)
 	if errMsg != "" {
 		h.logger.Warn("Invalid pagination params", "error", errMsg)
 		h.returnBadRequest(w, errMsg)
 		return
 	}
+	span.SetAttributes(paginationAttributes(pagination)...)
+
+	format, errMsg := NegotiateFormat(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid response format", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
 
-	startDate, errMsg := ParseStartDate(r)
+	withCount, errMsg := ParseCountFlag(r)
 	if errMsg != "" {
-		h.logger.Warn("Invalid start date", "error", errMsg)
+		h.logger.Warn("Invalid count flag", "error", errMsg)
 		h.returnBadRequest(w, errMsg)
 		return
 	}
 
-	params := domain.GetReadingsParams{
-		Pagination: pagination,
-		StartDate:  startDate,
+	startDate, endDate, errMsg := ParseDateRange(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid date range", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	params := domain.GetRiverParams{
+		GaugeName: gauge,
+		GetReadingsParams: domain.GetReadingsParams{
+			Pagination: pagination,
+			StartDate:  startDate,
+			EndDate:    endDate,
+		},
+	}
+
+	w.Header().Set("Vary", "Accept")
+	if format != formatJSON {
+		h.streamReadings(w, r, span, params, format, withCount)
+		return
 	}
 
 	readings, err := h.repo.GetReadings(r.Context(), params)
 	if err != nil {
+		if err == domain.ErrNotFound {
+			h.logger.Warn("Gauge not found", "gauge", gauge)
+			http.Error(w, "Gauge not found", http.StatusNotFound)
+			return
+		}
 		h.logger.Error("Error fetching readings", "error", err)
+		failSpan(span, err)
 		http.Error(w, "Internal server error when getting readings", http.StatusInternalServerError)
 		return
 	}
 
+	span.SetAttributes(tracing.RowCountKey.Int(len(readings)))
 	response := map[string]interface{}{
 		"readings": readings,
 	}
 
+	// a full page means there may be more readings, so hand out a cursor to seek past it
+	var cursor string
+	if n := len(readings); n > 0 && n == pagination.PageSize {
+		cursor = encodeCursor(readings[n-1].Timestamp)
+		response["next_cursor"] = cursor
+	}
+
+	var meta *pageMeta
+	if withCount {
+		total, err := h.repo.CountReadings(r.Context(), params)
+		if err != nil {
+			h.logger.Error("Error counting readings", "error", err)
+			failSpan(span, err)
+			http.Error(w, "Internal server error when counting readings", http.StatusInternalServerError)
+			return
+		}
+		meta = newPageMeta(total, pagination.PageSize)
+		addPageMeta(response, pagination, meta)
+	}
+
+	setLinkHeader(w, r, pagination, cursor != "", cursor, meta)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Error encoding response", "error", err, "format", format)
+	}
+}
+
+// streamReadings writes a page of readings as CSV or NDJSON as the repository reads them. The
+// totals are counted first so the Link header can point at the last page before any reading is sent.
+func (h *RiverHandler) streamReadings(w http.ResponseWriter, r *http.Request, span trace.Span, params domain.GetRiverParams, format string, withCount bool) {
+	var meta *pageMeta
+	if withCount {
+		total, err := h.repo.CountReadings(r.Context(), params)
+		if err != nil {
+			if err == domain.ErrNotFound {
+				h.logger.Warn("Gauge not found", "gauge", params.GaugeName)
+				http.Error(w, "Gauge not found", http.StatusNotFound)
+				return
+			}
+			h.logger.Error("Error counting readings", "error", err)
+			failSpan(span, err)
+			http.Error(w, "Internal server error when counting readings", http.StatusInternalServerError)
+			return
+		}
+		meta = newPageMeta(total, params.Pagination.PageSize)
+	}
+
+	stream := newPageStream[domain.RiverReading](w, format, domain.RiverReadingCSVHeader)
+	err := h.repo.StreamReadingsPage(r.Context(), params, pageLinks(w, r, params.Pagination, meta), stream.write)
+	if err == nil {
+		err = stream.close()
+	}
+	span.SetAttributes(tracing.RowCountKey.Int(stream.written))
+	if err != nil {
+		stream.fail(span, h.logger, "Gauge not found", err)
+	}
+}
+
+// ExportReadings streams every river level reading for a gauge within the optional date range, with no page size cap
+func (h *RiverHandler) ExportReadings(w http.ResponseWriter, r *http.Request) {
+	gauge := gaugeName(r)
+	r, span := startSpan(r, "RiverHandler.ExportReadings", tracing.GaugeKey.String(gauge))
+	defer span.End()
+
+	format, errMsg := NegotiateFormat(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid response format", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	startDate, endDate, errMsg := ParseDateRange(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid date range", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	params := domain.ExportRiverParams{
+		GaugeName: gauge,
+		ExportParams: domain.ExportParams{
+			StartDate: startDate,
+			EndDate:   endDate,
+		},
+	}
+
+	w.Header().Set("Vary", "Accept")
+	stream := newReadingStream[domain.RiverReading](w, format, domain.RiverReadingCSVHeader)
+	err := h.repo.StreamReadings(r.Context(), params, stream.write)
+	if err == nil {
+		err = stream.close()
+	}
+	span.SetAttributes(tracing.RowCountKey.Int(stream.written))
+	if err != nil {
+		stream.fail(span, h.logger, "Gauge not found", err)
+	}
+}
+
+func (h *RiverHandler) GetAggregates(w http.ResponseWriter, r *http.Request) {
+	gauge := gaugeName(r)
+	r, span := startSpan(r, "RiverHandler.GetAggregates", tracing.GaugeKey.String(gauge))
+	defer span.End()
+
+	interval, fn, errMsg := ParseAggregateParams(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid aggregate params", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	startDate, endDate, errMsg := ParseDateRange(r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid date range", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	params := domain.GetRiverAggregateParams{
+		GaugeName: gauge,
+		AggregateParams: domain.AggregateParams{
+			Interval:  interval,
+			Func:      fn,
+			StartDate: startDate,
+			EndDate:   endDate,
+		},
+	}
+
+	buckets, err := h.repo.GetAggregates(r.Context(), params)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			h.logger.Warn("Gauge not found", "gauge", gauge)
+			http.Error(w, "Gauge not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Error aggregating readings", "error", err)
+		failSpan(span, err)
+		http.Error(w, "Internal server error when aggregating readings", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetAttributes(tracing.RowCountKey.Int(len(buckets)))
+	response := map[string]interface{}{
+		"gauge":    gauge,
+		"interval": interval,
+		"fn":       fn,
+		"buckets":  buckets,
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		h.logger.Error("Error encoding response", "error", err)
 	}
 }
 
+func (h *RiverHandler) GetLatestReading(w http.ResponseWriter, r *http.Request) {
+	gauge := gaugeName(r)
+	r, span := startSpan(r, "RiverHandler.GetLatestReading", tracing.GaugeKey.String(gauge))
+	defer span.End()
+
+	reading, err := h.repo.GetLatestReading(r.Context(), gauge)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			h.logger.Warn("No river readings found", "gauge", gauge)
+			http.Error(w, "No readings found for gauge", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Error fetching latest reading", "error", err)
+		failSpan(span, err)
+		http.Error(w, "Internal server error when getting latest reading", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newLatestReading(reading, reading.Timestamp, h.now())); err != nil {
+		h.logger.Error("Error encoding response", "error", err)
+	}
+}
+
+// PostReadings upserts a batch of river level readings for a gauge, so a retried batch has the same effect as one
+func (h *RiverHandler) PostReadings(w http.ResponseWriter, r *http.Request) {
+	gauge := gaugeName(r)
+	r, span := startSpan(r, "RiverHandler.PostReadings", tracing.GaugeKey.String(gauge))
+	defer span.End()
+
+	readings, errMsg := ParseReadingsBody(w, r)
+	if errMsg != "" {
+		h.logger.Warn("Invalid readings", "error", errMsg)
+		h.returnBadRequest(w, errMsg)
+		return
+	}
+
+	if err := h.repo.UpsertReadings(r.Context(), gauge, readings); err != nil {
+		if err == domain.ErrNotFound {
+			h.logger.Warn("Gauge not found", "gauge", gauge)
+			http.Error(w, "Gauge not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Error writing readings", "error", err)
+		failSpan(span, err)
+		http.Error(w, "Internal server error when writing readings", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Readings written", "gauge", gauge, "count", len(readings))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"gauge": gauge, "upserted": len(readings)}); err != nil {
+		h.logger.Error("Error encoding response", "error", err)
+	}
+}
+
+// gaugeName returns the gauge in the route, or the default gauge for the original gauge-less routes
+func gaugeName(r *http.Request) string {
+	if gauge := chi.URLParam(r, "gauge"); gauge != "" {
+		return gauge
+	}
+	return constants.DefaultRiverGauge
+}
+
 func (h *RiverHandler) returnBadRequest(w http.ResponseWriter, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusBadRequest)
@@ -8,10 +8,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
 	"github.com/oliverslade/flood-api/internal/repository/inmemory"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,130 +22,1132 @@ import (
 
 type mockErrorRepo struct{}
 
-func (m *mockErrorRepo) GetReadings(ctx context.Context, params domain.GetReadingsParams) ([]domain.RiverReading, error) {
+func (m *mockErrorRepo) GetReadings(ctx context.Context, params domain.GetRiverParams) ([]domain.RiverReading, error) {
 	return nil, fmt.Errorf("repository error")
 }
 
+func (m *mockErrorRepo) StreamReadingsPage(ctx context.Context, params domain.GetRiverParams, start func(domain.PageBounds) error, fn func(domain.RiverReading) error) error {
+	return fmt.Errorf("repository error")
+}
+
+func (m *mockErrorRepo) CountReadings(ctx context.Context, params domain.GetRiverParams) (int64, error) {
+	return 0, fmt.Errorf("repository error")
+}
+
+func (m *mockErrorRepo) GetAggregates(ctx context.Context, params domain.GetRiverAggregateParams) ([]domain.AggregateBucket, error) {
+	return nil, fmt.Errorf("repository error")
+}
+
+func (m *mockErrorRepo) GetLatestReading(ctx context.Context, gaugeName string) (domain.RiverReading, error) {
+	return domain.RiverReading{}, fmt.Errorf("repository error")
+}
+
+func (m *mockErrorRepo) StreamReadings(ctx context.Context, params domain.ExportRiverParams, fn func(domain.RiverReading) error) error {
+	return fmt.Errorf("repository error")
+}
+
+func (m *mockErrorRepo) UpsertReadings(ctx context.Context, gaugeName string, readings []domain.Measurement) error {
+	return fmt.Errorf("repository error")
+}
+
+// mockMidStreamErrorRepo fails exports after their first reading has been sent
+type mockMidStreamErrorRepo struct {
+	mockErrorRepo
+}
+
+func (m *mockMidStreamErrorRepo) StreamReadings(ctx context.Context, params domain.ExportRiverParams, fn func(domain.RiverReading) error) error {
+	if err := fn(domain.RiverReading{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 1.2, GaugeName: params.GaugeName}); err != nil {
+		return err
+	}
+	return fmt.Errorf("repository error")
+}
+
 func TestRiverHandler_GetReadings(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
 
-	t.Run("returns readings successfully with default parameters", func(t *testing.T) {
-		repo := inmemory.NewRiverRepo()
-		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRiverHandler(repo, logger)
+		t.Run("returns readings successfully with default parameters", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
 
-		router := chi.NewRouter()
-		router.Get("/river", handler.GetReadings)
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
 
-		req, err := http.NewRequest("GET", "/river", nil)
-		require.NoError(t, err)
+			req, err := http.NewRequest("GET", "/river", nil)
+			require.NoError(t, err)
 
-		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
 
-		assert.Equal(t, http.StatusOK, rr.Code)
-		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+			var response struct {
+				Readings []struct {
+					Timestamp string  `json:"timestamp"`
+					Level     float64 `json:"level"`
+				} `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			require.Len(t, response.Readings, 5)
+			assert.Equal(t, "2024-01-01T09:00:00Z", response.Readings[0].Timestamp)
+			assert.Equal(t, 1.2, response.Readings[0].Level)
+			assert.Equal(t, "2024-01-01T10:00:00Z", response.Readings[1].Timestamp)
+			assert.Equal(t, 1.3, response.Readings[1].Level)
+			assert.Equal(t, "2024-01-01T11:00:00Z", response.Readings[2].Timestamp)
+			assert.Equal(t, 1.4, response.Readings[2].Level)
+			assert.Equal(t, "2024-01-01T12:00:00Z", response.Readings[3].Timestamp)
+			assert.Equal(t, 1.5, response.Readings[3].Level)
+			assert.Equal(t, "2024-01-02T09:00:00Z", response.Readings[4].Timestamp)
+			assert.Equal(t, 1.1, response.Readings[4].Level)
+		})
+
+		t.Run("returns paginated readings", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?page=2&pagesize=2", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Readings []struct {
+					Timestamp string  `json:"timestamp"`
+					Level     float64 `json:"level"`
+				} `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			require.Len(t, response.Readings, 2)
+			assert.Equal(t, "2024-01-01T11:00:00Z", response.Readings[0].Timestamp)
+			assert.Equal(t, 1.4, response.Readings[0].Level)
+		})
+
+		t.Run("serves the configured page sizes", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+			handler.Pagination = Pagination{DefaultPageSize: 2, MaxPageSize: 3}
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			for url, want := range map[string]int{"/river": 2, "/river?pagesize=4": 3} {
+				req, err := http.NewRequest("GET", url, nil)
+				require.NoError(t, err)
+
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				require.Equal(t, http.StatusOK, rr.Code)
+
+				var response struct {
+					Readings []domain.RiverReading `json:"readings"`
+				}
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				assert.Len(t, response.Readings, want, url)
+			}
+		})
+
+		t.Run("validates invalid page parameter", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?page=0", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+
+		t.Run("validates invalid start date format", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?start=invalid-date", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+
+		t.Run("filters readings by inclusive end date", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?start=2024-01-01&end=2024-01-01", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Readings []struct {
+					Timestamp string  `json:"timestamp"`
+					Level     float64 `json:"level"`
+				} `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			require.Len(t, response.Readings, 4)
+			assert.Equal(t, "2024-01-01T09:00:00Z", response.Readings[0].Timestamp)
+			assert.Equal(t, "2024-01-01T12:00:00Z", response.Readings[3].Timestamp)
+		})
+
+		t.Run("filters readings by RFC 3339 from and to", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?from=2024-01-01T10:00:00Z&to=2024-01-01T12:00:00Z", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Readings []struct {
+					Timestamp string  `json:"timestamp"`
+					Level     float64 `json:"level"`
+				} `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			// to is exclusive, so the 12:00 reading is not included
+			require.Len(t, response.Readings, 2)
+			assert.Equal(t, "2024-01-01T10:00:00Z", response.Readings[0].Timestamp)
+			assert.Equal(t, "2024-01-01T11:00:00Z", response.Readings[1].Timestamp)
+		})
+
+		t.Run("validates date range parameters", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			testCases := []struct {
+				name   string
+				params string
+			}{
+				{"End before start", "?start=2024-01-02&end=2024-01-01"},
+				{"Invalid end date", "?end=invalid-date"},
+				{"Invalid from datetime", "?from=2024-01-01"},
+				{"Invalid to datetime", "?to=2024-01-01 10:00:00"},
+				{"Start and from together", "?start=2024-01-01&from=2024-01-01T00:00:00Z"},
+				{"End and to together", "?end=2024-01-01&to=2024-01-01T00:00:00Z"},
+				{"Empty range", "?from=2024-01-01T10:00:00Z&to=2024-01-01T10:00:00Z"},
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					req, err := http.NewRequest("GET", "/river"+tc.params, nil)
+					require.NoError(t, err)
+
+					rr := httptest.NewRecorder()
+					router.ServeHTTP(rr, req)
+
+					assert.Equal(t, http.StatusBadRequest, rr.Code)
+				})
+			}
+		})
 
-		var response struct {
-			Readings []struct {
-				Timestamp string  `json:"timestamp"`
-				Level     float64 `json:"level"`
-			} `json:"readings"`
+		t.Run("pages through readings with a cursor", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			type cursorResponse struct {
+				Readings []struct {
+					Timestamp string  `json:"timestamp"`
+					Level     float64 `json:"level"`
+				} `json:"readings"`
+				NextCursor string `json:"next_cursor"`
+			}
+
+			var timestamps []string
+			url := "/river?pagesize=2"
+			for pages := 0; url != ""; pages++ {
+				require.Less(t, pages, 5, "cursor pagination did not terminate")
+
+				req, err := http.NewRequest("GET", url, nil)
+				require.NoError(t, err)
+
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+				require.Equal(t, http.StatusOK, rr.Code)
+
+				var response cursorResponse
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				require.NoError(t, err)
+
+				for _, r := range response.Readings {
+					timestamps = append(timestamps, r.Timestamp)
+				}
+
+				url = ""
+				if response.NextCursor != "" {
+					url = "/river?pagesize=2&cursor=" + response.NextCursor
+				}
+			}
+
+			assert.Equal(t, []string{
+				"2024-01-01T09:00:00Z",
+				"2024-01-01T10:00:00Z",
+				"2024-01-01T11:00:00Z",
+				"2024-01-01T12:00:00Z",
+				"2024-01-02T09:00:00Z",
+			}, timestamps)
+		})
+
+		t.Run("validates cursor parameter", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			testCases := []struct {
+				name   string
+				params string
+			}{
+				{"Malformed cursor", "?cursor=not-a-cursor"},
+				{"Cursor and page together", "?page=2&cursor=" + encodeCursor(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))},
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					req, err := http.NewRequest("GET", "/river"+tc.params, nil)
+					require.NoError(t, err)
+
+					rr := httptest.NewRecorder()
+					router.ServeHTTP(rr, req)
+
+					assert.Equal(t, http.StatusBadRequest, rr.Code)
+				})
+			}
+		})
+
+		t.Run("returns pagination metadata and links when counting", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?page=2&pagesize=2&count=true", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Total      int64 `json:"total"`
+				Page       int   `json:"page"`
+				PageSize   int   `json:"pagesize"`
+				TotalPages int64 `json:"total_pages"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(5), response.Total)
+			assert.Equal(t, 2, response.Page)
+			assert.Equal(t, 2, response.PageSize)
+			assert.Equal(t, int64(3), response.TotalPages)
+
+			link := rr.Header().Get("Link")
+			assert.Contains(t, link, `</river?count=true&page=1&pagesize=2>; rel="first"`)
+			assert.Contains(t, link, `</river?count=true&page=1&pagesize=2>; rel="prev"`)
+			assert.Contains(t, link, `</river?count=true&page=3&pagesize=2>; rel="next"`)
+			assert.Contains(t, link, `</river?count=true&page=3&pagesize=2>; rel="last"`)
+		})
+
+		t.Run("omits totals and last link without count", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?pagesize=2", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.NotContains(t, rr.Body.String(), `"total"`)
+
+			link := rr.Header().Get("Link")
+			assert.Contains(t, link, `</river?page=2&pagesize=2>; rel="next"`)
+			assert.NotContains(t, link, `rel="prev"`)
+			assert.NotContains(t, link, `rel="last"`)
+		})
+
+		t.Run("validates invalid count flag", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?count=maybe", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+
+		t.Run("returns CSV when requested in the Accept header", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?pagesize=2", nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", "application/json;q=0.5, text/csv")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+			assert.Contains(t, rr.Header().Get("Link"), `rel="next"`)
+			assert.Equal(t, "timestamp,level,gauge\n2024-01-01T09:00:00Z,1.2,rede-bridge\n2024-01-01T10:00:00Z,1.3,rede-bridge\n", rr.Body.String())
+		})
+
+		t.Run("links the next CSV page by the cursor of its last row", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?format=csv&count=true&pagesize=2&cursor="+encodeCursor(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)), nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			link := rr.Header().Get("Link")
+			assert.Contains(t, link, "</river?count=true&cursor="+encodeCursor(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC))+`&format=csv&pagesize=2>; rel="next"`)
+			assert.Contains(t, link, `rel="last"`)
+			assert.Equal(t, "timestamp,level,gauge\n2024-01-01T10:00:00Z,1.3,rede-bridge\n2024-01-01T11:00:00Z,1.4,rede-bridge\n", rr.Body.String())
+		})
+
+		t.Run("returns 404 for an unknown gauge as CSV", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river/{gauge}", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river/nonexistent?format=csv", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Equal(t, "Gauge not found\n", rr.Body.String())
+		})
+
+		t.Run("returns NDJSON when requested with the format override", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?format=ndjson&start=2024-01-02", nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", "text/csv")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+			assert.Equal(t, `{"timestamp":"2024-01-02T09:00:00Z","level":1.1,"gauge":"rede-bridge"}`+"\n", rr.Body.String())
+		})
+
+		t.Run("validates invalid format override", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river?format=xml", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+
+		t.Run("handles repository errors gracefully", func(t *testing.T) {
+			repo := &mockErrorRepo{}
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+
+			req, err := http.NewRequest("GET", "/river", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Internal server error")
+		})
+	})
+}
+
+func TestRiverHandler_GetAggregates(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		type aggregateResponse struct {
+			Interval string `json:"interval"`
+			Fn       string `json:"fn"`
+			Buckets  []struct {
+				Start string  `json:"start"`
+				Value float64 `json:"value"`
+				Count int64   `json:"count"`
+			} `json:"buckets"`
 		}
-		err = json.Unmarshal(rr.Body.Bytes(), &response)
-		require.NoError(t, err)
 
-		require.Len(t, response.Readings, 5)
-		assert.Equal(t, "2024-01-01T09:00:00Z", response.Readings[0].Timestamp)
-		assert.Equal(t, 1.2, response.Readings[0].Level)
-		assert.Equal(t, "2024-01-01T10:00:00Z", response.Readings[1].Timestamp)
-		assert.Equal(t, 1.3, response.Readings[1].Level)
-		assert.Equal(t, "2024-01-01T11:00:00Z", response.Readings[2].Timestamp)
-		assert.Equal(t, 1.4, response.Readings[2].Level)
-		assert.Equal(t, "2024-01-01T12:00:00Z", response.Readings[3].Timestamp)
-		assert.Equal(t, 1.5, response.Readings[3].Level)
-		assert.Equal(t, "2024-01-02T09:00:00Z", response.Readings[4].Timestamp)
-		assert.Equal(t, 1.1, response.Readings[4].Level)
+		t.Run("returns daily totals", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river/aggregate", handler.GetAggregates)
+
+			req, err := http.NewRequest("GET", "/river/aggregate?interval=day&fn=sum", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+			var response aggregateResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			assert.Equal(t, "day", response.Interval)
+			assert.Equal(t, "sum", response.Fn)
+			require.Len(t, response.Buckets, 2)
+			assert.Equal(t, "2024-01-01T00:00:00Z", response.Buckets[0].Start)
+			assert.Equal(t, 5.4, response.Buckets[0].Value)
+			assert.Equal(t, int64(4), response.Buckets[0].Count)
+			assert.Equal(t, "2024-01-02T00:00:00Z", response.Buckets[1].Start)
+			assert.Equal(t, 1.1, response.Buckets[1].Value)
+			assert.Equal(t, int64(1), response.Buckets[1].Count)
+		})
+
+		t.Run("returns weekly mean within a date range", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river/aggregate", handler.GetAggregates)
+
+			req, err := http.NewRequest("GET", "/river/aggregate?interval=week&fn=mean&end=2024-01-01", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response aggregateResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			// 2024-01-01 is a Monday, so the week bucket starts on the same day
+			require.Len(t, response.Buckets, 1)
+			assert.Equal(t, "2024-01-01T00:00:00Z", response.Buckets[0].Start)
+			assert.Equal(t, 1.35, response.Buckets[0].Value)
+			assert.Equal(t, int64(4), response.Buckets[0].Count)
+		})
+
+		t.Run("validates aggregate parameters", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river/aggregate", handler.GetAggregates)
+
+			testCases := []struct {
+				name   string
+				params string
+			}{
+				{"Missing interval", "?fn=sum"},
+				{"Invalid interval", "?interval=year&fn=sum"},
+				{"Missing fn", "?interval=day"},
+				{"Invalid fn", "?interval=day&fn=median"},
+				{"Invalid date range", "?interval=day&fn=sum&start=2024-01-02&end=2024-01-01"},
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					req, err := http.NewRequest("GET", "/river/aggregate"+tc.params, nil)
+					require.NoError(t, err)
+
+					rr := httptest.NewRecorder()
+					router.ServeHTTP(rr, req)
+
+					assert.Equal(t, http.StatusBadRequest, rr.Code)
+				})
+			}
+		})
+
+		t.Run("handles repository errors gracefully", func(t *testing.T) {
+			repo := &mockErrorRepo{}
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river/aggregate", handler.GetAggregates)
+
+			req, err := http.NewRequest("GET", "/river/aggregate?interval=day&fn=sum", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Internal server error")
+		})
 	})
+}
 
-	t.Run("returns paginated readings", func(t *testing.T) {
-		repo := inmemory.NewRiverRepo()
-		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRiverHandler(repo, logger)
+func TestRiverHandler_GetLatestReading(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		t.Run("returns the most recent reading and its age", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+			handler.now = func() time.Time { return time.Date(2024, 1, 2, 9, 15, 0, 0, time.UTC) }
 
-		router := chi.NewRouter()
-		router.Get("/river", handler.GetReadings)
+			router := chi.NewRouter()
+			router.Get("/river/latest", handler.GetLatestReading)
 
-		req, err := http.NewRequest("GET", "/river?page=2&pagesize=2", nil)
-		require.NoError(t, err)
+			req, err := http.NewRequest("GET", "/river/latest", nil)
+			require.NoError(t, err)
 
-		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
 
-		assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
 
-		var response struct {
-			Readings []struct {
-				Timestamp string  `json:"timestamp"`
-				Level     float64 `json:"level"`
-			} `json:"readings"`
-		}
-		err = json.Unmarshal(rr.Body.Bytes(), &response)
-		require.NoError(t, err)
+			var response struct {
+				Reading struct {
+					Timestamp string  `json:"timestamp"`
+					Level     float64 `json:"level"`
+				} `json:"reading"`
+				AgeSeconds int64 `json:"age_seconds"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			assert.Equal(t, "2024-01-02T09:00:00Z", response.Reading.Timestamp)
+			assert.Equal(t, 1.1, response.Reading.Level)
+			assert.Equal(t, int64(900), response.AgeSeconds)
+		})
+
+		t.Run("handles repository errors gracefully", func(t *testing.T) {
+			repo := &mockErrorRepo{}
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river/latest", handler.GetLatestReading)
 
-		require.Len(t, response.Readings, 2)
-		assert.Equal(t, "2024-01-01T11:00:00Z", response.Readings[0].Timestamp)
-		assert.Equal(t, 1.4, response.Readings[0].Level)
+			req, err := http.NewRequest("GET", "/river/latest", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Internal server error")
+		})
 	})
+}
 
-	t.Run("validates invalid page parameter", func(t *testing.T) {
-		repo := inmemory.NewRiverRepo()
-		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRiverHandler(repo, logger)
+func TestRiverHandler_ExportReadings(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		t.Run("streams every reading as JSON by default", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
 
-		router := chi.NewRouter()
-		router.Get("/river", handler.GetReadings)
+			router := chi.NewRouter()
+			router.Get("/export/river", handler.ExportReadings)
 
-		req, err := http.NewRequest("GET", "/river?page=0", nil)
-		require.NoError(t, err)
+			req, err := http.NewRequest("GET", "/export/river", nil)
+			require.NoError(t, err)
 
-		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+			var response struct {
+				Readings []domain.RiverReading `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Len(t, response.Readings, 5)
+		})
+
+		t.Run("streams a date range as CSV", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/export/river", handler.ExportReadings)
 
-		assert.Equal(t, http.StatusBadRequest, rr.Code)
+			req, err := http.NewRequest("GET", "/export/river?from=2024-01-01T11:00:00Z&end=2024-01-01", nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", "text/csv")
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "timestamp,level,gauge\n2024-01-01T11:00:00Z,1.4,rede-bridge\n2024-01-01T12:00:00Z,1.5,rede-bridge\n", rr.Body.String())
+		})
+
+		t.Run("writes an empty document when nothing matches", func(t *testing.T) {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/export/river", handler.ExportReadings)
+
+			req, err := http.NewRequest("GET", "/export/river?start=2030-01-01", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.JSONEq(t, `{"readings":[]}`, rr.Body.String())
+		})
+
+		t.Run("handles repository errors gracefully", func(t *testing.T) {
+			repo := &mockErrorRepo{}
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/export/river", handler.ExportReadings)
+
+			req, err := http.NewRequest("GET", "/export/river", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Internal server error")
+		})
 	})
 
-	t.Run("validates invalid start date format", func(t *testing.T) {
-		repo := inmemory.NewRiverRepo()
+	t.Run("aborts the response when the repository fails after the first reading", func(t *testing.T) {
 		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRiverHandler(repo, logger)
+		handler := NewRiverHandler(&mockMidStreamErrorRepo{}, logger)
 
 		router := chi.NewRouter()
-		router.Get("/river", handler.GetReadings)
+		router.Get("/export/river", handler.ExportReadings)
 
-		req, err := http.NewRequest("GET", "/river?start=invalid-date", nil)
+		req, err := http.NewRequest("GET", "/export/river", nil)
 		require.NoError(t, err)
 
 		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() { router.ServeHTTP(rr, req) })
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.True(t, strings.HasPrefix(rr.Body.String(), `{"readings":[`))
+	})
+}
 
-		assert.Equal(t, http.StatusBadRequest, rr.Code)
+func TestRiverHandler_Gauges(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		newRouter := func() *chi.Mux {
+			repo := b.river(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river", handler.GetReadings)
+			router.Get("/river/latest", handler.GetLatestReading)
+			router.Get("/river/{gauge}", handler.GetReadings)
+			router.Get("/river/{gauge}/aggregate", handler.GetAggregates)
+			router.Get("/river/{gauge}/latest", handler.GetLatestReading)
+			router.Get("/export/river/{gauge}", handler.ExportReadings)
+			return router
+		}
+
+		t.Run("returns readings for the requested gauge only", func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/river/otterburn", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Readings []domain.RiverReading `json:"readings"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			require.Len(t, response.Readings, 2)
+			for _, reading := range response.Readings {
+				assert.Equal(t, "otterburn", reading.GaugeName)
+			}
+		})
+
+		t.Run("serves the default gauge without a gauge in the path", func(t *testing.T) {
+			for _, path := range []string{"/river", "/river/rede-bridge"} {
+				req, err := http.NewRequest("GET", path, nil)
+				require.NoError(t, err)
+
+				rr := httptest.NewRecorder()
+				newRouter().ServeHTTP(rr, req)
+
+				assert.Equal(t, http.StatusOK, rr.Code, path)
+
+				var response struct {
+					Readings []domain.RiverReading `json:"readings"`
+				}
+				err = json.Unmarshal(rr.Body.Bytes(), &response)
+				require.NoError(t, err)
+
+				require.Len(t, response.Readings, 5, path)
+				assert.Equal(t, "rede-bridge", response.Readings[0].GaugeName, path)
+			}
+		})
+
+		t.Run("aggregates the requested gauge", func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/river/otterburn/aggregate?interval=day&fn=max", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.JSONEq(t, `{
+				"gauge": "otterburn",
+				"interval": "day",
+				"fn": "max",
+				"buckets": [{"start": "2024-01-01T00:00:00Z", "value": 0.9, "count": 2}]
+			}`, rr.Body.String())
+		})
+
+		t.Run("returns 404 for an unknown gauge", func(t *testing.T) {
+			for _, path := range []string{
+				"/river/nonexistent",
+				"/river/nonexistent/aggregate?interval=day&fn=sum",
+				"/river/nonexistent/latest",
+				"/export/river/nonexistent",
+			} {
+				req, err := http.NewRequest("GET", path, nil)
+				require.NoError(t, err)
+
+				rr := httptest.NewRecorder()
+				newRouter().ServeHTTP(rr, req)
+
+				assert.Equal(t, http.StatusNotFound, rr.Code, path)
+			}
+		})
+
+		t.Run("returns 404 for a gauge without readings", func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/river/falstone/latest", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			newRouter().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Contains(t, rr.Body.String(), "No readings found for gauge")
+		})
 	})
+}
 
-	t.Run("handles repository errors gracefully", func(t *testing.T) {
-		repo := &mockErrorRepo{}
-		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-		handler := NewRiverHandler(repo, logger)
+func TestRiverHandler_GeneratedSeries(t *testing.T) {
+	// a leap year of 15 minute readings, levels following the time of day
+	data := inmemory.Fixtures()
+	gauge := domain.Gauge{ID: "rede-bridge", Name: "rede-bridge", River: "River Rede"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data.River = nil
+	data.AddRiver(gauge, inmemory.Series(start, 15*time.Minute, 366*96, func(ts time.Time) float64 {
+		return 1 + float64(ts.Hour())/100
+	}))
 
-		router := chi.NewRouter()
-		router.Get("/river", handler.GetReadings)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	handler := NewRiverHandler(inmemory.NewRiverRepoFrom(inmemory.NewStore(data)), logger)
+	router := chi.NewRouter()
+	router.Get("/river/{gauge}", handler.GetReadings)
 
-		req, err := http.NewRequest("GET", "/river", nil)
+	type pageResponse struct {
+		Readings   []domain.RiverReading `json:"readings"`
+		NextCursor string                `json:"next_cursor"`
+		Total      int64                 `json:"total"`
+	}
+	get := func(url string) pageResponse {
+		req, err := http.NewRequest("GET", url, nil)
 		require.NoError(t, err)
 
 		rr := httptest.NewRecorder()
 		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, url)
+
+		var response pageResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("counts a month of readings", func(t *testing.T) {
+		response := get("/river/rede-bridge?start=2024-03-01&end=2024-03-31&pagesize=1000&count=true")
+
+		assert.Equal(t, int64(31*96), response.Total)
+		require.Len(t, response.Readings, 1000)
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), response.Readings[0].Timestamp)
+	})
+
+	t.Run("pages through a week with a cursor", func(t *testing.T) {
+		var readings []domain.RiverReading
+		url := "/river/rede-bridge?start=2024-06-01&end=2024-06-07&pagesize=100"
+		for pages := 0; url != ""; pages++ {
+			require.Less(t, pages, 10, "cursor pagination did not terminate")
+
+			response := get(url)
+			readings = append(readings, response.Readings...)
+
+			url = ""
+			if response.NextCursor != "" {
+				url = "/river/rede-bridge?end=2024-06-07&pagesize=100&cursor=" + response.NextCursor
+			}
+		}
+
+		require.Len(t, readings, 7*96)
+		for i, reading := range readings {
+			assert.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(i)*15*time.Minute), reading.Timestamp)
+		}
+	})
+}
+
+func TestRiverHandler_PostReadings(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		newRouter := func(repo repository.RiverRepository) *chi.Mux {
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewRiverHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/river/{gauge}", handler.GetReadings)
+			router.Post("/river/readings", handler.PostReadings)
+			router.Post("/river/{gauge}/readings", handler.PostReadings)
+			return router
+		}
+
+		post := func(router http.Handler, path, body string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest("POST", path, strings.NewReader(body))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		getReadings := func(router http.Handler, gauge string) []domain.RiverReading {
+			req, err := http.NewRequest("GET", "/river/"+gauge, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Readings []domain.RiverReading `json:"readings"`
+			}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			return response.Readings
+		}
+
+		t.Run("upserts readings so a retried batch is stored once", func(t *testing.T) {
+			router := newRouter(b.river(t))
+			body := `{"readings":[{"timestamp":"2024-01-01T10:00:00Z","level":0.95},{"timestamp":"2024-01-01T12:00:00+01:00","level":1.05}]}`
+
+			for attempt := 0; attempt < 2; attempt++ {
+				rr := post(router, "/river/otterburn/readings", body)
+				assert.Equal(t, http.StatusOK, rr.Code)
+				assert.JSONEq(t, `{"gauge":"otterburn","upserted":2}`, rr.Body.String())
+			}
+
+			readings := getReadings(router, "otterburn")
+			require.Len(t, readings, 3)
+			assert.Equal(t, 0.8, readings[0].Level)
+			assert.Equal(t, 0.95, readings[1].Level, "existing timestamp should take the new level")
+			assert.Equal(t, time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC), readings[2].Timestamp)
+			assert.Equal(t, 1.05, readings[2].Level)
+		})
+
+		t.Run("writes to the default gauge without a gauge in the path", func(t *testing.T) {
+			router := newRouter(b.river(t))
 
-		assert.Equal(t, http.StatusInternalServerError, rr.Code)
-		assert.Contains(t, rr.Body.String(), "Internal server error")
+			rr := post(router, "/river/readings", `{"readings":[{"timestamp":"2024-01-03T09:00:00Z","level":1.0}]}`)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			assert.Len(t, getReadings(router, "rede-bridge"), 6)
+		})
+
+		t.Run("returns 404 for unknown gauge", func(t *testing.T) {
+			rr := post(newRouter(b.river(t)), "/river/nonexistent/readings", `{"readings":[{"timestamp":"2024-01-03T09:00:00Z","level":1.0}]}`)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Gauge not found")
+		})
+
+		t.Run("validates the batch", func(t *testing.T) {
+			tooMany := `{"readings":[` + strings.Repeat(`{"timestamp":"2024-01-03T09:00:00Z","level":1.0},`, 1000) + `{"timestamp":"2024-01-03T09:00:00Z","level":1.0}]}`
+
+			tests := []struct {
+				name    string
+				body    string
+				message string
+			}{
+				{"invalid json", `{"readings":`, "Request body must be JSON with a readings array"},
+				{"unknown field", `{"readings":[{"timestamp":"2024-01-03T09:00:00Z","level":1.0,"station":"x"}]}`, "Request body must be JSON with a readings array"},
+				{"no readings", `{"readings":[]}`, "At least one reading is required"},
+				{"too many readings", tooMany, "At most 1000 readings can be written at once"},
+				{"date only timestamp", `{"readings":[{"timestamp":"2024-01-03","level":1.0}]}`, "Timestamp of readings[0] must be an RFC 3339 datetime"},
+				{"missing level", `{"readings":[{"timestamp":"2024-01-03T09:00:00Z","level":1.0},{"timestamp":"2024-01-03T10:00:00Z"}]}`, "Level of readings[1] is required"},
+				{"negative level", `{"readings":[{"timestamp":"2024-01-03T09:00:00Z","level":-0.1}]}`, "Level of readings[0] must not be negative"},
+			}
+
+			for _, tc := range tests {
+				t.Run(tc.name, func(t *testing.T) {
+					router := newRouter(b.river(t))
+					rr := post(router, "/river/otterburn/readings", tc.body)
+
+					assert.Equal(t, http.StatusBadRequest, rr.Code)
+					assert.JSONEq(t, fmt.Sprintf(`{"error":%q}`, tc.message), rr.Body.String())
+					assert.Len(t, getReadings(router, "otterburn"), 2, "nothing should be written")
+				})
+			}
+		})
+
+		t.Run("returns 500 when the repository fails", func(t *testing.T) {
+			rr := post(newRouter(&mockErrorRepo{}), "/river/readings", `{"readings":[{"timestamp":"2024-01-03T09:00:00Z","level":1.0}]}`)
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		})
+	})
+}
+
+func TestRequireBearerToken(t *testing.T) {
+	router := chi.NewRouter()
+	router.With(RequireBearerToken("s3cret")).Post("/river/readings", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
 	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		expected      int
+	}{
+		{"valid token", "Bearer s3cret", http.StatusOK},
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer guess", http.StatusUnauthorized},
+		{"wrong scheme", "Basic s3cret", http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/river/readings", nil)
+			require.NoError(t, err)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expected, rr.Code)
+			if tc.expected == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="flood-api"`, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
 }
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+)
+
+type StationHandler struct {
+	repo   repository.StationRepository
+	logger *slog.Logger
+}
+
+func NewStationHandler(repo repository.StationRepository, logger *slog.Logger) *StationHandler {
+	return &StationHandler{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (h *StationHandler) ListStations(w http.ResponseWriter, r *http.Request) {
+	stations, err := h.repo.ListStations(r.Context())
+	if err != nil {
+		h.logger.Error("Error fetching stations", "error", err)
+		http.Error(w, "Internal server error when getting stations", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"stations": stations,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Error encoding response", "error", err)
+	}
+}
+
+func (h *StationHandler) GetStation(w http.ResponseWriter, r *http.Request) {
+	stationName := chi.URLParam(r, "station")
+
+	station, err := h.repo.GetStation(r.Context(), stationName)
+	if err != nil {
+		if err == domain.ErrNotFound {
+			h.logger.Warn("Station not found", "station", stationName)
+			http.Error(w, "Station not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Error fetching station", "error", err)
+		http.Error(w, "Internal server error when getting station", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"station": station,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error("Error encoding response", "error", err)
+	}
+}
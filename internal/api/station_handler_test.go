@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStationErrorRepo struct{}
+
+func (m *mockStationErrorRepo) ListStations(ctx context.Context) ([]domain.StationSummary, error) {
+	return nil, fmt.Errorf("repository error")
+}
+
+func (m *mockStationErrorRepo) GetStation(ctx context.Context, stationName string) (domain.StationSummary, error) {
+	return domain.StationSummary{}, fmt.Errorf("repository error")
+}
+
+type stationResponse struct {
+	ID           string  `json:"id"`
+	Name         string  `json:"name"`
+	FirstReading *string `json:"first_reading"`
+	LastReading  *string `json:"last_reading"`
+	ReadingCount int64   `json:"reading_count"`
+}
+
+func TestStationHandler_ListStations(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		t.Run("returns all stations sorted by name", func(t *testing.T) {
+			repo := b.station(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewStationHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/stations", handler.ListStations)
+
+			req, err := http.NewRequest("GET", "/stations", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+			var response struct {
+				Stations []stationResponse `json:"stations"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			require.Len(t, response.Stations, 11)
+			assert.Equal(t, "acomb-codlaw-hill", response.Stations[0].Name)
+			assert.Equal(t, "015313", response.Stations[0].ID)
+			assert.Nil(t, response.Stations[0].FirstReading)
+			assert.Equal(t, int64(0), response.Stations[0].ReadingCount)
+			assert.Equal(t, "knarsdale", response.Stations[10].Name)
+		})
+
+		t.Run("handles repository errors gracefully", func(t *testing.T) {
+			repo := &mockStationErrorRepo{}
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewStationHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/stations", handler.ListStations)
+
+			req, err := http.NewRequest("GET", "/stations", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusInternalServerError, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Internal server error")
+		})
+	})
+}
+
+func TestStationHandler_GetStation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		t.Run("returns station with reading span", func(t *testing.T) {
+			repo := b.station(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewStationHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/stations/{station}", handler.GetStation)
+
+			req, err := http.NewRequest("GET", "/stations/catcleugh", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response struct {
+				Station stationResponse `json:"station"`
+			}
+			err = json.Unmarshal(rr.Body.Bytes(), &response)
+			require.NoError(t, err)
+
+			assert.Equal(t, "010660", response.Station.ID)
+			assert.Equal(t, "catcleugh", response.Station.Name)
+			require.NotNil(t, response.Station.FirstReading)
+			require.NotNil(t, response.Station.LastReading)
+			assert.Equal(t, "2024-01-01T09:00:00Z", *response.Station.FirstReading)
+			assert.Equal(t, "2024-01-03T11:00:00Z", *response.Station.LastReading)
+			assert.Equal(t, int64(3), response.Station.ReadingCount)
+		})
+
+		t.Run("returns 404 for non-existent station", func(t *testing.T) {
+			repo := b.station(t)
+			logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+			handler := NewStationHandler(repo, logger)
+
+			router := chi.NewRouter()
+			router.Get("/stations/{station}", handler.GetStation)
+
+			req, err := http.NewRequest("GET", "/stations/non-existent", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Station not found")
+		})
+	})
+}
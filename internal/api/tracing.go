@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/tracing"
+)
+
+// startSpan starts a span for a handler and returns the request carrying it, so the repository's
+// queries nest under it
+func startSpan(r *http.Request, name string, attrs ...attribute.KeyValue) (*http.Request, trace.Span) {
+	ctx, span := otel.Tracer("github.com/oliverslade/flood-api/internal/api").Start(r.Context(), name, trace.WithAttributes(attrs...))
+	return r.WithContext(ctx), span
+}
+
+func paginationAttributes(pagination domain.PaginationParams) []attribute.KeyValue {
+	return []attribute.KeyValue{tracing.PageKey.Int(pagination.Page), tracing.PageSizeKey.Int(pagination.PageSize)}
+}
+
+// failSpan records the error behind a 500 on the handler's span
+func failSpan(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+	"github.com/oliverslade/flood-api/internal/tracing"
+)
+
+// recordSpans installs a tracer provider for the test that keeps every finished span
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestHandlerSpans(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	t.Run("describe the station, page and rows returned", func(t *testing.T) {
+		recorder := recordSpans(t)
+		handler := NewRainfallHandler(inmemory.NewRainfallRepoFrom(inmemory.NewStore(inmemory.Fixtures())), logger)
+		router := chi.NewRouter()
+		router.Get("/rainfall/{station}", handler.GetReadingsByStation)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/rainfall/catcleugh?page=1&pagesize=2", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "RainfallHandler.GetReadingsByStation", spans[0].Name())
+		attrs := spanAttributes(spans[0])
+		assert.Equal(t, "catcleugh", attrs[tracing.StationKey].AsString())
+		assert.Equal(t, int64(1), attrs[tracing.PageKey].AsInt64())
+		assert.Equal(t, int64(2), attrs[tracing.PageSizeKey].AsInt64())
+		assert.Equal(t, int64(2), attrs[tracing.RowCountKey].AsInt64())
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	})
+
+	t.Run("record repository errors", func(t *testing.T) {
+		recorder := recordSpans(t)
+		handler := NewRainfallHandler(&mockRainfallErrorRepo{}, logger)
+		router := chi.NewRouter()
+		router.Get("/rainfall/latest", handler.GetLatestReadings)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/rainfall/latest", nil))
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		require.Len(t, spans[0].Events(), 1)
+		assert.Equal(t, "exception", spans[0].Events()[0].Name)
+	})
+
+	t.Run("count exported rows", func(t *testing.T) {
+		recorder := recordSpans(t)
+		handler := NewRiverHandler(inmemory.NewRiverRepoFrom(inmemory.NewStore(inmemory.Fixtures())), logger)
+		router := chi.NewRouter()
+		router.Get("/export/river", handler.ExportReadings)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/export/river", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "RiverHandler.ExportReadings", spans[0].Name())
+		assert.Positive(t, spanAttributes(spans[0])[tracing.RowCountKey].AsInt64())
+	})
+}
@@ -0,0 +1,381 @@
+// Package config holds everything flood-api can be configured with. Settings are read from their
+// defaults, then a YAML or TOML file, then environment variables and finally command line flags,
+// each overriding the ones before.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/oliverslade/flood-api/internal/constants"
+)
+
+const (
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+	BackendMemory   = "memory"
+)
+
+// FileEnv names the config file when -config isn't given
+const FileEnv = "FLOOD_CONFIG"
+
+// Config is the effective configuration of flood-api
+type Config struct {
+	Server  Server  `yaml:"server" toml:"server"`
+	Storage Storage `yaml:"storage" toml:"storage"`
+	Pool    Pool    `yaml:"pool" toml:"pool"`
+	API     API     `yaml:"api" toml:"api"`
+	Ingest  Ingest  `yaml:"ingest" toml:"ingest"`
+}
+
+type Server struct {
+	Port         int           `yaml:"port" toml:"port"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// RequestTimeout cancels every request but exports after this long
+	RequestTimeout time.Duration `yaml:"request_timeout" toml:"request_timeout"`
+	DrainDelay     time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+	DrainTimeout   time.Duration `yaml:"drain_timeout" toml:"drain_timeout"`
+}
+
+type Storage struct {
+	Backend      string `yaml:"backend" toml:"backend"`
+	DatabaseURL  string `yaml:"database_url" toml:"database_url"`
+	SQLitePath   string `yaml:"sqlite_path" toml:"sqlite_path"`
+	FixturesPath string `yaml:"fixtures_path" toml:"fixtures_path"`
+	Migrate      bool   `yaml:"migrate" toml:"migrate"`
+}
+
+// Pool sizes the Postgres connection pool
+type Pool struct {
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+}
+
+type API struct {
+	DefaultPageSize int `yaml:"default_page_size" toml:"default_page_size"`
+	MaxPageSize     int `yaml:"max_page_size" toml:"max_page_size"`
+	// WriteToken authenticates the write endpoints, which are disabled without one
+	WriteToken string `yaml:"write_token" toml:"write_token"`
+}
+
+type Ingest struct {
+	Interval       time.Duration `yaml:"interval" toml:"interval"`
+	URL            string        `yaml:"url" toml:"url"`
+	Gauges         GaugeList     `yaml:"gauges" toml:"gauges"`
+	RollupInterval time.Duration `yaml:"rollup_interval" toml:"rollup_interval"`
+}
+
+// Default returns the settings flood-api runs with when nothing else is configured
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:           9001,
+			ReadTimeout:    10 * time.Second,
+			WriteTimeout:   10 * time.Second,
+			IdleTimeout:    120 * time.Second,
+			RequestTimeout: 5 * time.Second,
+			DrainDelay:     5 * time.Second,
+			DrainTimeout:   30 * time.Second,
+		},
+		Storage: Storage{Backend: BackendPostgres, Migrate: true},
+		Pool: Pool{
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: 30 * time.Second,
+		},
+		API:    API{DefaultPageSize: constants.DefaultPageSize, MaxPageSize: constants.MaxPageSize},
+		Ingest: Ingest{URL: constants.DefraBaseURL},
+	}
+}
+
+// secretEnv are the environment variables secrets are read from. They have no flags, so they
+// don't show up in the process list.
+var secretEnv = map[string]func(*Config) *string{
+	"DATABASE_URL":    func(c *Config) *string { return &c.Storage.DatabaseURL },
+	"API_WRITE_TOKEN": func(c *Config) *string { return &c.API.WriteToken },
+}
+
+// bind registers a flag for every setting but the secrets, writing into c
+func (c *Config) bind(fs *flag.FlagSet) {
+	fs.IntVar(&c.Server.Port, "port", c.Server.Port, "TCP port to listen on")
+	fs.DurationVar(&c.Server.ReadTimeout, "read-timeout", c.Server.ReadTimeout, "Longest time to read a request, including its body")
+	fs.DurationVar(&c.Server.WriteTimeout, "write-timeout", c.Server.WriteTimeout, "Longest time to write a response")
+	fs.DurationVar(&c.Server.IdleTimeout, "idle-timeout", c.Server.IdleTimeout, "Longest time to keep an idle connection open")
+	fs.DurationVar(&c.Server.RequestTimeout, "request-timeout", c.Server.RequestTimeout, "Cancel requests other than exports after this long")
+	fs.DurationVar(&c.Server.DrainDelay, "drain-delay", c.Server.DrainDelay, "On SIGTERM or SIGINT, fail readiness for this long before closing the listener so load balancers stop sending requests")
+	fs.DurationVar(&c.Server.DrainTimeout, "drain-timeout", c.Server.DrainTimeout, "On SIGTERM or SIGINT, give requests in flight this long to finish before cancelling them")
+
+	fs.StringVar(&c.Storage.Backend, "backend", c.Storage.Backend, "Storage backend to serve from: postgres, sqlite or memory")
+	fs.StringVar(&c.Storage.SQLitePath, "sqlite", c.Storage.SQLitePath, "Path of the SQLite database to serve with -backend=sqlite")
+	fs.StringVar(&c.Storage.FixturesPath, "fixtures", c.Storage.FixturesPath, "JSON file or directory of CSV files to serve with -backend=memory, the built-in fixtures when empty")
+	fs.BoolVar(&c.Storage.Migrate, "migrate", c.Storage.Migrate, "Apply pending Postgres migrations before serving")
+
+	fs.IntVar(&c.Pool.MaxOpenConns, "pool-max-open", c.Pool.MaxOpenConns, "Most Postgres connections to open, 0 for no limit")
+	fs.IntVar(&c.Pool.MaxIdleConns, "pool-max-idle", c.Pool.MaxIdleConns, "Most idle Postgres connections to keep")
+	fs.DurationVar(&c.Pool.ConnMaxLifetime, "pool-max-lifetime", c.Pool.ConnMaxLifetime, "Close Postgres connections after this long, 0 to keep them")
+	fs.DurationVar(&c.Pool.ConnMaxIdleTime, "pool-max-idle-time", c.Pool.ConnMaxIdleTime, "Close Postgres connections idle for this long, 0 to keep them")
+
+	fs.IntVar(&c.API.DefaultPageSize, "default-page-size", c.API.DefaultPageSize, "Readings per page when the pagesize parameter isn't given")
+	fs.IntVar(&c.API.MaxPageSize, "max-page-size", c.API.MaxPageSize, "Most readings a page can be asked for")
+
+	fs.DurationVar(&c.Ingest.Interval, "ingest-interval", c.Ingest.Interval, "Poll Defra for new readings on this interval, disabled when 0")
+	fs.StringVar(&c.Ingest.URL, "ingest-url", c.Ingest.URL, "Base URL of the Defra flood monitoring API")
+	fs.Var(&c.Ingest.Gauges, "ingest-gauge", "River gauge to poll as name=station-reference, repeatable")
+	fs.DurationVar(&c.Ingest.RollupInterval, "rollup-interval", c.Ingest.RollupInterval, "Refresh the rainfall rollups with readings written through the API or imports on this interval, disabled when 0")
+}
+
+// envName is the environment variable a flag's setting can be given in, such as FLOOD_INGEST_INTERVAL
+func envName(flagName string) string {
+	return "FLOOD_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Load builds the configuration from the defaults, the config file named by -config or FLOOD_CONFIG,
+// the environment read through getenv and the flags in args, then validates it. It returns
+// flag.ErrHelp when -h was given, after printing the usage to stderr.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg, _, err := load(args, getenv)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// LoadStorage is Load for commands that only use the database, such as migrate. Only the storage
+// settings are validated, and the arguments left after the flags are returned.
+func LoadStorage(args []string, getenv func(string) string) (Config, []string, error) {
+	cfg, rest, err := load(args, getenv)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if err := cfg.ValidateStorage(); err != nil {
+		return Config{}, nil, err
+	}
+	return cfg, rest, nil
+}
+
+func load(args []string, getenv func(string) string) (Config, []string, error) {
+	// the flags are parsed once to find the config file, and again over the file and environment
+	// so that they take precedence
+	given := Default()
+	fs := newFlagSet(&given, getenv, os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	cfg := Default()
+	if path := fs.Lookup("config").Value.String(); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return Config{}, nil, err
+		}
+	}
+	if err := cfg.loadEnv(getenv); err != nil {
+		return Config{}, nil, err
+	}
+
+	// gauges given as flags replace the configured ones rather than adding to them
+	if len(given.Ingest.Gauges) > 0 {
+		cfg.Ingest.Gauges = nil
+	}
+	if err := newFlagSet(&cfg, getenv, io.Discard).Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+func newFlagSet(c *Config, getenv func(string) string, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("flood-api", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.String("config", getenv(FileEnv), "YAML or TOML file to read settings from, overridden by environment variables and flags")
+	c.bind(fs)
+	return fs
+}
+
+// loadFile reads the settings in a YAML or TOML file, chosen by its extension. Settings it doesn't
+// mention keep their current values, and keys that aren't settings are rejected.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml, not %q", path, ext)
+	}
+	return nil
+}
+
+// loadEnv reads the FLOOD_ variable of every flag and the secrets. FLOOD_INGEST_GAUGE takes a
+// comma separated list of gauges.
+func (c *Config) loadEnv(getenv func(string) string) error {
+	fs := flag.NewFlagSet("env", flag.ContinueOnError)
+	c.bind(fs)
+
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		value := getenv(name)
+		if value == "" {
+			return
+		}
+		values := []string{value}
+		if f.Name == "ingest-gauge" {
+			c.Ingest.Gauges = nil
+			values = strings.Split(value, ",")
+		}
+		for _, v := range values {
+			if err := f.Value.Set(strings.TrimSpace(v)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	})
+
+	for name, field := range secretEnv {
+		if value := getenv(name); value != "" {
+			*field(c) = value
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate reports every setting that is out of range or doesn't fit with the others
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	type duration struct {
+		name string
+		d    time.Duration
+	}
+	for _, t := range []duration{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.request_timeout", c.Server.RequestTimeout},
+		{"server.drain_timeout", c.Server.DrainTimeout},
+	} {
+		check(t.d > 0, "%s must be positive, got %s", t.name, t.d)
+	}
+	for _, t := range []duration{
+		{"server.drain_delay", c.Server.DrainDelay},
+		{"pool.conn_max_lifetime", c.Pool.ConnMaxLifetime},
+		{"pool.conn_max_idle_time", c.Pool.ConnMaxIdleTime},
+		{"ingest.interval", c.Ingest.Interval},
+		{"ingest.rollup_interval", c.Ingest.RollupInterval},
+	} {
+		check(t.d >= 0, "%s must not be negative, got %s", t.name, t.d)
+	}
+
+	if err := c.ValidateStorage(); err != nil {
+		errs = append(errs, err)
+	}
+
+	check(c.Pool.MaxOpenConns >= 0, "pool.max_open_conns must not be negative, got %d", c.Pool.MaxOpenConns)
+	check(c.Pool.MaxIdleConns >= 0, "pool.max_idle_conns must not be negative, got %d", c.Pool.MaxIdleConns)
+
+	check(c.API.DefaultPageSize > 0, "api.default_page_size must be positive, got %d", c.API.DefaultPageSize)
+	check(c.API.MaxPageSize >= c.API.DefaultPageSize, "api.max_page_size must be at least api.default_page_size (%d), got %d", c.API.DefaultPageSize, c.API.MaxPageSize)
+
+	// only Postgres can be kept topped up from Defra
+	check(c.Ingest.Interval == 0 || c.Storage.Backend == BackendPostgres, "ingest.interval is only supported with the postgres backend")
+	check(c.Ingest.RollupInterval == 0 || c.Storage.Backend == BackendPostgres, "ingest.rollup_interval is only supported with the postgres backend")
+	check(c.Ingest.Interval == 0 || c.Ingest.URL != "", "ingest.url is required when ingest.interval is set")
+
+	return errors.Join(errs...)
+}
+
+// ValidateStorage reports a storage backend that is unknown or is missing its database
+func (c Config) ValidateStorage() error {
+	switch c.Storage.Backend {
+	case BackendPostgres:
+		if c.Storage.DatabaseURL == "" {
+			return errors.New("DATABASE_URL is required with the postgres backend")
+		}
+	case BackendSQLite:
+		if c.Storage.SQLitePath == "" {
+			return errors.New("storage.sqlite_path is required with the sqlite backend")
+		}
+	case BackendMemory:
+	default:
+		return fmt.Errorf("storage.backend must be postgres, sqlite or memory, got %q", c.Storage.Backend)
+	}
+	return nil
+}
+
+// Redacted returns a copy with the secrets hidden, keeping the host of the database URL so it can be checked
+func (c Config) Redacted() Config {
+	if c.Storage.DatabaseURL != "" {
+		c.Storage.DatabaseURL = redactURL(c.Storage.DatabaseURL)
+	}
+	if c.API.WriteToken != "" {
+		c.API.WriteToken = redacted
+	}
+	return c
+}
+
+const redacted = "REDACTED"
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		// a key=value connection string could hold the password anywhere
+		return redacted
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	q := u.Query()
+	if q.Has("password") {
+		q.Set("password", redacted)
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// Print writes the configuration as YAML with its secrets redacted, in the form Load reads
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// env returns a getenv reading from vars
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := Load(nil, env(map[string]string{"DATABASE_URL": "postgres://localhost/flood"}))
+		require.NoError(t, err)
+
+		want := Default()
+		want.Storage.DatabaseURL = "postgres://localhost/flood"
+		assert.Equal(t, want, cfg)
+	})
+
+	t.Run("flags override the environment, which overrides the file", func(t *testing.T) {
+		path := writeFile(t, "flood.yaml", `
+server:
+  port: 9100
+  request_timeout: 8s
+storage:
+  backend: memory
+api:
+  max_page_size: 500
+ingest:
+  gauges:
+    - name: rede-bridge
+      reference: "22009"
+`)
+		cfg, err := Load([]string{"-config", path, "-port", "9300"}, env(map[string]string{
+			"FLOOD_PORT":          "9200",
+			"FLOOD_MAX_PAGE_SIZE": "400",
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, 9300, cfg.Server.Port)
+		assert.Equal(t, 8*time.Second, cfg.Server.RequestTimeout)
+		assert.Equal(t, BackendMemory, cfg.Storage.Backend)
+		assert.Equal(t, 400, cfg.API.MaxPageSize)
+		assert.Equal(t, GaugeList{{Name: "rede-bridge", Reference: "22009"}}, cfg.Ingest.Gauges)
+		assert.Equal(t, 10*time.Second, cfg.Server.ReadTimeout, "settings no source mentions keep their defaults")
+	})
+
+	t.Run("the config file can be named in the environment", func(t *testing.T) {
+		path := writeFile(t, "flood.yml", "storage:\n  backend: memory\n")
+		cfg, err := Load(nil, env(map[string]string{FileEnv: path}))
+		require.NoError(t, err)
+		assert.Equal(t, BackendMemory, cfg.Storage.Backend)
+	})
+
+	t.Run("gauges replace the ones before rather than adding to them", func(t *testing.T) {
+		path := writeFile(t, "flood.yaml", "storage:\n  backend: memory\ningest:\n  gauges:\n    - {name: a, reference: '1'}\n")
+
+		cfg, err := Load([]string{"-config", path}, env(map[string]string{"FLOOD_INGEST_GAUGE": "b=2, c=3"}))
+		require.NoError(t, err)
+		assert.Equal(t, GaugeList{{Name: "b", Reference: "2"}, {Name: "c", Reference: "3"}}, cfg.Ingest.Gauges)
+
+		cfg, err = Load([]string{"-config", path, "-ingest-gauge", "d=4", "-ingest-gauge", "e=5"}, env(map[string]string{"FLOOD_INGEST_GAUGE": "b=2"}))
+		require.NoError(t, err)
+		assert.Equal(t, GaugeList{{Name: "d", Reference: "4"}, {Name: "e", Reference: "5"}}, cfg.Ingest.Gauges)
+	})
+
+	t.Run("secrets are only read from the file and environment", func(t *testing.T) {
+		path := writeFile(t, "flood.yaml", "api:\n  write_token: from-file\n")
+		cfg, err := Load([]string{"-config", path}, env(map[string]string{"DATABASE_URL": "postgres://localhost/flood"}))
+		require.NoError(t, err)
+		assert.Equal(t, "from-file", cfg.API.WriteToken)
+
+		cfg, err = Load([]string{"-config", path}, env(map[string]string{"DATABASE_URL": "postgres://localhost/flood", "API_WRITE_TOKEN": "from-env"}))
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", cfg.API.WriteToken)
+
+		_, err = Load([]string{"-database-url", "postgres://localhost/flood"}, env(nil))
+		assert.ErrorContains(t, err, "flag provided but not defined")
+	})
+
+	t.Run("rejects what it can't read", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			args []string
+			env  map[string]string
+			want string
+		}{
+			"unknown key":    {args: []string{"-config", writeFile(t, "flood.yaml", "server:\n  prot: 9100\n")}, want: "field prot not found"},
+			"wrong type":     {args: []string{"-config", writeFile(t, "flood.yaml", "server:\n  port: ninety\n")}, want: "cannot unmarshal"},
+			"unknown toml":   {args: []string{"-config", writeFile(t, "flood.toml", "[server]\nprot = 9100\n")}, want: "unknown keys [server.prot]"},
+			"bad toml":       {args: []string{"-config", writeFile(t, "flood.toml", "[server]\nport = ninety\n")}, want: "flood.toml"},
+			"bad extension":  {args: []string{"-config", writeFile(t, "flood.json", "{}")}, want: "must be .yaml, .yml or .toml"},
+			"missing file":   {args: []string{"-config", "/nonexistent/flood.yaml"}, want: "no such file"},
+			"bad env":        {env: map[string]string{"FLOOD_REQUEST_TIMEOUT": "soon"}, want: "FLOOD_REQUEST_TIMEOUT"},
+			"bad env gauge":  {env: map[string]string{"FLOOD_INGEST_GAUGE": "rede-bridge"}, want: "gauge must be name=reference"},
+			"bad flag":       {args: []string{"-port", "ninety"}, want: "invalid value"},
+			"invalid config": {args: []string{"-backend", "mysql"}, want: "storage.backend must be postgres, sqlite or memory"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := Load(tc.args, env(tc.env))
+				assert.ErrorContains(t, err, tc.want)
+			})
+		}
+	})
+}
+
+func TestLoadStorage(t *testing.T) {
+	// the server's settings are out of range, but a migration doesn't use them
+	cfg, rest, err := LoadStorage([]string{"-port", "0", "-drain-timeout", "0s", "3"}, env(map[string]string{"DATABASE_URL": "postgres://localhost/flood"}))
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://localhost/flood", cfg.Storage.DatabaseURL)
+	assert.Equal(t, []string{"3"}, rest)
+
+	_, _, err = LoadStorage(nil, env(nil))
+	assert.ErrorContains(t, err, "DATABASE_URL is required")
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := writeFile(t, "flood.toml", `
+# the same settings as YAML, in TOML
+[server]
+port = 9_100
+request_timeout = "8s" # trailing comments are ignored
+
+[storage]
+backend = 'sqlite'
+sqlite_path = "/data/flood#1.db"
+migrate = false
+
+[[ingest.gauges]]
+name = "rede-bridge"
+reference = "22009"
+
+[[ingest.gauges]]
+name = "otterburn"
+reference = "22007"
+`)
+	cfg, err := Load([]string{"-config", path}, env(nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, 9100, cfg.Server.Port)
+	assert.Equal(t, 8*time.Second, cfg.Server.RequestTimeout)
+	assert.Equal(t, Storage{Backend: BackendSQLite, SQLitePath: "/data/flood#1.db", Migrate: false}, cfg.Storage)
+	assert.Equal(t, GaugeList{{Name: "rede-bridge", Reference: "22009"}, {Name: "otterburn", Reference: "22007"}}, cfg.Ingest.Gauges)
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	require.ErrorContains(t, cfg.Validate(), "DATABASE_URL is required")
+
+	cfg.Storage.Backend = BackendSQLite
+	cfg.Server.Port = 0
+	cfg.Server.RequestTimeout = 0
+	cfg.Pool.MaxIdleConns = -1
+	cfg.API.DefaultPageSize = 100
+	cfg.API.MaxPageSize = 50
+	cfg.Ingest.Interval = time.Minute
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"server.port must be between 1 and 65535, got 0",
+		"server.request_timeout must be positive, got 0s",
+		"storage.sqlite_path is required with the sqlite backend",
+		"pool.max_idle_conns must not be negative, got -1",
+		"api.max_page_size must be at least api.default_page_size (100), got 50",
+		"ingest.interval is only supported with the postgres backend",
+	}, strings.Split(err.Error(), "\n"))
+}
+
+func TestPrint(t *testing.T) {
+	cfg := Default()
+	cfg.Storage.DatabaseURL = "postgres://flood:hunter2@db:5432/flood?sslmode=disable"
+	cfg.API.WriteToken = "s3cret"
+	cfg.Ingest.Gauges = GaugeList{{Name: "rede-bridge", Reference: "22009"}}
+
+	var out strings.Builder
+	require.NoError(t, cfg.Print(&out))
+	assert.Contains(t, out.String(), "database_url: postgres://flood:REDACTED@db:5432/flood?sslmode=disable\n")
+	assert.Contains(t, out.String(), "write_token: REDACTED\n")
+	assert.Contains(t, out.String(), "request_timeout: 5s\n")
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), "s3cret")
+
+	// the printed config reads back as the same settings, bar the secrets
+	path := writeFile(t, "printed.yaml", out.String())
+	loaded, err := Load([]string{"-config", path}, env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Redacted(), loaded)
+
+	for raw, want := range map[string]string{
+		"postgres://localhost/flood":                "postgres://localhost/flood",
+		"postgres://db/flood?password=hunter2":      "postgres://db/flood?password=REDACTED",
+		"host=db user=flood password=hunter2":       "REDACTED",
+		"postgres://flood@db/flood?sslmode=disable": "postgres://flood@db/flood?sslmode=disable",
+	} {
+		assert.Equal(t, want, redactURL(raw), raw)
+	}
+}
+
+func TestGaugeList(t *testing.T) {
+	var gauges GaugeList
+
+	require.NoError(t, gauges.Set("rede-bridge=22009"))
+	require.NoError(t, gauges.Set("otterburn=22007"))
+	assert.Equal(t, GaugeList{{Name: "rede-bridge", Reference: "22009"}, {Name: "otterburn", Reference: "22007"}}, gauges)
+	assert.Equal(t, "rede-bridge=22009,otterburn=22007", gauges.String())
+
+	for _, invalid := range []string{"rede-bridge", "=22009", "rede-bridge=", "latest=22009", "aggregate=22009"} {
+		assert.Error(t, gauges.Set(invalid), invalid)
+	}
+}
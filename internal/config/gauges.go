@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/oliverslade/flood-api/internal/constants"
+)
+
+// Gauge maps a river gauge name to the Defra station reference that measures it
+type Gauge struct {
+	Name      string
+	Reference string
+}
+
+// GaugeList is a repeatable name=reference flag value
+type GaugeList []Gauge
+
+func (g *GaugeList) String() string {
+	pairs := make([]string, 0, len(*g))
+	for _, gauge := range *g {
+		pairs = append(pairs, gauge.Name+"="+gauge.Reference)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (g *GaugeList) Set(value string) error {
+	name, ref, ok := strings.Cut(value, "=")
+	if !ok || name == "" || ref == "" {
+		return fmt.Errorf("gauge must be name=reference, got %q", value)
+	}
+	if slices.Contains(constants.ReservedGaugeNames, name) {
+		return fmt.Errorf("gauge name %q is reserved", name)
+	}
+	*g = append(*g, Gauge{Name: name, Reference: ref})
+	return nil
+}
@@ -0,0 +1,8 @@
+package constants
+
+// DefaultRiverGauge is served by the gauge-less /river routes, which predate multiple gauges
+const DefaultRiverGauge = "rede-bridge"
+
+// ReservedGaugeNames can't name a gauge, as the /river/latest and /river/aggregate routes would
+// shadow its /river/{gauge} route
+var ReservedGaugeNames = []string{"latest", "aggregate"}
@@ -0,0 +1,4 @@
+package constants
+
+// DefraBaseURL is the Environment Agency real-time flood monitoring API
+const DefraBaseURL = "https://environment.data.gov.uk/flood-monitoring"
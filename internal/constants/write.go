@@ -0,0 +1,4 @@
+package constants
+
+// MaxWriteBatchSize caps the readings accepted by a single write request
+const MaxWriteBatchSize = 1000
@@ -4,31 +4,64 @@ import (
 	"encoding/json"
 	"errors"
 	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
 var ErrNotFound = errors.New("not found")
 
+// UnknownStationsError lists the requested station names that don't exist
+type UnknownStationsError struct {
+	Names []string
+}
+
+func (e *UnknownStationsError) Error() string {
+	return "unknown stations: " + strings.Join(e.Names, ", ")
+}
+
+func (e *UnknownStationsError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
 // roundLevel rounds to 3 decimal places as per API spec
 func roundLevel(v float64) float64 {
 	return math.Round(v*1000) / 1000
 }
 
+// formatCSVLevel renders a level for CSV output with the same rounding as JSON
+func formatCSVLevel(v float64) string {
+	return strconv.FormatFloat(roundLevel(v), 'f', -1, 64)
+}
+
+// Column names matching the CSVRecord output of each reading type
+var (
+	RiverReadingCSVHeader    = []string{"timestamp", "level", "gauge"}
+	RainfallReadingCSVHeader = []string{"timestamp", "level", "station"}
+)
+
 type RiverReading struct {
 	Timestamp time.Time `json:"timestamp"`
 	Level     float64   `json:"level"`
+	GaugeName string    `json:"gauge"`
 }
 
 func (r RiverReading) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
 		Timestamp time.Time `json:"timestamp"`
 		Level     float64   `json:"level"`
+		Gauge     string    `json:"gauge"`
 	}{
 		Timestamp: r.Timestamp,
 		Level:     roundLevel(r.Level),
+		Gauge:     r.GaugeName,
 	})
 }
 
+func (r RiverReading) CSVRecord() []string {
+	return []string{r.Timestamp.Format(time.RFC3339Nano), formatCSVLevel(r.Level), r.GaugeName}
+}
+
 type RainfallReading struct {
 	Timestamp   time.Time `json:"timestamp"`
 	Level       float64   `json:"level"`
@@ -47,22 +80,142 @@ func (r RainfallReading) MarshalJSON() ([]byte, error) {
 	})
 }
 
+func (r RainfallReading) CSVRecord() []string {
+	return []string{r.Timestamp.Format(time.RFC3339Nano), formatCSVLevel(r.Level), r.StationName}
+}
+
+// AggregateBucket summarises the readings in one time bucket
+type AggregateBucket struct {
+	Start time.Time `json:"start"`
+	Value float64   `json:"value"`
+	Count int64     `json:"count"`
+}
+
+func (b AggregateBucket) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Start time.Time `json:"start"`
+		Value float64   `json:"value"`
+		Count int64     `json:"count"`
+	}{
+		Start: b.Start,
+		Value: roundLevel(b.Value),
+		Count: b.Count,
+	})
+}
+
 type Station struct {
 	ID   string
 	Name string
 }
 
+// Gauge is a river level monitoring point
+type Gauge struct {
+	ID    string
+	Name  string
+	River string
+}
+
+// ImportResult counts what happened to the rows of one bulk loaded table
+type ImportResult struct {
+	Imported int64 // rows that were new and stored
+	Skipped  int64 // rows already stored, or repeated within the source
+	Rejected int64 // rows that couldn't be converted, or refer to a station that doesn't exist
+}
+
+// Measurement is a single ingested value before it is stored against a station or gauge
+type Measurement struct {
+	Timestamp time.Time
+	Level     float64
+}
+
+// StationSummary describes a station and the span of readings it has recorded
+type StationSummary struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	FirstReading *time.Time `json:"first_reading"`
+	LastReading  *time.Time `json:"last_reading"`
+	ReadingCount int64      `json:"reading_count"`
+}
+
 type PaginationParams struct {
 	Page     int
 	PageSize int
+	After    *time.Time // Optional keyset cursor, takes precedence over Page but is still bounded by StartDate
 }
 
 type GetReadingsParams struct {
 	Pagination PaginationParams
-	StartDate  *time.Time // Optional start date filter
+	StartDate  *time.Time // Optional start date filter (inclusive)
+	EndDate    *time.Time // Optional end date filter (exclusive)
+}
+
+type GetRiverParams struct {
+	GaugeName string
+	GetReadingsParams
 }
 
 type GetRainfallParams struct {
 	StationName string
 	GetReadingsParams
 }
+
+type GetRainfallStationsParams struct {
+	StationNames []string // Stations to merge, nil for every station
+	GetReadingsParams
+}
+
+// PageBounds describes a page of readings before any of it is streamed, so the links to the
+// pages either side of it can be sent ahead of the readings
+type PageBounds struct {
+	Count int       // Readings on the page
+	Last  time.Time // Timestamp of the last reading, zero when the page is empty
+}
+
+// Aggregate intervals match the Postgres date_trunc field names
+const (
+	IntervalHour  = "hour"
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// Aggregate functions applied to the levels in each bucket
+const (
+	AggregateSum  = "sum"
+	AggregateMean = "mean"
+	AggregateMax  = "max"
+	AggregateMin  = "min"
+)
+
+type AggregateParams struct {
+	Interval  string
+	Func      string
+	StartDate *time.Time // Optional start date filter (inclusive)
+	EndDate   *time.Time // Optional end date filter (exclusive)
+}
+
+type GetRiverAggregateParams struct {
+	GaugeName string
+	AggregateParams
+}
+
+type GetRainfallAggregateParams struct {
+	StationName string
+	AggregateParams
+}
+
+// ExportParams bounds a full-history export, which has no pagination
+type ExportParams struct {
+	StartDate *time.Time // Optional start date filter (inclusive)
+	EndDate   *time.Time // Optional end date filter (exclusive)
+}
+
+type ExportRiverParams struct {
+	GaugeName string
+	ExportParams
+}
+
+type ExportRainfallParams struct {
+	StationName string
+	ExportParams
+}
@@ -0,0 +1,149 @@
+// Package health serves the liveness and readiness probes, checking the dependencies the API needs
+// before it reports itself ready
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckTimeout bounds each check, so a database that has stopped answering fails readiness rather
+// than holding the probe open
+const CheckTimeout = 2 * time.Second
+
+// Check reports whether a dependency is usable, returning an error saying why when it isn't
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Ping checks the database accepts queries
+func Ping(db *sql.DB) Check {
+	return Check{Name: "database", Run: db.PingContext}
+}
+
+// HasRows checks table holds at least one row, named by the table without its schema
+func HasRows(db *sql.DB, table string) Check {
+	_, name, found := strings.Cut(table, ".")
+	if !found {
+		name = table
+	}
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		var exists bool
+		if err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+")").Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return errors.New(name + " is empty")
+		}
+		return nil
+	}}
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name string `json:"name"`
+	// Status is ok or failed
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Report is the outcome of every check, ok only when they all passed. A draining server reports
+// draining without running any.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Live serves /healthz, answering as long as the process is serving requests at all
+func Live(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+// Ready serves /readyz, running its checks on every request
+type Ready struct {
+	checks   []Check
+	draining atomic.Bool
+}
+
+func NewReady(checks ...Check) *Ready {
+	return &Ready{checks: checks}
+}
+
+// Drain fails readiness from now on, so load balancers stop sending requests before the server shuts down
+func (h *Ready) Drain() {
+	h.draining.Store(true)
+}
+
+// Run runs every check at once, each bounded by CheckTimeout, and reports them in the order given
+func (h *Ready) Run(ctx context.Context) Report {
+	if h.draining.Load() {
+		return Report{Status: "draining", Checks: []Result{}}
+	}
+
+	results := make([]Result, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Run(ctx)
+			results[i] = Result{Name: check.Name, Status: "ok", DurationMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				results[i].Status = "failed"
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: "ok", Checks: results}
+	for _, result := range results {
+		if result.Status != "ok" {
+			report.Status = "failed"
+		}
+	}
+	return report
+}
+
+// ServeHTTP answers 200 when every check passes and 503 otherwise. The body is ok or the failed
+// checks, or with ?verbose the JSON report of every check and how long it took.
+func (h *Ready) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.Run(r.Context())
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+
+	if r.URL.Query().Has("verbose") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	if status == http.StatusOK || report.Status == "draining" {
+		w.Write([]byte(report.Status + "\n"))
+		return
+	}
+	for _, result := range report.Checks {
+		if result.Status != "ok" {
+			w.Write([]byte(result.Name + ": " + result.Error + "\n"))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, h http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func passing(name string) Check {
+	return Check{Name: name, Run: func(context.Context) error { return nil }}
+}
+
+func TestLive(t *testing.T) {
+	rec := probe(t, http.HandlerFunc(Live), "/healthz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok\n", rec.Body.String())
+}
+
+func TestReady(t *testing.T) {
+	t.Run("ready when every check passes", func(t *testing.T) {
+		rec := probe(t, NewReady(passing("database"), passing("stationnames")), "/readyz")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "ok\n", rec.Body.String())
+	})
+
+	t.Run("unavailable naming the failed checks", func(t *testing.T) {
+		failing := Check{Name: "migrations", Run: func(context.Context) error { return errors.New("migrated to version 9, want 10") }}
+		rec := probe(t, NewReady(passing("database"), failing), "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "migrations: migrated to version 9, want 10\n", rec.Body.String())
+	})
+
+	t.Run("verbose reports every check and its duration", func(t *testing.T) {
+		slow := Check{Name: "database", Run: func(ctx context.Context) error {
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		}}
+		rec := probe(t, NewReady(slow, passing("stationnames")), "/readyz?verbose")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var report Report
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.Equal(t, "ok", report.Status)
+		require.Len(t, report.Checks, 2)
+		assert.Equal(t, "database", report.Checks[0].Name)
+		assert.GreaterOrEqual(t, report.Checks[0].DurationMS, 5.0)
+		assert.Equal(t, "stationnames", report.Checks[1].Name)
+	})
+
+	t.Run("unavailable once draining", func(t *testing.T) {
+		ready := NewReady(passing("database"))
+		ready.Drain()
+
+		rec := probe(t, ready, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "draining\n", rec.Body.String())
+
+		rec = probe(t, ready, "/readyz?verbose")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"draining","checks":[]}`, rec.Body.String())
+	})
+
+	t.Run("checks that hang are cut off", func(t *testing.T) {
+		hung := Check{Name: "database", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+		start := time.Now()
+		report := NewReady(hung).Run(context.Background())
+		assert.Less(t, time.Since(start), 2*CheckTimeout)
+		assert.Equal(t, "failed", report.Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+	})
+}
+
+func TestDatabaseChecks(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	// every connection to :memory: is a new database
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec("CREATE TABLE stationnames (id text, name text)")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, Ping(db).Run(ctx))
+
+	stations := HasRows(db, "stationnames")
+	assert.EqualError(t, stations.Run(ctx), "stationnames is empty")
+	_, err = db.Exec("INSERT INTO stationnames VALUES ('031555', 'catcleugh')")
+	require.NoError(t, err)
+	assert.NoError(t, stations.Run(ctx))
+
+	assert.Equal(t, "stationnames", HasRows(db, "public.stationnames").Name)
+	assert.Error(t, HasRows(db, "missing").Run(ctx))
+}
@@ -0,0 +1,109 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// timestampLayouts are the text forms the challenge data and Postgres dumps of it use
+var timestampLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// parseStation converts a stationnames row, both columns being required since migration 004
+func parseStation(rawID, rawName interface{}) (domain.Station, error) {
+	id, err := parseText("id", rawID)
+	if err != nil {
+		return domain.Station{}, err
+	}
+	name, err := parseText("name", rawName)
+	if err != nil {
+		return domain.Station{}, err
+	}
+	return domain.Station{ID: id, Name: name}, nil
+}
+
+// parseMeasurement converts the timestamp and level columns shared by both reading tables
+func parseMeasurement(rawTimestamp, rawLevel interface{}) (domain.Measurement, error) {
+	timestamp, err := parseTimestamp(rawTimestamp)
+	if err != nil {
+		return domain.Measurement{}, err
+	}
+	level, err := parseLevel(rawLevel)
+	if err != nil {
+		return domain.Measurement{}, err
+	}
+	return domain.Measurement{Timestamp: timestamp, Level: level}, nil
+}
+
+// parseTimestamp converts a text timestamp the way migration 003's ::timestamp cast does,
+// keeping the wall clock time and dropping any UTC offset
+func parseTimestamp(v interface{}) (time.Time, error) {
+	s, ok := text(v)
+	if !ok || s == "" {
+		return time.Time{}, fmt.Errorf("timestamp is empty")
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("timestamp %q is not a datetime", s)
+}
+
+// parseLevel converts a real or text level to a float64, as migration 005 widened the columns to double precision
+func parseLevel(v interface{}) (float64, error) {
+	var level float64
+	switch v := v.(type) {
+	case float64:
+		level = v
+	case int64:
+		level = float64(v)
+	default:
+		s, ok := text(v)
+		if !ok || s == "" {
+			return 0, fmt.Errorf("level is empty")
+		}
+		parsed, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("level %q is not a number", s)
+		}
+		level = parsed
+	}
+
+	if math.IsNaN(level) || math.IsInf(level, 0) {
+		return 0, fmt.Errorf("level %v is not a finite number", level)
+	}
+	return level, nil
+}
+
+// parseText converts a required text column such as a station id
+func parseText(name string, v interface{}) (string, error) {
+	s, ok := text(v)
+	if !ok || s == "" {
+		return "", fmt.Errorf("%s is empty", name)
+	}
+	return s, nil
+}
+
+// text returns a column value as trimmed text, or false for NULL and non-text values
+func text(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v), true
+	case []byte:
+		return strings.TrimSpace(string(v)), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 1, 1, 9, 15, 0, 0, time.UTC)
+
+	valid := []struct {
+		name  string
+		value interface{}
+	}{
+		{"challenge format", "2024-01-01T09:15:00"},
+		{"space separated", "2024-01-01 09:15:00"},
+		{"utc designator", "2024-01-01T09:15:00Z"},
+		{"offset is dropped like a ::timestamp cast", "2024-01-01T09:15:00+01:00"},
+		{"postgres dump offset", "2024-01-01 09:15:00+00"},
+		{"bytes", []byte("2024-01-01T09:15:00")},
+		{"surrounding whitespace", " 2024-01-01T09:15:00 "},
+	}
+	for _, tc := range valid {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseTimestamp(tc.value)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	invalid := []struct {
+		name  string
+		value interface{}
+	}{
+		{"null", nil},
+		{"empty", ""},
+		{"date only", "2024-01-01"},
+		{"not a date", "yesterday"},
+		{"number", int64(1704100500)},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseTimestamp(tc.value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	valid := []struct {
+		name  string
+		value interface{}
+		want  float64
+	}{
+		{"real", 0.123, 0.123},
+		{"integer", int64(2), 2},
+		{"text", "1.25", 1.25},
+		{"negative is kept as migrations never filtered it", "-0.05", -0.05},
+	}
+	for _, tc := range valid {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseLevel(tc.value)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	for _, value := range []interface{}{nil, "", "high", "NaN", "Inf"} {
+		_, err := parseLevel(value)
+		assert.Error(t, err, "%#v", value)
+	}
+}
@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+)
+
+// Report counts the outcome of an import per table
+type Report struct {
+	Stations    domain.ImportResult
+	Rainfall    domain.ImportResult
+	RiverLevels domain.ImportResult
+}
+
+// Importer converts rows from a Source and bulk loads them into the database.
+// Rows that can't be converted are counted as rejected and logged rather than failing the import.
+type Importer struct {
+	repo   repository.ImportRepository
+	logger *slog.Logger
+}
+
+func New(repo repository.ImportRepository, logger *slog.Logger) *Importer {
+	return &Importer{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Import loads stations first so rainfall can be checked against them, then rainfall,
+// then river levels, which the challenge data only has for one gauge
+func (i *Importer) Import(ctx context.Context, src Source, gaugeName string) (Report, error) {
+	var report Report
+	var err error
+
+	report.Stations, err = i.importStations(ctx, src)
+	if err != nil {
+		return report, fmt.Errorf("stationnames: %w", err)
+	}
+
+	report.Rainfall, err = i.importRainfall(ctx, src)
+	if err != nil {
+		return report, fmt.Errorf("rainfalls: %w", err)
+	}
+
+	report.RiverLevels, err = i.importRiverLevels(ctx, src, gaugeName)
+	if err != nil {
+		return report, fmt.Errorf("riverlevels: %w", err)
+	}
+
+	return report, nil
+}
+
+func (i *Importer) importStations(ctx context.Context, src Source) (domain.ImportResult, error) {
+	var rejected int64
+	result, err := i.repo.ImportStations(ctx, func(add func(domain.Station) error) error {
+		return src.Stations(ctx, func(rawID, rawName interface{}) error {
+			station, err := parseStation(rawID, rawName)
+			if err != nil {
+				rejected++
+				i.logger.Debug("Rejected station", "table", "stationnames", "error", err)
+				return nil
+			}
+			return add(station)
+		})
+	})
+	result.Rejected += rejected
+	return result, err
+}
+
+func (i *Importer) importRainfall(ctx context.Context, src Source) (domain.ImportResult, error) {
+	var rejected int64
+	result, err := i.repo.ImportRainfall(ctx, func(add func(string, domain.Measurement) error) error {
+		return src.Rainfall(ctx, func(rawStationID, rawTimestamp, rawLevel interface{}) error {
+			stationID, err := parseText("stationid", rawStationID)
+			if err != nil {
+				rejected++
+				i.logger.Debug("Rejected reading", "table", "rainfalls", "error", err)
+				return nil
+			}
+			m, err := parseMeasurement(rawTimestamp, rawLevel)
+			if err != nil {
+				rejected++
+				i.logger.Debug("Rejected reading", "table", "rainfalls", "station", stationID, "error", err)
+				return nil
+			}
+			return add(stationID, m)
+		})
+	})
+	result.Rejected += rejected
+	return result, err
+}
+
+func (i *Importer) importRiverLevels(ctx context.Context, src Source, gaugeName string) (domain.ImportResult, error) {
+	var rejected int64
+	result, err := i.repo.ImportRiverLevels(ctx, gaugeName, func(add func(domain.Measurement) error) error {
+		return src.RiverLevels(ctx, func(rawTimestamp, rawLevel interface{}) error {
+			m, err := parseMeasurement(rawTimestamp, rawLevel)
+			if err != nil {
+				rejected++
+				i.logger.Debug("Rejected reading", "table", "riverlevels", "error", err)
+				return nil
+			}
+			return add(m)
+		})
+	})
+	result.Rejected += rejected
+	return result, err
+}
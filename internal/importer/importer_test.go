@@ -0,0 +1,200 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rainfallRow struct {
+	StationID string
+	domain.Measurement
+}
+
+// fakeImportRepo records what it is given and, like the database, skips repeated keys
+// and rejects rainfall for stations it hasn't been given
+type fakeImportRepo struct {
+	stations map[string]string
+	rainfall []rainfallRow
+	river    []domain.Measurement
+}
+
+func newFakeImportRepo() *fakeImportRepo {
+	return &fakeImportRepo{stations: map[string]string{}}
+}
+
+func (f *fakeImportRepo) ImportStations(ctx context.Context, load func(add func(domain.Station) error) error) (domain.ImportResult, error) {
+	var result domain.ImportResult
+	err := load(func(s domain.Station) error {
+		if _, exists := f.stations[s.ID]; exists {
+			result.Skipped++
+			return nil
+		}
+		f.stations[s.ID] = s.Name
+		result.Imported++
+		return nil
+	})
+	return result, err
+}
+
+func (f *fakeImportRepo) ImportRainfall(ctx context.Context, load func(add func(string, domain.Measurement) error) error) (domain.ImportResult, error) {
+	var result domain.ImportResult
+	err := load(func(stationID string, m domain.Measurement) error {
+		if _, exists := f.stations[stationID]; !exists {
+			result.Rejected++
+			return nil
+		}
+		for _, row := range f.rainfall {
+			if row.StationID == stationID && row.Timestamp.Equal(m.Timestamp) {
+				result.Skipped++
+				return nil
+			}
+		}
+		f.rainfall = append(f.rainfall, rainfallRow{StationID: stationID, Measurement: m})
+		result.Imported++
+		return nil
+	})
+	return result, err
+}
+
+func (f *fakeImportRepo) ImportRiverLevels(ctx context.Context, gaugeName string, load func(add func(domain.Measurement) error) error) (domain.ImportResult, error) {
+	if gaugeName != "rede-bridge" {
+		return domain.ImportResult{}, domain.ErrNotFound
+	}
+
+	var result domain.ImportResult
+	err := load(func(m domain.Measurement) error {
+		for _, existing := range f.river {
+			if existing.Timestamp.Equal(m.Timestamp) {
+				result.Skipped++
+				return nil
+			}
+		}
+		f.river = append(f.river, m)
+		result.Imported++
+		return nil
+	})
+	return result, err
+}
+
+// createChallengeDB writes a SQLite database with the original challenge schema, including the rows that need rejecting
+func createChallengeDB(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "flood.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, stmt := range []string{
+		`CREATE TABLE stationnames (id text, name text)`,
+		`CREATE TABLE rainfalls (stationid text, timestamp text, level real)`,
+		`CREATE TABLE riverlevels (timestamp text, level real)`,
+		`INSERT INTO stationnames VALUES ('010660', 'catcleugh'), ('014555', 'haltwhistle'), (NULL, 'nameless')`,
+		`INSERT INTO rainfalls VALUES
+			('010660', '2024-01-01T09:00:00', 0.2),
+			('010660', '2024-01-01T09:15:00', 0.4),
+			('010660', '2024-01-01T09:15:00', 0.4),
+			('014555', '2024-01-01T09:00:00', 0),
+			('014555', '', 0.2),
+			('014555', '2024-01-01T09:30:00', NULL),
+			('999999', '2024-01-01T09:00:00', 1.0)`,
+		`INSERT INTO riverlevels VALUES
+			('2024-01-01T09:00:00', 1.2),
+			('2024-01-01T09:15:00', 1.25),
+			('not a time', 1.3)`,
+	} {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	return path
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func newTestImporter(repo *fakeImportRepo) *Importer {
+	return New(repo, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+}
+
+func TestImporter_SQLite(t *testing.T) {
+	src, err := OpenSQLite(createChallengeDB(t))
+	require.NoError(t, err)
+	defer src.Close()
+
+	repo := newFakeImportRepo()
+	report, err := newTestImporter(repo).Import(context.Background(), src, "rede-bridge")
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.ImportResult{Imported: 2, Rejected: 1}, report.Stations)
+	// the repeated row is skipped, the empty timestamp, null level and unknown station are rejected
+	assert.Equal(t, domain.ImportResult{Imported: 3, Skipped: 1, Rejected: 3}, report.Rainfall)
+	assert.Equal(t, domain.ImportResult{Imported: 2, Rejected: 1}, report.RiverLevels)
+
+	assert.Equal(t, map[string]string{"010660": "catcleugh", "014555": "haltwhistle"}, repo.stations)
+	assert.Equal(t, rainfallRow{
+		StationID:   "010660",
+		Measurement: domain.Measurement{Timestamp: time.Date(2024, 1, 1, 9, 15, 0, 0, time.UTC), Level: 0.4},
+	}, repo.rainfall[1])
+	assert.Equal(t, []domain.Measurement{
+		{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 1.2},
+		{Timestamp: time.Date(2024, 1, 1, 9, 15, 0, 0, time.UTC), Level: 1.25},
+	}, repo.river)
+
+	t.Run("importing again skips everything", func(t *testing.T) {
+		report, err := newTestImporter(repo).Import(context.Background(), src, "rede-bridge")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(0), report.Stations.Imported+report.Rainfall.Imported+report.RiverLevels.Imported)
+		assert.Equal(t, int64(4), report.Rainfall.Skipped)
+	})
+}
+
+func TestImporter_CSV(t *testing.T) {
+	dir := t.TempDir()
+	src := &CSVSource{
+		StationsPath: writeFile(t, dir, "stationnames.csv", "\ufeffname,id\ncatcleugh,010660\n"),
+		// columns in a different order, and a short record missing its level
+		RainfallPath: writeFile(t, dir, "rainfalls.csv", "timestamp,level,stationid\n2024-01-01 09:00:00,0.2,010660\n2024-01-01 09:15:00\n"),
+	}
+
+	repo := newFakeImportRepo()
+	report, err := newTestImporter(repo).Import(context.Background(), src, "rede-bridge")
+	require.NoError(t, err)
+
+	assert.Equal(t, domain.ImportResult{Imported: 1}, report.Stations)
+	assert.Equal(t, domain.ImportResult{Imported: 1, Rejected: 1}, report.Rainfall)
+	assert.Equal(t, domain.ImportResult{}, report.RiverLevels, "no river file was given")
+	assert.Equal(t, map[string]string{"010660": "catcleugh"}, repo.stations)
+
+	t.Run("missing column", func(t *testing.T) {
+		src := &CSVSource{StationsPath: writeFile(t, dir, "bad.csv", "id,station\n010660,catcleugh\n")}
+
+		_, err := newTestImporter(newFakeImportRepo()).Import(context.Background(), src, "rede-bridge")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `header has no "name" column`)
+	})
+}
+
+func TestImporter_UnknownGauge(t *testing.T) {
+	src, err := OpenSQLite(createChallengeDB(t))
+	require.NoError(t, err)
+	defer src.Close()
+
+	_, err = newTestImporter(newFakeImportRepo()).Import(context.Background(), src, "non-existent")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestOpenSQLite_MissingFile(t *testing.T) {
+	_, err := OpenSQLite(filepath.Join(t.TempDir(), "missing.db"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Source yields the raw rows of the original challenge tables, with nil for NULL
+type Source interface {
+	Stations(ctx context.Context, fn func(id, name interface{}) error) error
+	Rainfall(ctx context.Context, fn func(stationID, timestamp, level interface{}) error) error
+	RiverLevels(ctx context.Context, fn func(timestamp, level interface{}) error) error
+	Close() error
+}
+
+// SQLiteSource reads the challenge SQLite database, whose tables match migration 001
+type SQLiteSource struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database read-only so an import can never modify the original
+func OpenSQLite(path string) (*SQLiteSource, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", (&url.URL{Scheme: "file", Opaque: path, RawQuery: "mode=ro"}).String())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteSource{db: db}, nil
+}
+
+func (s *SQLiteSource) Stations(ctx context.Context, fn func(id, name interface{}) error) error {
+	return s.scan(ctx, "SELECT id, name FROM stationnames", 2, func(v []interface{}) error {
+		return fn(v[0], v[1])
+	})
+}
+
+func (s *SQLiteSource) Rainfall(ctx context.Context, fn func(stationID, timestamp, level interface{}) error) error {
+	return s.scan(ctx, "SELECT stationid, timestamp, level FROM rainfalls", 3, func(v []interface{}) error {
+		return fn(v[0], v[1], v[2])
+	})
+}
+
+func (s *SQLiteSource) RiverLevels(ctx context.Context, fn func(timestamp, level interface{}) error) error {
+	return s.scan(ctx, "SELECT timestamp, level FROM riverlevels", 2, func(v []interface{}) error {
+		return fn(v[0], v[1])
+	})
+}
+
+func (s *SQLiteSource) Close() error {
+	return s.db.Close()
+}
+
+// scan calls fn with the untyped column values of every row, leaving conversion to the importer
+func (s *SQLiteSource) scan(ctx context.Context, query string, columns int, fn func([]interface{}) error) error {
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	values := make([]interface{}, columns)
+	dest := make([]interface{}, columns)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// CSVSource reads one CSV file per table, each with a header row naming the table's columns.
+// An empty path skips that table.
+type CSVSource struct {
+	StationsPath    string
+	RainfallPath    string
+	RiverLevelsPath string
+}
+
+func (s *CSVSource) Stations(ctx context.Context, fn func(id, name interface{}) error) error {
+	return readCSV(ctx, s.StationsPath, []string{"id", "name"}, func(v []interface{}) error {
+		return fn(v[0], v[1])
+	})
+}
+
+func (s *CSVSource) Rainfall(ctx context.Context, fn func(stationID, timestamp, level interface{}) error) error {
+	return readCSV(ctx, s.RainfallPath, []string{"stationid", "timestamp", "level"}, func(v []interface{}) error {
+		return fn(v[0], v[1], v[2])
+	})
+}
+
+func (s *CSVSource) RiverLevels(ctx context.Context, fn func(timestamp, level interface{}) error) error {
+	return readCSV(ctx, s.RiverLevelsPath, []string{"timestamp", "level"}, func(v []interface{}) error {
+		return fn(v[0], v[1])
+	})
+}
+
+func (s *CSVSource) Close() error {
+	return nil
+}
+
+// readCSV calls fn with the named columns of every record, in the order of columns whatever order the file uses.
+// Empty fields and fields missing from a short record are passed as nil, like NULL from SQLite.
+func readCSV(ctx context.Context, path string, columns []string, fn func([]interface{}) error) error {
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%s: reading header: %w", path, err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+
+	positions := make([]int, len(columns))
+	for i, column := range columns {
+		pos, ok := index[column]
+		if !ok {
+			return fmt.Errorf("%s: header has no %q column", path, column)
+		}
+		positions[i] = pos
+	}
+
+	values := make([]interface{}, len(columns))
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		for i, pos := range positions {
+			values[i] = nil
+			if pos < len(record) && record[pos] != "" {
+				values[i] = record[pos]
+			}
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+}
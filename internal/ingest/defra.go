@@ -0,0 +1,100 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// Measures select which of a station's series to keep, matched against the measure id
+// e.g. 010660-rainfall-tipping_bucket_raingauge-t-15_min-mm or 22009-level-stage-i-15_min-m
+const (
+	RainfallMeasure   = "rainfall"
+	RiverLevelMeasure = "level-stage"
+)
+
+// readingsLimit caps a single response, well above the readings a station produces between polls
+const readingsLimit = 10000
+
+// Client fetches readings from a Defra flood monitoring style API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+type readingsResponse struct {
+	Items []readingItem `json:"items"`
+}
+
+type readingItem struct {
+	DateTime time.Time       `json:"dateTime"`
+	Measure  string          `json:"measure"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// GetReadings returns a station's readings of one measure since the given time, in chronological order
+func (c *Client) GetReadings(ctx context.Context, stationRef, measure string, since time.Time) ([]domain.Measurement, error) {
+	query := url.Values{}
+	query.Set("since", since.UTC().Format(time.RFC3339))
+	query.Set("_limit", fmt.Sprint(readingsLimit))
+	reqURL := c.baseURL + "/id/stations/" + url.PathEscape(stationRef) + "/readings?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", reqURL, resp.Status)
+	}
+
+	var body readingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode readings for station %s: %w", stationRef, err)
+	}
+
+	readings := make([]domain.Measurement, 0, len(body.Items))
+	for _, item := range body.Items {
+		if !matchesMeasure(item.Measure, measure) {
+			continue
+		}
+		// Defra occasionally publishes a list of values or nothing for a suspect reading, skip those
+		var level *float64
+		if err := json.Unmarshal(item.Value, &level); err != nil || level == nil {
+			continue
+		}
+		readings = append(readings, domain.Measurement{Timestamp: item.DateTime.UTC(), Level: *level})
+	}
+
+	sort.Slice(readings, func(i, j int) bool {
+		return readings[i].Timestamp.Before(readings[j].Timestamp)
+	})
+	return readings, nil
+}
+
+// matchesMeasure checks the parameter part of a measure id or URL
+func matchesMeasure(measureID, measure string) bool {
+	return strings.Contains(path.Base(measureID), "-"+measure+"-")
+}
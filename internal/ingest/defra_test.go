@@ -0,0 +1,56 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetReadings(t *testing.T) {
+	server := newDefraStandIn(t, map[string]string{
+		"010660": "rainfall_010660.json",
+		"22009":  "river_22009.json",
+	})
+	client := NewClient(server.URL+"/", server.Client())
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("keeps only the requested measure in chronological order", func(t *testing.T) {
+		readings, err := client.GetReadings(context.Background(), "22009", RiverLevelMeasure, since)
+		require.NoError(t, err)
+
+		assert.Equal(t, []domain.Measurement{
+			{Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Level: 0.518},
+			{Timestamp: time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC), Level: 0.523},
+		}, readings)
+	})
+
+	t.Run("skips readings without a single numeric value", func(t *testing.T) {
+		readings, err := client.GetReadings(context.Background(), "010660", RainfallMeasure, since)
+		require.NoError(t, err)
+
+		require.Len(t, readings, 3)
+		for _, r := range readings {
+			assert.NotEqual(t, time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC), r.Timestamp)
+		}
+	})
+
+	t.Run("sends since and a limit", func(t *testing.T) {
+		_, err := client.GetReadings(context.Background(), "010660", RainfallMeasure, since.In(time.FixedZone("BST", 3600)))
+		require.NoError(t, err)
+
+		last := server.requests[len(server.requests)-1]
+		assert.Equal(t, "/id/stations/010660/readings", last.URL.Path)
+		assert.Equal(t, "2024-01-01T00:00:00Z", last.URL.Query().Get("since"))
+		assert.Equal(t, "10000", last.URL.Query().Get("_limit"))
+	})
+
+	t.Run("returns an error for a non-200 response", func(t *testing.T) {
+		_, err := client.GetReadings(context.Background(), "999999", RainfallMeasure, since)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404 Not Found")
+	})
+}
@@ -0,0 +1,131 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/config"
+	"github.com/oliverslade/flood-api/internal/repository"
+)
+
+// DefaultLookback is how far back a station or gauge with no stored readings starts from
+const DefaultLookback = 24 * time.Hour
+
+// Ingester copies new readings from Defra into the database
+// Rainfall is polled for every known station, whose ids are Defra station references,
+// river levels only for the gauges it is given
+type Ingester struct {
+	client   *Client
+	repo     repository.IngestRepository
+	gauges   []config.Gauge
+	lookback time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+func New(client *Client, repo repository.IngestRepository, gauges []config.Gauge, lookback time.Duration, logger *slog.Logger) *Ingester {
+	return &Ingester{
+		client:   client,
+		repo:     repo,
+		gauges:   gauges,
+		lookback: lookback,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Run polls immediately and then on every interval until ctx is cancelled
+func (i *Ingester) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := i.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			i.logger.Error("Ingest failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce polls every station and gauge once and returns how many new readings were stored
+// A failing station or gauge doesn't stop the others, their errors are joined
+// The rainfall rollups are refreshed whenever new rainfall readings were stored
+func (i *Ingester) RunOnce(ctx context.Context) (int64, error) {
+	stationIDs, err := i.repo.ListStationIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list stations: %w", err)
+	}
+
+	var total int64
+	var errs []error
+
+	for _, stationID := range stationIDs {
+		n, err := i.ingestRainfall(ctx, stationID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rainfall station %s: %w", stationID, err))
+			continue
+		}
+		total += n
+	}
+	if total > 0 {
+		if err := i.repo.RefreshRollups(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("refresh rollups: %w", err))
+		}
+	}
+
+	for _, gauge := range i.gauges {
+		n, err := i.ingestRiver(ctx, gauge)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("river gauge %s: %w", gauge.Name, err))
+			continue
+		}
+		total += n
+	}
+
+	i.logger.Info("Ingest complete", "inserted", total, "failed", len(errs))
+	return total, errors.Join(errs...)
+}
+
+func (i *Ingester) ingestRainfall(ctx context.Context, stationID string) (int64, error) {
+	latest, err := i.repo.GetLatestRainfallTimestamp(ctx, stationID)
+	if err != nil {
+		return 0, err
+	}
+
+	readings, err := i.client.GetReadings(ctx, stationID, RainfallMeasure, i.since(latest))
+	if err != nil {
+		return 0, err
+	}
+
+	return i.repo.InsertRainfallReadings(ctx, stationID, readings)
+}
+
+func (i *Ingester) ingestRiver(ctx context.Context, gauge config.Gauge) (int64, error) {
+	latest, err := i.repo.GetLatestRiverTimestamp(ctx, gauge.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	readings, err := i.client.GetReadings(ctx, gauge.Reference, RiverLevelMeasure, i.since(latest))
+	if err != nil {
+		return 0, err
+	}
+
+	return i.repo.InsertRiverReadings(ctx, gauge.Name, readings)
+}
+
+// since resumes from the latest stored reading, which the upsert skips, or the lookback when there is none
+func (i *Ingester) since(latest time.Time) time.Time {
+	if latest.IsZero() {
+		return i.now().Add(-i.lookback)
+	}
+	return latest
+}
@@ -0,0 +1,265 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/config"
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defraStandIn serves the recorded payloads in testdata as /id/stations/{ref}/readings,
+// honouring since the way Defra does by returning readings at or after it
+type defraStandIn struct {
+	*httptest.Server
+	payloads map[string]string
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func newDefraStandIn(t *testing.T, payloads map[string]string) *defraStandIn {
+	s := &defraStandIn{payloads: payloads}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *defraStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	s.mu.Unlock()
+
+	ref, ok := strings.CutPrefix(r.URL.Path, "/id/stations/")
+	ref, ok2 := strings.CutSuffix(ref, "/readings")
+	file, found := s.payloads[ref]
+	if !ok || !ok2 || !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	raw, err := os.ReadFile("testdata/" + file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(raw, &body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if since, err := time.Parse(time.RFC3339, r.URL.Query().Get("since")); err == nil {
+		var kept []any
+		for _, item := range body["items"].([]any) {
+			dateTime, _ := time.Parse(time.RFC3339, item.(map[string]any)["dateTime"].(string))
+			if !dateTime.Before(since) {
+				kept = append(kept, item)
+			}
+		}
+		body["items"] = kept
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// sinceFor returns the since parameter of every request made for a station reference
+func (s *defraStandIn) sinceFor(ref string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var since []string
+	for _, r := range s.requests {
+		if strings.Contains(r.URL.Path, "/"+ref+"/") {
+			since = append(since, r.URL.Query().Get("since"))
+		}
+	}
+	return since
+}
+
+// fakeIngestRepo keeps readings keyed by timestamp so repeated inserts behave like the idempotent SQL
+type fakeIngestRepo struct {
+	stationIDs []string
+	gauges     map[string]bool
+	rainfall   map[string]map[time.Time]float64
+	river      map[string]map[time.Time]float64
+	refreshes  int
+}
+
+func newFakeIngestRepo(stationIDs []string, gauges ...string) *fakeIngestRepo {
+	repo := &fakeIngestRepo{
+		stationIDs: stationIDs,
+		gauges:     map[string]bool{},
+		rainfall:   map[string]map[time.Time]float64{},
+		river:      map[string]map[time.Time]float64{},
+	}
+	for _, gauge := range gauges {
+		repo.gauges[gauge] = true
+	}
+	return repo
+}
+
+func (f *fakeIngestRepo) ListStationIDs(ctx context.Context) ([]string, error) {
+	return f.stationIDs, nil
+}
+
+func (f *fakeIngestRepo) GetLatestRainfallTimestamp(ctx context.Context, stationID string) (time.Time, error) {
+	return latest(f.rainfall[stationID]), nil
+}
+
+func (f *fakeIngestRepo) GetLatestRiverTimestamp(ctx context.Context, gaugeName string) (time.Time, error) {
+	if !f.gauges[gaugeName] {
+		return time.Time{}, domain.ErrNotFound
+	}
+	return latest(f.river[gaugeName]), nil
+}
+
+func (f *fakeIngestRepo) InsertRainfallReadings(ctx context.Context, stationID string, readings []domain.Measurement) (int64, error) {
+	return insert(f.rainfall, stationID, readings), nil
+}
+
+func (f *fakeIngestRepo) InsertRiverReadings(ctx context.Context, gaugeName string, readings []domain.Measurement) (int64, error) {
+	if !f.gauges[gaugeName] {
+		return 0, domain.ErrNotFound
+	}
+	return insert(f.river, gaugeName, readings), nil
+}
+
+func (f *fakeIngestRepo) RefreshRollups(ctx context.Context) error {
+	f.refreshes++
+	return nil
+}
+
+func latest(series map[time.Time]float64) time.Time {
+	var max time.Time
+	for ts := range series {
+		if ts.After(max) {
+			max = ts
+		}
+	}
+	return max
+}
+
+func insert(store map[string]map[time.Time]float64, key string, readings []domain.Measurement) int64 {
+	if store[key] == nil {
+		store[key] = map[time.Time]float64{}
+	}
+	var inserted int64
+	for _, m := range readings {
+		if _, exists := store[key][m.Timestamp]; !exists {
+			store[key][m.Timestamp] = m.Level
+			inserted++
+		}
+	}
+	return inserted
+}
+
+func newTestIngester(server *defraStandIn, repo *fakeIngestRepo, gauges []config.Gauge) *Ingester {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ingester := New(NewClient(server.URL, server.Client()), repo, gauges, 48*time.Hour, logger)
+	ingester.now = func() time.Time { return time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC) }
+	return ingester
+}
+
+func TestIngester_RunOnce(t *testing.T) {
+	server := newDefraStandIn(t, map[string]string{
+		"010660": "rainfall_010660.json",
+		"22009":  "river_22009.json",
+	})
+	repo := newFakeIngestRepo([]string{"010660"}, "rede-bridge")
+	ingester := newTestIngester(server, repo, []config.Gauge{{Name: "rede-bridge", Reference: "22009"}})
+
+	t.Run("first poll stores every reading from the lookback", func(t *testing.T) {
+		inserted, err := ingester.RunOnce(context.Background())
+		require.NoError(t, err)
+
+		// 3 numeric rainfall readings, the list valued one is skipped, and 2 river levels without the flow series
+		assert.Equal(t, int64(5), inserted)
+		assert.Equal(t, map[time.Time]float64{
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC):  0.0,
+			time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC): 0.2,
+			time.Date(2024, 1, 1, 0, 45, 0, 0, time.UTC): 0.4,
+		}, repo.rainfall["010660"])
+		assert.Equal(t, map[time.Time]float64{
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC):  0.518,
+			time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC): 0.523,
+		}, repo.river["rede-bridge"])
+
+		assert.Equal(t, []string{"2023-12-31T00:00:00Z"}, server.sinceFor("010660"))
+		assert.Equal(t, []string{"2023-12-31T00:00:00Z"}, server.sinceFor("22009"))
+		assert.Equal(t, 1, repo.refreshes, "new rainfall refreshes the rollups")
+	})
+
+	t.Run("polling again resumes from the latest reading and stores nothing twice", func(t *testing.T) {
+		inserted, err := ingester.RunOnce(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(0), inserted)
+		assert.Len(t, repo.rainfall["010660"], 3)
+		assert.Len(t, repo.river["rede-bridge"], 2)
+
+		assert.Equal(t, "2024-01-01T00:45:00Z", server.sinceFor("010660")[1])
+		assert.Equal(t, "2024-01-01T00:15:00Z", server.sinceFor("22009")[1])
+		assert.Equal(t, 1, repo.refreshes, "the rollups are only refreshed after new rainfall")
+	})
+}
+
+func TestIngester_RunOnce_ContinuesPastFailures(t *testing.T) {
+	server := newDefraStandIn(t, map[string]string{
+		"010660": "rainfall_010660.json",
+		"22009":  "river_22009.json",
+	})
+	repo := newFakeIngestRepo([]string{"999999", "010660"}, "rede-bridge")
+	ingester := newTestIngester(server, repo, []config.Gauge{
+		{Name: "unknown-gauge", Reference: "22009"},
+		{Name: "rede-bridge", Reference: "22009"},
+	})
+
+	inserted, err := ingester.RunOnce(context.Background())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rainfall station 999999")
+	assert.Contains(t, err.Error(), "404 Not Found")
+	assert.Contains(t, err.Error(), "river gauge unknown-gauge")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	assert.Equal(t, int64(5), inserted)
+	assert.Len(t, repo.rainfall["010660"], 3)
+	assert.Len(t, repo.river["rede-bridge"], 2)
+}
+
+func TestIngester_Run(t *testing.T) {
+	server := newDefraStandIn(t, map[string]string{"010660": "rainfall_010660.json"})
+	repo := newFakeIngestRepo([]string{"010660"})
+	ingester := newTestIngester(server, repo, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ingester.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(server.sinceFor("010660")) >= 2
+	}, time.Second, 5*time.Millisecond, "should poll again on the interval")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run should return once the context is cancelled")
+	}
+}
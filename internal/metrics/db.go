@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// QueryBuckets are histogram upper bounds in seconds for database queries, from half a millisecond to 5s
+var QueryBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+var queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "flood_db_query_duration_seconds",
+	Help:    "Time taken by repository queries until their first row, by sqlc query name",
+	Buckets: QueryBuckets,
+}, []string{"query"})
+
+// ObserveQuery records how long the named query took
+func ObserveQuery(name string, took time.Duration) {
+	queryDuration.WithLabelValues(name).Observe(took.Seconds())
+}
+
+// RegisterDBStats exposes the connection pool statistics of db as the go_sql_ metrics, labelled
+// with name. It fails if a pool is already registered under name.
+func RegisterDBStats(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "flood_http_requests_total",
+		Help: "HTTP requests served, by method, chi route pattern and status code",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "flood_http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, by method, chi route pattern and status code",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// unmatchedRoute labels requests no route matched, so probes for random paths share one series
+const unmatchedRoute = "unmatched"
+
+// Middleware records the count and latency of every request by the chi route pattern that served it,
+// such as /rainfall/{station}, rather than the path. It must wrap the router so the pattern is known
+// once the request has been served.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		// deferred so responses a handler aborts with http.ErrAbortHandler are still counted
+		defer func() {
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			status := ww.Status()
+			if status == 0 {
+				// nothing was written, which net/http sends as a 200
+				status = http.StatusOK
+			}
+
+			labels := []string{r.Method, route, strconv.Itoa(status)}
+			httpRequests.WithLabelValues(labels...).Inc()
+			httpDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
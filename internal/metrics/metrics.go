@@ -0,0 +1,14 @@
+// Package metrics holds the API's Prometheus metrics. They are registered with the default
+// registry, which also carries the Go runtime and process collectors, and served by Handler.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler serves every metric in the default registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
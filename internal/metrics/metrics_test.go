@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestMiddleware(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(Middleware)
+	router.Get("/rainfall/{station}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	router.Get("/broken", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	})
+
+	for _, path := range []string{"/rainfall/catcleugh", "/rainfall/kielder", "/broken", "/wp-login.php"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t)
+	assert.Contains(t, body, `flood_http_requests_total{method="GET",route="/rainfall/{station}",status="200"} 2`)
+	assert.Contains(t, body, `flood_http_requests_total{method="GET",route="/broken",status="500"} 1`)
+	assert.Contains(t, body, `flood_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `flood_http_request_duration_seconds_count{method="GET",route="/rainfall/{station}",status="200"} 2`)
+	assert.NotContains(t, body, "catcleugh")
+}
+
+func TestRegisterDBStats(t *testing.T) {
+	// the pool never connects, so its settings can be read without a database
+	db := sql.OpenDB(noDatabase{})
+	defer db.Close()
+	db.SetMaxOpenConns(25)
+
+	require.NoError(t, RegisterDBStats(db, "postgres"))
+	assert.Error(t, RegisterDBStats(db, "postgres"), "a pool can only be registered once under a name")
+	ObserveQuery("GetStationByName", 0)
+
+	body := scrape(t)
+	for _, line := range []string{
+		`go_sql_max_open_connections{db_name="postgres"} 25`,
+		`go_sql_open_connections{db_name="postgres"} 0`,
+		`go_sql_wait_count_total{db_name="postgres"} 0`,
+		`flood_db_query_duration_seconds_count{query="GetStationByName"} 1`,
+	} {
+		assert.Contains(t, body, line)
+	}
+}
+
+func TestHandlerServesRuntimeMetrics(t *testing.T) {
+	body := scrape(t)
+	assert.Contains(t, body, "go_goroutines ")
+	assert.Contains(t, body, "process_start_time_seconds ")
+}
+
+// noDatabase is a connector for a pool that is never used
+type noDatabase struct{}
+
+func (noDatabase) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("no database")
+}
+
+func (noDatabase) Driver() driver.Driver { return nil }
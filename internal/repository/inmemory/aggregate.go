@@ -0,0 +1,70 @@
+package inmemory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// aggregate mirrors the Postgres date_trunc grouping, readings must already be date filtered
+func aggregate(readings series, interval, fn string) []domain.AggregateBucket {
+	buckets := []domain.AggregateBucket{}
+	var sums []float64
+	index := map[time.Time]int{}
+
+	for _, m := range readings {
+		start := truncate(m.Timestamp, interval)
+		i, exists := index[start]
+		if !exists {
+			i = len(buckets)
+			index[start] = i
+			buckets = append(buckets, domain.AggregateBucket{Start: start, Value: m.Level})
+			sums = append(sums, 0)
+		}
+
+		b := &buckets[i]
+		b.Count++
+		sums[i] += m.Level
+		switch fn {
+		case domain.AggregateMax:
+			if m.Level > b.Value {
+				b.Value = m.Level
+			}
+		case domain.AggregateMin:
+			if m.Level < b.Value {
+				b.Value = m.Level
+			}
+		}
+	}
+
+	for i := range buckets {
+		switch fn {
+		case domain.AggregateSum:
+			buckets[i].Value = sums[i]
+		case domain.AggregateMean:
+			buckets[i].Value = sums[i] / float64(buckets[i].Count)
+		}
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Start.Before(buckets[j].Start)
+	})
+	return buckets
+}
+
+// truncate matches date_trunc, weeks start on Monday
+func truncate(t time.Time, interval string) time.Time {
+	y, m, d := t.Date()
+	switch interval {
+	case domain.IntervalHour:
+		return t.Truncate(time.Hour)
+	case domain.IntervalWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case domain.IntervalMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
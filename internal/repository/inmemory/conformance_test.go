@@ -0,0 +1,26 @@
+package inmemory_test
+
+import (
+	"testing"
+
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+	"github.com/oliverslade/flood-api/internal/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	newRepos := func(t *testing.T, data inmemory.Dataset) repositorytest.Repositories {
+		store := inmemory.NewStore(data)
+		return repositorytest.Repositories{
+			River:    inmemory.NewRiverRepoFrom(store),
+			Rainfall: inmemory.NewRainfallRepoFrom(store),
+			Station:  inmemory.NewStationRepoFrom(store),
+		}
+	}
+
+	t.Run("seed", func(t *testing.T) {
+		repositorytest.Run(t, newRepos, repositorytest.Seed())
+	})
+	t.Run("fixtures", func(t *testing.T) {
+		repositorytest.Run(t, newRepos, inmemory.Fixtures())
+	})
+}
@@ -0,0 +1,70 @@
+package inmemory
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// Dataset is everything the in-memory repositories start with
+type Dataset struct {
+	Stations []domain.Station
+	Gauges   []domain.Gauge
+	Rainfall []domain.RainfallReading
+	River    []domain.RiverReading
+}
+
+// Fixtures returns the data the in-memory repositories start with, so other backends can be
+// seeded to match and run the same tests
+func Fixtures() Dataset {
+	data := Dataset{
+		Rainfall: rainfallFixtures(),
+		River:    riverFixtures(),
+	}
+	for _, station := range stationFixtures() {
+		data.Stations = append(data.Stations, station)
+	}
+	for _, gauge := range gaugeFixtures() {
+		data.Gauges = append(data.Gauges, gauge)
+	}
+
+	sort.Slice(data.Stations, func(i, j int) bool {
+		return data.Stations[i].Name < data.Stations[j].Name
+	})
+	sort.Slice(data.Gauges, func(i, j int) bool {
+		return data.Gauges[i].Name < data.Gauges[j].Name
+	})
+	return data
+}
+
+// Series generates count readings interval apart from start, taking each level from level
+func Series(start time.Time, interval time.Duration, count int, level func(ts time.Time) float64) []domain.Measurement {
+	readings := make([]domain.Measurement, count)
+	for i := range readings {
+		ts := start.Add(time.Duration(i) * interval).UTC()
+		readings[i] = domain.Measurement{Timestamp: ts, Level: level(ts)}
+	}
+	return readings
+}
+
+// AddRainfall appends readings for a station, adding the station if the dataset doesn't have it yet
+func (d *Dataset) AddRainfall(station domain.Station, readings []domain.Measurement) {
+	if !slices.Contains(d.Stations, station) {
+		d.Stations = append(d.Stations, station)
+	}
+	for _, m := range readings {
+		d.Rainfall = append(d.Rainfall, domain.RainfallReading{Timestamp: m.Timestamp, Level: m.Level, StationName: station.Name})
+	}
+}
+
+// AddRiver appends readings for a gauge, adding the gauge if the dataset doesn't have it yet
+func (d *Dataset) AddRiver(gauge domain.Gauge, readings []domain.Measurement) {
+	if !slices.Contains(d.Gauges, gauge) {
+		d.Gauges = append(d.Gauges, gauge)
+	}
+	for _, m := range readings {
+		d.River = append(d.River, domain.RiverReading{Timestamp: m.Timestamp, Level: m.Level, GaugeName: gauge.Name})
+	}
+}
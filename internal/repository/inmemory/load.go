@@ -0,0 +1,224 @@
+package inmemory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// Fixture files in a CSV directory. The reading files have the same columns as the CSV exports,
+// so an export can be used as a fixture as it is.
+const (
+	stationsFile = "stations.csv" // id,name
+	gaugesFile   = "gauges.csv"   // id,name,river
+	rainfallFile = "rainfall.csv" // timestamp,level,station
+	riverFile    = "river.csv"    // timestamp,level,gauge
+)
+
+// datasetJSON is the layout of a JSON fixture file, the readings being in the same form the API returns them
+type datasetJSON struct {
+	Stations []stationJSON            `json:"stations"`
+	Gauges   []gaugeJSON              `json:"gauges"`
+	Rainfall []domain.RainfallReading `json:"rainfall"`
+	River    []domain.RiverReading    `json:"river"`
+}
+
+type stationJSON struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type gaugeJSON struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	River string `json:"river"`
+}
+
+// LoadDataset reads a dataset from a JSON file, or from a directory of CSV files any of which may be
+// left out. Readings for a station or gauge the dataset doesn't have are an error.
+func LoadDataset(path string) (Dataset, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Dataset{}, err
+	}
+
+	var data Dataset
+	if info.IsDir() {
+		data, err = loadCSV(path)
+	} else {
+		data, err = loadJSON(path)
+	}
+	if err != nil {
+		return Dataset{}, err
+	}
+	if err := data.validate(); err != nil {
+		return Dataset{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+func loadJSON(path string) (Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Dataset{}, err
+	}
+	defer f.Close()
+
+	var file datasetJSON
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return Dataset{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	data := Dataset{Rainfall: file.Rainfall, River: file.River}
+	for _, station := range file.Stations {
+		data.Stations = append(data.Stations, domain.Station{ID: station.ID, Name: station.Name})
+	}
+	for _, gauge := range file.Gauges {
+		data.Gauges = append(data.Gauges, domain.Gauge{ID: gauge.ID, Name: gauge.Name, River: gauge.River})
+	}
+	return data, nil
+}
+
+func loadCSV(dir string) (Dataset, error) {
+	var data Dataset
+
+	err := readCSV(filepath.Join(dir, stationsFile), []string{"id", "name"}, func(v []string) error {
+		data.Stations = append(data.Stations, domain.Station{ID: v[0], Name: v[1]})
+		return nil
+	})
+	if err != nil {
+		return Dataset{}, err
+	}
+
+	err = readCSV(filepath.Join(dir, gaugesFile), []string{"id", "name", "river"}, func(v []string) error {
+		data.Gauges = append(data.Gauges, domain.Gauge{ID: v[0], Name: v[1], River: v[2]})
+		return nil
+	})
+	if err != nil {
+		return Dataset{}, err
+	}
+
+	err = readCSV(filepath.Join(dir, rainfallFile), domain.RainfallReadingCSVHeader, func(v []string) error {
+		m, err := parseMeasurement(v[0], v[1])
+		if err != nil {
+			return err
+		}
+		data.Rainfall = append(data.Rainfall, domain.RainfallReading{Timestamp: m.Timestamp, Level: m.Level, StationName: v[2]})
+		return nil
+	})
+	if err != nil {
+		return Dataset{}, err
+	}
+
+	err = readCSV(filepath.Join(dir, riverFile), domain.RiverReadingCSVHeader, func(v []string) error {
+		m, err := parseMeasurement(v[0], v[1])
+		if err != nil {
+			return err
+		}
+		data.River = append(data.River, domain.RiverReading{Timestamp: m.Timestamp, Level: m.Level, GaugeName: v[2]})
+		return nil
+	})
+	if err != nil {
+		return Dataset{}, err
+	}
+	return data, nil
+}
+
+// readCSV calls fn with the named columns of every record, in the order of columns whatever order
+// the file uses. A missing file is skipped.
+func readCSV(path string, columns []string, fn func([]string) error) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%s: reading header: %w", path, err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+
+	positions := make([]int, len(columns))
+	for i, column := range columns {
+		pos, ok := index[column]
+		if !ok {
+			return fmt.Errorf("%s: header has no %q column", path, column)
+		}
+		positions[i] = pos
+	}
+
+	values := make([]string, len(columns))
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		for i, pos := range positions {
+			values[i] = strings.TrimSpace(record[pos])
+		}
+		if err := fn(values); err != nil {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+}
+
+// parseMeasurement reads an RFC 3339 timestamp and a level, as the CSV exports write them
+func parseMeasurement(rawTimestamp, rawLevel string) (domain.Measurement, error) {
+	ts, err := time.Parse(time.RFC3339Nano, rawTimestamp)
+	if err != nil {
+		return domain.Measurement{}, fmt.Errorf("timestamp %q must be an RFC 3339 datetime", rawTimestamp)
+	}
+	level, err := strconv.ParseFloat(rawLevel, 64)
+	if err != nil {
+		return domain.Measurement{}, fmt.Errorf("level %q must be a number", rawLevel)
+	}
+	return domain.Measurement{Timestamp: ts.UTC(), Level: level}, nil
+}
+
+// validate checks every reading belongs to a station or gauge in the dataset
+func (d Dataset) validate() error {
+	stations := make(map[string]bool, len(d.Stations))
+	for _, station := range d.Stations {
+		stations[station.Name] = true
+	}
+	for _, reading := range d.Rainfall {
+		if !stations[reading.StationName] {
+			return fmt.Errorf("rainfall for unknown station %q", reading.StationName)
+		}
+	}
+
+	gauges := make(map[string]bool, len(d.Gauges))
+	for _, gauge := range d.Gauges {
+		gauges[gauge.Name] = true
+	}
+	for _, reading := range d.River {
+		if !gauges[reading.GaugeName] {
+			return fmt.Errorf("river level for unknown gauge %q", reading.GaugeName)
+		}
+	}
+	return nil
+}
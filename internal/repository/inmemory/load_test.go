@@ -0,0 +1,165 @@
+package inmemory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadDataset(t *testing.T) {
+	want := Dataset{
+		Stations: []domain.Station{{ID: "010660", Name: "catcleugh"}},
+		Gauges:   []domain.Gauge{{ID: "rede-bridge", Name: "rede-bridge", River: "River Rede"}},
+		Rainfall: []domain.RainfallReading{
+			{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 2.1, StationName: "catcleugh"},
+		},
+		River: []domain.RiverReading{
+			{Timestamp: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC), Level: 1.2, GaugeName: "rede-bridge"},
+		},
+	}
+
+	t.Run("reads a JSON file", func(t *testing.T) {
+		path := writeFile(t, t.TempDir(), "fixtures.json", `{
+			"stations": [{"id": "010660", "name": "catcleugh"}],
+			"gauges": [{"id": "rede-bridge", "name": "rede-bridge", "river": "River Rede"}],
+			"rainfall": [{"timestamp": "2024-01-01T09:00:00Z", "level": 2.1, "station": "catcleugh"}],
+			"river": [{"timestamp": "2024-01-01T09:00:00+01:00", "level": 1.2, "gauge": "rede-bridge"}]
+		}`)
+
+		data, err := LoadDataset(path)
+		require.NoError(t, err)
+		require.Len(t, data.River, 1)
+		assert.True(t, want.River[0].Timestamp.Equal(data.River[0].Timestamp))
+		data.River[0].Timestamp = data.River[0].Timestamp.UTC()
+		assert.Equal(t, want, data)
+	})
+
+	t.Run("reads a directory of CSV files in any column order", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "stations.csv", "\ufeffname,id\ncatcleugh,010660\n")
+		writeFile(t, dir, "gauges.csv", "id,name,river\nrede-bridge,rede-bridge,River Rede\n")
+		writeFile(t, dir, "rainfall.csv", "timestamp,level,station\n2024-01-01T09:00:00Z,2.1,catcleugh\n")
+		writeFile(t, dir, "river.csv", "gauge,timestamp,level\nrede-bridge,2024-01-01T08:00:00Z,1.2\n")
+
+		data, err := LoadDataset(dir)
+		require.NoError(t, err)
+		assert.Equal(t, want, data)
+	})
+
+	t.Run("skips missing CSV files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "stations.csv", "id,name\n010660,catcleugh\n")
+
+		data, err := LoadDataset(dir)
+		require.NoError(t, err)
+		assert.Equal(t, Dataset{Stations: want.Stations}, data)
+	})
+
+	t.Run("rejects invalid fixtures", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			file    string
+			content string
+			message string
+		}{
+			{"unknown station", "fixtures.json", `{"rainfall": [{"timestamp": "2024-01-01T09:00:00Z", "level": 1, "station": "nope"}]}`, `rainfall for unknown station "nope"`},
+			{"unknown field", "fixtures.json", `{"stations": [{"id": "1", "name": "a", "river": "x"}]}`, `unknown field "river"`},
+			{"bad timestamp", "river.csv", "timestamp,level,gauge\n2024-01-01 09:00,1.0,rede-bridge\n", `river.csv:2: timestamp "2024-01-01 09:00" must be an RFC 3339 datetime`},
+			{"bad level", "rainfall.csv", "timestamp,level,station\n2024-01-01T09:00:00Z,high,catcleugh\n", `rainfall.csv:2: level "high" must be a number`},
+			{"missing column", "gauges.csv", "id,name\nrede-bridge,rede-bridge\n", `header has no "river" column`},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				dir := t.TempDir()
+				path := writeFile(t, dir, tc.file, tc.content)
+				if filepath.Ext(tc.file) == ".csv" {
+					path = dir
+				}
+
+				_, err := LoadDataset(path)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.message)
+			})
+		}
+	})
+}
+
+func TestStoreConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	data := Dataset{}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data.AddRiver(domain.Gauge{ID: "rede-bridge", Name: "rede-bridge"}, Series(start, 15*time.Minute, 1000, func(ts time.Time) float64 {
+		return float64(ts.Hour()) / 10
+	}))
+
+	store := NewStore(data)
+	river := NewRiverRepoFrom(store)
+	params := domain.GetRiverParams{
+		GaugeName:         "rede-bridge",
+		GetReadingsParams: domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: 1, PageSize: 100}},
+	}
+
+	// writers extend the series while readers page through it, run with -race to check the locking
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				ts := start.Add(time.Duration(1000+w*50+i) * 15 * time.Minute)
+				assert.NoError(t, river.UpsertReadings(ctx, "rede-bridge", []domain.Measurement{{Timestamp: ts, Level: 1}}))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				readings, err := river.GetReadings(ctx, params)
+				assert.NoError(t, err)
+				assert.Len(t, readings, 100)
+			}
+		}()
+	}
+	wg.Wait()
+
+	count, err := river.CountReadings(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1200), count)
+}
+
+func TestWriteDataset(t *testing.T) {
+	data := Dataset{}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data.AddRainfall(domain.Station{ID: "010660", Name: "catcleugh"}, Series(start, 15*time.Minute, 3, func(ts time.Time) float64 {
+		return 0.2 * float64(ts.Minute()/15)
+	}))
+	data.AddRiver(domain.Gauge{ID: "rede-bridge", Name: "rede-bridge", River: "River Rede"}, Series(start, 15*time.Minute, 3, func(ts time.Time) float64 {
+		return float64(300+ts.Minute()) / 1000
+	}))
+
+	for _, name := range []string{"fixtures.json", "fixtures"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, WriteDataset(path, data))
+
+			loaded, err := LoadDataset(path)
+			require.NoError(t, err)
+			assert.Equal(t, data, loaded)
+		})
+	}
+}
@@ -2,30 +2,43 @@ package inmemory
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/oliverslade/flood-api/internal/domain"
 	"github.com/oliverslade/flood-api/internal/repository"
 )
 
-// This is an in-memory implementation fake for use with the service layer unit tests
+// RainfallRepo serves rainfall from a Store, as a fake for the handler tests or a backend
+// that needs no database
 type RainfallRepo struct {
-	readings []domain.RainfallReading
-	stations map[string]domain.Station
+	store *Store
 }
 
+// NewRainfallRepo returns a repository holding its own copy of the fixtures
 func NewRainfallRepo() repository.RainfallRepository {
-	// mirrors actual database structure
-	readings := []domain.RainfallReading{
+	return NewRainfallRepoFrom(NewStore(Fixtures()))
+}
+
+// NewRainfallRepoFrom returns a repository reading and writing the stations and rainfall of store
+func NewRainfallRepoFrom(store *Store) repository.RainfallRepository {
+	return &RainfallRepo{store: store}
+}
+
+// rainfallFixtures mirrors actual database structure
+func rainfallFixtures() []domain.RainfallReading {
+	return []domain.RainfallReading{
 		{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 2.1, StationName: "catcleugh"},
 		{Timestamp: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), Level: 2.2, StationName: "catcleugh"},
 		{Timestamp: time.Date(2024, 1, 3, 11, 0, 0, 0, time.UTC), Level: 2.3, StationName: "catcleugh"},
 		{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 1.5, StationName: "haltwhistle"},
 		{Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Level: 1.6, StationName: "haltwhistle"},
 	}
+}
 
-	// Mirror actual database station mapping (ID -> Name)
-	stations := map[string]domain.Station{
+// stationFixtures mirrors actual database station mapping (ID -> Name)
+func stationFixtures() map[string]domain.Station {
+	return map[string]domain.Station{
 		"catcleugh":                 {ID: "010660", Name: "catcleugh"},
 		"haltwhistle":               {ID: "014555", Name: "haltwhistle"},
 		"hexham-firtrees":           {ID: "016140", Name: "hexham-firtrees"},
@@ -38,30 +51,45 @@ func NewRainfallRepo() repository.RainfallRepository {
 		"acomb-codlaw-hill":         {ID: "015313", Name: "acomb-codlaw-hill"},
 		"allenheads-allen-lodge":    {ID: "015347", Name: "allenheads-allen-lodge"},
 	}
-
-	return &RainfallRepo{readings: readings, stations: stations}
 }
 
 func (r *RainfallRepo) GetReadingsByStation(ctx context.Context, params domain.GetRainfallParams) ([]domain.RainfallReading, error) {
-	if _, exists := r.stations[params.StationName]; !exists {
-		return nil, domain.ErrNotFound
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	readings, err := r.stationReadings(params.StationName)
+	if err != nil {
+		return nil, err
+	}
+	return rainfallReadings(readings.page(params.GetReadingsParams), params.StationName), nil
+}
+
+func (r *RainfallRepo) StreamReadingsPageByStation(ctx context.Context, params domain.GetRainfallParams, start func(domain.PageBounds) error, fn func(domain.RainfallReading) error) error {
+	page, err := r.GetReadingsByStation(ctx, params)
+	if err != nil {
+		return err
 	}
+	return streamPage(ctx, page, rainfallTimestamp, start, fn)
+}
 
-	var filtered []domain.RainfallReading
-	for _, reading := range r.readings {
-		if reading.StationName == params.StationName {
-			filtered = append(filtered, reading)
-		}
+func (r *RainfallRepo) CountReadingsByStation(ctx context.Context, params domain.GetRainfallParams) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	readings, err := r.stationReadings(params.StationName)
+	if err != nil {
+		return 0, err
 	}
+	return int64(len(readings.between(params.StartDate, params.EndDate))), nil
+}
 
-	if params.StartDate != nil {
-		var filteredByDate []domain.RainfallReading
-		for _, reading := range filtered {
-			if reading.Timestamp.After(*params.StartDate) || reading.Timestamp.Equal(*params.StartDate) {
-				filteredByDate = append(filteredByDate, reading)
-			}
-		}
-		filtered = filteredByDate
+func (r *RainfallRepo) GetReadingsByStations(ctx context.Context, params domain.GetRainfallStationsParams) ([]domain.RainfallReading, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	filtered, err := r.filterByStations(params)
+	if err != nil {
+		return nil, err
 	}
 
 	offset := (params.Pagination.Page - 1) * params.Pagination.PageSize
@@ -76,3 +104,157 @@ func (r *RainfallRepo) GetReadingsByStation(ctx context.Context, params domain.G
 
 	return filtered[offset:end], nil
 }
+
+func (r *RainfallRepo) StreamReadingsPageByStations(ctx context.Context, params domain.GetRainfallStationsParams, start func(domain.PageBounds) error, fn func(domain.RainfallReading) error) error {
+	page, err := r.GetReadingsByStations(ctx, params)
+	if err != nil {
+		return err
+	}
+	return streamPage(ctx, page, rainfallTimestamp, start, fn)
+}
+
+func (r *RainfallRepo) CountReadingsByStations(ctx context.Context, params domain.GetRainfallStationsParams) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	filtered, err := r.filterByStations(params)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(filtered)), nil
+}
+
+// filterByStations merges the readings of the requested stations in the same order as the database,
+// by timestamp then station ID
+func (r *RainfallRepo) filterByStations(params domain.GetRainfallStationsParams) ([]domain.RainfallReading, error) {
+	names := params.StationNames
+	if names == nil {
+		for name := range r.store.stations {
+			names = append(names, name)
+		}
+	}
+
+	var unknown []string
+	for _, name := range names {
+		if _, exists := r.store.stations[name]; !exists {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, &domain.UnknownStationsError{Names: unknown}
+	}
+
+	filtered := []domain.RainfallReading{}
+	merged := map[string]bool{}
+	for _, name := range names {
+		if merged[name] {
+			continue
+		}
+		merged[name] = true
+		filtered = append(filtered, rainfallReadings(r.store.rainfall[name].between(params.StartDate, params.EndDate), name)...)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if !filtered[i].Timestamp.Equal(filtered[j].Timestamp) {
+			return filtered[i].Timestamp.Before(filtered[j].Timestamp)
+		}
+		return r.store.stations[filtered[i].StationName].ID < r.store.stations[filtered[j].StationName].ID
+	})
+	return filtered, nil
+}
+
+func (r *RainfallRepo) GetAggregatesByStation(ctx context.Context, params domain.GetRainfallAggregateParams) ([]domain.AggregateBucket, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	readings, err := r.stationReadings(params.StationName)
+	if err != nil {
+		return nil, err
+	}
+	return aggregate(readings.between(params.StartDate, params.EndDate), params.Interval, params.Func), nil
+}
+
+func (r *RainfallRepo) StreamReadingsByStation(ctx context.Context, params domain.ExportRainfallParams, fn func(domain.RainfallReading) error) error {
+	// Copy the readings so fn runs without holding the lock
+	r.store.mu.RLock()
+	readings, err := r.stationReadings(params.StationName)
+	if err != nil {
+		r.store.mu.RUnlock()
+		return err
+	}
+	filtered := rainfallReadings(readings.between(params.StartDate, params.EndDate), params.StationName)
+	r.store.mu.RUnlock()
+
+	for _, reading := range filtered {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(reading); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RainfallRepo) GetLatestReadingByStation(ctx context.Context, stationName string) (domain.RainfallReading, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	readings, err := r.stationReadings(stationName)
+	if err != nil {
+		return domain.RainfallReading{}, err
+	}
+	if len(readings) == 0 {
+		return domain.RainfallReading{}, domain.ErrNotFound
+	}
+	return rainfallReadings(readings[len(readings)-1:], stationName)[0], nil
+}
+
+func (r *RainfallRepo) GetLatestReadings(ctx context.Context) ([]domain.RainfallReading, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	latest := []domain.RainfallReading{}
+	for name, readings := range r.store.rainfall {
+		if len(readings) > 0 {
+			latest = append(latest, rainfallReadings(readings[len(readings)-1:], name)...)
+		}
+	}
+
+	sort.Slice(latest, func(i, j int) bool {
+		return latest[i].StationName < latest[j].StationName
+	})
+	return latest, nil
+}
+
+func (r *RainfallRepo) UpsertReadingsByStation(ctx context.Context, stationName string, readings []domain.Measurement) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, err := r.stationReadings(stationName)
+	if err != nil {
+		return err
+	}
+	r.store.rainfall[stationName] = existing.upsert(readings)
+	return nil
+}
+
+// stationReadings returns the readings of a station, the caller holding the store's lock
+func (r *RainfallRepo) stationReadings(stationName string) (series, error) {
+	if _, exists := r.store.stations[stationName]; !exists {
+		return nil, domain.ErrNotFound
+	}
+	return r.store.rainfall[stationName], nil
+}
+
+func rainfallReadings(readings series, stationName string) []domain.RainfallReading {
+	converted := make([]domain.RainfallReading, len(readings))
+	for i, m := range readings {
+		converted[i] = domain.RainfallReading{Timestamp: m.Timestamp, Level: m.Level, StationName: stationName}
+	}
+	return converted
+}
+
+func rainfallTimestamp(reading domain.RainfallReading) time.Time {
+	return reading.Timestamp
+}
@@ -8,45 +8,149 @@ import (
 	"github.com/oliverslade/flood-api/internal/repository"
 )
 
-// This is an in-memory implementation fake for use with the service layer unit tests
+// RiverRepo serves river levels from a Store, as a fake for the handler tests or a backend
+// that needs no database
 type RiverRepo struct {
-	readings []domain.RiverReading
+	store *Store
 }
 
+// NewRiverRepo returns a repository holding its own copy of the fixtures
 func NewRiverRepo() repository.RiverRepository {
-	readings := []domain.RiverReading{
-		{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 1.2},
-		{Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Level: 1.3},
-		{Timestamp: time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC), Level: 1.4},
-		{Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Level: 1.5},
-		{Timestamp: time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), Level: 1.1},
+	return NewRiverRepoFrom(NewStore(Fixtures()))
+}
+
+// NewRiverRepoFrom returns a repository reading and writing the gauges and river levels of store
+func NewRiverRepoFrom(store *Store) repository.RiverRepository {
+	return &RiverRepo{store: store}
+}
+
+// riverFixtures mirrors actual database structure
+func riverFixtures() []domain.RiverReading {
+	return []domain.RiverReading{
+		{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 1.2, GaugeName: "rede-bridge"},
+		{Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Level: 1.3, GaugeName: "rede-bridge"},
+		{Timestamp: time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC), Level: 1.4, GaugeName: "rede-bridge"},
+		{Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Level: 1.5, GaugeName: "rede-bridge"},
+		{Timestamp: time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), Level: 1.1, GaugeName: "rede-bridge"},
+		{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 0.8, GaugeName: "otterburn"},
+		{Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Level: 0.9, GaugeName: "otterburn"},
+	}
+}
+
+// gaugeFixtures mirrors the rivergauges table by name
+func gaugeFixtures() map[string]domain.Gauge {
+	return map[string]domain.Gauge{
+		"rede-bridge": {ID: "rede-bridge", Name: "rede-bridge", River: "River Rede"},
+		"otterburn":   {ID: "otterburn", Name: "otterburn", River: "River Rede"},
+		"falstone":    {ID: "falstone", Name: "falstone", River: "North Tyne"},
+	}
+}
+
+func (r *RiverRepo) GetReadings(ctx context.Context, params domain.GetRiverParams) ([]domain.RiverReading, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	readings, err := r.gaugeReadings(params.GaugeName)
+	if err != nil {
+		return nil, err
 	}
+	return riverReadings(readings.page(params.GetReadingsParams), params.GaugeName), nil
+}
 
-	return &RiverRepo{readings: readings}
+func (r *RiverRepo) StreamReadingsPage(ctx context.Context, params domain.GetRiverParams, start func(domain.PageBounds) error, fn func(domain.RiverReading) error) error {
+	page, err := r.GetReadings(ctx, params)
+	if err != nil {
+		return err
+	}
+	return streamPage(ctx, page, riverTimestamp, start, fn)
 }
 
-func (r *RiverRepo) GetReadings(ctx context.Context, params domain.GetReadingsParams) ([]domain.RiverReading, error) {
-	var filtered []domain.RiverReading
+func (r *RiverRepo) CountReadings(ctx context.Context, params domain.GetRiverParams) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
 
-	if params.StartDate != nil {
-		for _, reading := range r.readings {
-			if reading.Timestamp.After(*params.StartDate) || reading.Timestamp.Equal(*params.StartDate) {
-				filtered = append(filtered, reading)
-			}
+	readings, err := r.gaugeReadings(params.GaugeName)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(readings.between(params.StartDate, params.EndDate))), nil
+}
+
+func (r *RiverRepo) GetAggregates(ctx context.Context, params domain.GetRiverAggregateParams) ([]domain.AggregateBucket, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	readings, err := r.gaugeReadings(params.GaugeName)
+	if err != nil {
+		return nil, err
+	}
+	return aggregate(readings.between(params.StartDate, params.EndDate), params.Interval, params.Func), nil
+}
+
+func (r *RiverRepo) GetLatestReading(ctx context.Context, gaugeName string) (domain.RiverReading, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	readings, err := r.gaugeReadings(gaugeName)
+	if err != nil {
+		return domain.RiverReading{}, err
+	}
+	if len(readings) == 0 {
+		return domain.RiverReading{}, domain.ErrNotFound
+	}
+	return riverReadings(readings[len(readings)-1:], gaugeName)[0], nil
+}
+
+func (r *RiverRepo) StreamReadings(ctx context.Context, params domain.ExportRiverParams, fn func(domain.RiverReading) error) error {
+	// Copy the readings so fn runs without holding the lock
+	r.store.mu.RLock()
+	readings, err := r.gaugeReadings(params.GaugeName)
+	if err != nil {
+		r.store.mu.RUnlock()
+		return err
+	}
+	filtered := riverReadings(readings.between(params.StartDate, params.EndDate), params.GaugeName)
+	r.store.mu.RUnlock()
+
+	for _, reading := range filtered {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(reading); err != nil {
+			return err
 		}
-	} else {
-		filtered = r.readings
 	}
+	return nil
+}
 
-	offset := (params.Pagination.Page - 1) * params.Pagination.PageSize
-	end := offset + params.Pagination.PageSize
+func (r *RiverRepo) UpsertReadings(ctx context.Context, gaugeName string, readings []domain.Measurement) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, err := r.gaugeReadings(gaugeName)
+	if err != nil {
+		return err
+	}
+	r.store.river[gaugeName] = existing.upsert(readings)
+	return nil
+}
 
-	if offset >= len(filtered) {
-		return []domain.RiverReading{}, nil
+// gaugeReadings returns the readings of a gauge, the caller holding the store's lock
+func (r *RiverRepo) gaugeReadings(gaugeName string) (series, error) {
+	if _, exists := r.store.gauges[gaugeName]; !exists {
+		return nil, domain.ErrNotFound
 	}
-	if end > len(filtered) {
-		end = len(filtered)
+	return r.store.river[gaugeName], nil
+}
+
+func riverReadings(readings series, gaugeName string) []domain.RiverReading {
+	converted := make([]domain.RiverReading, len(readings))
+	for i, m := range readings {
+		converted[i] = domain.RiverReading{Timestamp: m.Timestamp, Level: m.Level, GaugeName: gaugeName}
 	}
+	return converted
+}
 
-	return filtered[offset:end], nil
+func riverTimestamp(reading domain.RiverReading) time.Time {
+	return reading.Timestamp
 }
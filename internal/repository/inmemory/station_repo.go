@@ -0,0 +1,64 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+)
+
+// StationRepo summarises the stations and rainfall of a Store, as a fake for the handler tests or a
+// backend that needs no database
+type StationRepo struct {
+	store *Store
+}
+
+// NewStationRepo returns a repository holding its own copy of the fixtures
+func NewStationRepo() repository.StationRepository {
+	return NewStationRepoFrom(NewStore(Fixtures()))
+}
+
+// NewStationRepoFrom returns a repository summarising the stations and rainfall of store
+func NewStationRepoFrom(store *Store) repository.StationRepository {
+	return &StationRepo{store: store}
+}
+
+func (r *StationRepo) ListStations(ctx context.Context) ([]domain.StationSummary, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	summaries := make([]domain.StationSummary, 0, len(r.store.stations))
+	for _, station := range r.store.stations {
+		summaries = append(summaries, r.summarise(station))
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries, nil
+}
+
+func (r *StationRepo) GetStation(ctx context.Context, stationName string) (domain.StationSummary, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	station, exists := r.store.stations[stationName]
+	if !exists {
+		return domain.StationSummary{}, domain.ErrNotFound
+	}
+	return r.summarise(station), nil
+}
+
+// summarise reads the span of a station's readings from the ends of its series
+func (r *StationRepo) summarise(station domain.Station) domain.StationSummary {
+	summary := domain.StationSummary{ID: station.ID, Name: station.Name}
+	readings := r.store.rainfall[station.Name]
+	if len(readings) > 0 {
+		first, last := readings[0].Timestamp, readings[len(readings)-1].Timestamp
+		summary.FirstReading = &first
+		summary.LastReading = &last
+		summary.ReadingCount = int64(len(readings))
+	}
+	return summary
+}
@@ -0,0 +1,154 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// Store holds a dataset for the in-memory repositories. Each station's and gauge's readings are
+// kept in timestamp order so date filters are found by binary search, and it is safe for
+// concurrent use, so repositories sharing a store can serve the API.
+type Store struct {
+	mu       sync.RWMutex
+	stations map[string]domain.Station
+	gauges   map[string]domain.Gauge
+	rainfall map[string]series // by station name
+	river    map[string]series // by gauge name
+}
+
+// NewStore indexes a copy of data. Readings for stations or gauges it doesn't have are dropped,
+// like rows a foreign key would have rejected.
+func NewStore(data Dataset) *Store {
+	s := &Store{
+		stations: make(map[string]domain.Station, len(data.Stations)),
+		gauges:   make(map[string]domain.Gauge, len(data.Gauges)),
+		rainfall: make(map[string]series, len(data.Stations)),
+		river:    make(map[string]series, len(data.Gauges)),
+	}
+	for _, station := range data.Stations {
+		s.stations[station.Name] = station
+	}
+	for _, gauge := range data.Gauges {
+		s.gauges[gauge.Name] = gauge
+	}
+
+	for _, reading := range data.Rainfall {
+		if _, exists := s.stations[reading.StationName]; exists {
+			s.rainfall[reading.StationName] = append(s.rainfall[reading.StationName], domain.Measurement{Timestamp: reading.Timestamp.UTC(), Level: reading.Level})
+		}
+	}
+	for _, reading := range data.River {
+		if _, exists := s.gauges[reading.GaugeName]; exists {
+			s.river[reading.GaugeName] = append(s.river[reading.GaugeName], domain.Measurement{Timestamp: reading.Timestamp.UTC(), Level: reading.Level})
+		}
+	}
+	for _, index := range []map[string]series{s.rainfall, s.river} {
+		for key, readings := range index {
+			index[key] = readings.sorted()
+		}
+	}
+	return s
+}
+
+// series is the readings of one station or gauge in timestamp order, with at most one per timestamp
+type series []domain.Measurement
+
+// sorted orders the readings, keeping the last of any with the same timestamp like an upsert would
+func (s series) sorted() series {
+	sort.SliceStable(s, func(i, j int) bool {
+		return s[i].Timestamp.Before(s[j].Timestamp)
+	})
+
+	unique := s[:0]
+	for _, m := range s {
+		if n := len(unique); n > 0 && unique[n-1].Timestamp.Equal(m.Timestamp) {
+			unique[n-1] = m
+			continue
+		}
+		unique = append(unique, m)
+	}
+	return unique
+}
+
+// search returns the index of the first reading at or after t
+func (s series) search(t time.Time) int {
+	return sort.Search(len(s), func(i int) bool {
+		return !s[i].Timestamp.Before(t)
+	})
+}
+
+// between returns the readings from start, inclusive, to end, exclusive, either of which may be nil
+func (s series) between(start, end *time.Time) series {
+	if end != nil {
+		s = s[:s.search(*end)]
+	}
+	if start != nil {
+		s = s[s.search(*start):]
+	}
+	return s
+}
+
+// after returns the readings strictly after t
+func (s series) after(t time.Time) series {
+	i := s.search(t)
+	if i < len(s) && s[i].Timestamp.Equal(t) {
+		i++
+	}
+	return s[i:]
+}
+
+// page returns the readings for a page of the date filters, a keyset cursor taking precedence over
+// the page number
+func (s series) page(params domain.GetReadingsParams) series {
+	s = s.between(params.StartDate, params.EndDate)
+	if params.Pagination.After != nil {
+		s = s.after(*params.Pagination.After)
+	} else {
+		offset := (params.Pagination.Page - 1) * params.Pagination.PageSize
+		if offset >= len(s) {
+			return nil
+		}
+		s = s[offset:]
+	}
+	if len(s) > params.Pagination.PageSize {
+		s = s[:params.Pagination.PageSize]
+	}
+	return s
+}
+
+// upsert returns a copy of the series with readings stored in timestamp order, each replacing any
+// reading already at its timestamp
+func (s series) upsert(readings []domain.Measurement) series {
+	merged := make(series, 0, len(s)+len(readings))
+	merged = append(merged, s...)
+	for _, m := range readings {
+		merged = append(merged, domain.Measurement{Timestamp: m.Timestamp.UTC(), Level: m.Level})
+	}
+	return merged.sorted()
+}
+
+// streamPage hands a page already copied out of the store to start and fn, so they run without
+// holding the lock
+func streamPage[T any](ctx context.Context, page []T, timestamp func(T) time.Time, start func(domain.PageBounds) error, fn func(T) error) error {
+	bounds := domain.PageBounds{Count: len(page)}
+	if len(page) > 0 {
+		bounds.Last = timestamp(page[len(page)-1])
+	}
+	if err := start(bounds); err != nil {
+		return err
+	}
+
+	for _, reading := range page {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(reading); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package inmemory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// WriteDataset writes data in the form LoadDataset reads, as a JSON file when path ends in .json
+// and otherwise as a directory of CSV files, creating the directory if needed
+func WriteDataset(path string, data Dataset) error {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return writeJSON(path, data)
+	}
+	return writeCSV(path, data)
+}
+
+func writeJSON(path string, data Dataset) error {
+	file := datasetJSON{Rainfall: data.Rainfall, River: data.River}
+	for _, station := range data.Stations {
+		file.Stations = append(file.Stations, stationJSON{ID: station.ID, Name: station.Name})
+	}
+	for _, gauge := range data.Gauges {
+		file.Gauges = append(file.Gauges, gaugeJSON{ID: gauge.ID, Name: gauge.Name, River: gauge.River})
+	}
+
+	return createFile(path, func(f *os.File) error {
+		return json.NewEncoder(f).Encode(file)
+	})
+}
+
+func writeCSV(dir string, data Dataset) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name    string
+		header  []string
+		records func(add func([]string) error) error
+	}{
+		{stationsFile, []string{"id", "name"}, func(add func([]string) error) error {
+			for _, station := range data.Stations {
+				if err := add([]string{station.ID, station.Name}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{gaugesFile, []string{"id", "name", "river"}, func(add func([]string) error) error {
+			for _, gauge := range data.Gauges {
+				if err := add([]string{gauge.ID, gauge.Name, gauge.River}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{rainfallFile, domain.RainfallReadingCSVHeader, func(add func([]string) error) error {
+			for _, reading := range data.Rainfall {
+				if err := add(reading.CSVRecord()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{riverFile, domain.RiverReadingCSVHeader, func(add func([]string) error) error {
+			for _, reading := range data.River {
+				if err := add(reading.CSVRecord()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+	}
+
+	for _, file := range files {
+		err := createFile(filepath.Join(dir, file.name), func(f *os.File) error {
+			w := csv.NewWriter(f)
+			if err := w.Write(file.header); err != nil {
+				return err
+			}
+			if err := file.records(w.Write); err != nil {
+				return err
+			}
+			w.Flush()
+			return w.Error()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createFile calls write with a new file at path, reporting any error closing it
+func createFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
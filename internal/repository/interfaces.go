@@ -2,16 +2,80 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/oliverslade/flood-api/internal/domain"
 )
 
 type RiverRepository interface {
-	// returns river level readings with pagination and optional date filtering
-	GetReadings(ctx context.Context, params domain.GetReadingsParams) ([]domain.RiverReading, error)
+	// returns river level readings for a gauge name with pagination and optional date filtering
+	GetReadings(ctx context.Context, params domain.GetRiverParams) ([]domain.RiverReading, error)
+	// streams a page of river level readings for a gauge to fn as they are read, after passing the page's bounds to start once
+	StreamReadingsPage(ctx context.Context, params domain.GetRiverParams, start func(domain.PageBounds) error, fn func(domain.RiverReading) error) error
+	// returns the number of river level readings for a gauge matching the date filters, ignoring pagination
+	CountReadings(ctx context.Context, params domain.GetRiverParams) (int64, error)
+	// returns river levels for a gauge grouped into time buckets, in chronological order
+	GetAggregates(ctx context.Context, params domain.GetRiverAggregateParams) ([]domain.AggregateBucket, error)
+	// returns the most recent river level reading for a gauge name
+	GetLatestReading(ctx context.Context, gaugeName string) (domain.RiverReading, error)
+	// streams every river level reading for a gauge within the date filters to fn in chronological order, stopping at the first error fn returns
+	StreamReadings(ctx context.Context, params domain.ExportRiverParams, fn func(domain.RiverReading) error) error
+	// stores river level readings for a gauge name in one batch, replacing the level of any timestamp already stored
+	UpsertReadings(ctx context.Context, gaugeName string, readings []domain.Measurement) error
 }
 
 type RainfallRepository interface {
 	// returns rainfall readings for a station name
 	GetReadingsByStation(ctx context.Context, params domain.GetRainfallParams) ([]domain.RainfallReading, error)
+	// streams a page of rainfall readings for a station to fn as they are read, after passing the page's bounds to start once
+	StreamReadingsPageByStation(ctx context.Context, params domain.GetRainfallParams, start func(domain.PageBounds) error, fn func(domain.RainfallReading) error) error
+	// returns the number of rainfall readings for a station matching the date filters, ignoring pagination
+	CountReadingsByStation(ctx context.Context, params domain.GetRainfallParams) (int64, error)
+	// returns rainfall readings for several station names merged in chronological order, or an UnknownStationsError
+	GetReadingsByStations(ctx context.Context, params domain.GetRainfallStationsParams) ([]domain.RainfallReading, error)
+	// streams a page of the merged rainfall readings of several station names like StreamReadingsPageByStation
+	StreamReadingsPageByStations(ctx context.Context, params domain.GetRainfallStationsParams, start func(domain.PageBounds) error, fn func(domain.RainfallReading) error) error
+	// returns the number of rainfall readings for several station names matching the date filters, ignoring pagination
+	CountReadingsByStations(ctx context.Context, params domain.GetRainfallStationsParams) (int64, error)
+	// returns rainfall for a station grouped into time buckets, in chronological order
+	GetAggregatesByStation(ctx context.Context, params domain.GetRainfallAggregateParams) ([]domain.AggregateBucket, error)
+	// returns the most recent rainfall reading for a station name
+	GetLatestReadingByStation(ctx context.Context, stationName string) (domain.RainfallReading, error)
+	// returns the most recent rainfall reading of every station that has readings, ordered by station name
+	GetLatestReadings(ctx context.Context) ([]domain.RainfallReading, error)
+	// streams every rainfall reading for a station within the date filters to fn in chronological order, stopping at the first error fn returns
+	StreamReadingsByStation(ctx context.Context, params domain.ExportRainfallParams, fn func(domain.RainfallReading) error) error
+	// stores rainfall readings for a station name in one batch, replacing the level of any timestamp already stored
+	UpsertReadingsByStation(ctx context.Context, stationName string, readings []domain.Measurement) error
+}
+
+type StationRepository interface {
+	// returns every rainfall station with the span and number of its readings, ordered by name
+	ListStations(ctx context.Context) ([]domain.StationSummary, error)
+	// returns a single station summary by station name
+	GetStation(ctx context.Context, stationName string) (domain.StationSummary, error)
+}
+
+type IngestRepository interface {
+	// returns the id of every rainfall station, which is also its Defra station reference
+	ListStationIDs(ctx context.Context) ([]string, error)
+	// returns the timestamp of the most recent rainfall reading for a station id, or the zero time when it has none
+	GetLatestRainfallTimestamp(ctx context.Context, stationID string) (time.Time, error)
+	// returns the timestamp of the most recent river level reading for a gauge name, or the zero time when it has none
+	GetLatestRiverTimestamp(ctx context.Context, gaugeName string) (time.Time, error)
+	// stores rainfall readings for a station id, skipping timestamps it already has, and returns how many were new
+	InsertRainfallReadings(ctx context.Context, stationID string, readings []domain.Measurement) (int64, error)
+	// stores river level readings for a gauge name, skipping timestamps it already has, and returns how many were new
+	InsertRiverReadings(ctx context.Context, gaugeName string, readings []domain.Measurement) (int64, error)
+	// brings any summaries kept of the stored readings, such as rainfall rollups, up to date with new readings
+	RefreshRollups(ctx context.Context) error
+}
+
+type ImportRepository interface {
+	// bulk loads the stations load passes to add, keeping any station whose id is already stored
+	ImportStations(ctx context.Context, load func(add func(domain.Station) error) error) (domain.ImportResult, error)
+	// bulk loads rainfall readings by station id, rejecting readings for stations that don't exist
+	ImportRainfall(ctx context.Context, load func(add func(stationID string, m domain.Measurement) error) error) (domain.ImportResult, error)
+	// bulk loads river level readings for a gauge name, or returns ErrNotFound for an unknown gauge
+	ImportRiverLevels(ctx context.Context, gaugeName string, load func(add func(domain.Measurement) error) error) (domain.ImportResult, error)
 }
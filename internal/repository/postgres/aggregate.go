@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository/postgres/gen"
+)
+
+// nullTime converts an optional date filter into a nullable query argument
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+// toAggregateBuckets picks the requested statistic out of each bucket row.
+// The queries compute every statistic in one pass so the function doesn't need its own query.
+func toAggregateBuckets(rows []gen.GetRiverAggregatesRow, fn string) []domain.AggregateBucket {
+	buckets := make([]domain.AggregateBucket, len(rows))
+	for i, row := range rows {
+		value := row.Mean
+		switch fn {
+		case domain.AggregateSum:
+			value = row.Total
+		case domain.AggregateMax:
+			value = row.Maximum
+		case domain.AggregateMin:
+			value = row.Minimum
+		}
+		buckets[i] = domain.AggregateBucket{
+			Start: row.Bucket,
+			Value: value,
+			Count: row.SampleCount,
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// exportFetchSize is how many rows each FETCH pulls from an export cursor
+const exportFetchSize = 1000
+
+// Export queries are run through DECLARE rather than sqlc, which only generates plain statements
+const (
+	exportRiverReadingsQuery = `SELECT timestamp, level
+FROM riverlevels
+WHERE gaugeid = $1
+  AND ($2::timestamp IS NULL OR timestamp >= $2)
+  AND ($3::timestamp IS NULL OR timestamp < $3)
+ORDER BY timestamp ASC`
+
+	exportRainfallReadingsQuery = `SELECT timestamp, level
+FROM rainfalls
+WHERE stationid = $1
+  AND ($2::timestamp IS NULL OR timestamp >= $2)
+  AND ($3::timestamp IS NULL OR timestamp < $3)
+ORDER BY timestamp ASC`
+)
+
+// streamCursor runs query through a server-side cursor in a read-only transaction so memory stays
+// flat however long the series is. scan is called for every row, and a slow consumer holds back the
+// next FETCH rather than letting rows pile up in the client.
+func streamCursor(ctx context.Context, db *sql.DB, query string, args []interface{}, scan func(*sql.Rows) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE export_cursor NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", exportFetchSize)
+	for {
+		n, err := fetchBatch(ctx, tx, fetch, scan)
+		if err != nil {
+			return err
+		}
+		if n < exportFetchSize {
+			break
+		}
+	}
+
+	// committing closes the cursor
+	return tx.Commit()
+}
+
+// fetchBatch scans one FETCH worth of rows and returns how many there were
+func fetchBatch(ctx context.Context, tx *sql.Tx, fetch string, scan func(*sql.Rows) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
@@ -27,24 +27,90 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.countRainfallReadingsByStationStmt, err = db.PrepareContext(ctx, countRainfallReadingsByStation); err != nil {
 		return nil, fmt.Errorf("error preparing query CountRainfallReadingsByStation: %w", err)
 	}
+	if q.countRainfallReadingsByStationInDateRangeStmt, err = db.PrepareContext(ctx, countRainfallReadingsByStationInDateRange); err != nil {
+		return nil, fmt.Errorf("error preparing query CountRainfallReadingsByStationInDateRange: %w", err)
+	}
+	if q.countRainfallReadingsByStationWithEndDateStmt, err = db.PrepareContext(ctx, countRainfallReadingsByStationWithEndDate); err != nil {
+		return nil, fmt.Errorf("error preparing query CountRainfallReadingsByStationWithEndDate: %w", err)
+	}
 	if q.countRainfallReadingsByStationWithStartDateStmt, err = db.PrepareContext(ctx, countRainfallReadingsByStationWithStartDate); err != nil {
 		return nil, fmt.Errorf("error preparing query CountRainfallReadingsByStationWithStartDate: %w", err)
 	}
+	if q.countRainfallReadingsByStationsStmt, err = db.PrepareContext(ctx, countRainfallReadingsByStations); err != nil {
+		return nil, fmt.Errorf("error preparing query CountRainfallReadingsByStations: %w", err)
+	}
 	if q.countRiverReadingsStmt, err = db.PrepareContext(ctx, countRiverReadings); err != nil {
 		return nil, fmt.Errorf("error preparing query CountRiverReadings: %w", err)
 	}
+	if q.countRiverReadingsInDateRangeStmt, err = db.PrepareContext(ctx, countRiverReadingsInDateRange); err != nil {
+		return nil, fmt.Errorf("error preparing query CountRiverReadingsInDateRange: %w", err)
+	}
+	if q.countRiverReadingsWithEndDateStmt, err = db.PrepareContext(ctx, countRiverReadingsWithEndDate); err != nil {
+		return nil, fmt.Errorf("error preparing query CountRiverReadingsWithEndDate: %w", err)
+	}
 	if q.countRiverReadingsWithStartDateStmt, err = db.PrepareContext(ctx, countRiverReadingsWithStartDate); err != nil {
 		return nil, fmt.Errorf("error preparing query CountRiverReadingsWithStartDate: %w", err)
 	}
+	if q.getGaugeByNameStmt, err = db.PrepareContext(ctx, getGaugeByName); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGaugeByName: %w", err)
+	}
+	if q.getLatestRainfallReadingByStationStmt, err = db.PrepareContext(ctx, getLatestRainfallReadingByStation); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestRainfallReadingByStation: %w", err)
+	}
+	if q.getLatestRainfallReadingsStmt, err = db.PrepareContext(ctx, getLatestRainfallReadings); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestRainfallReadings: %w", err)
+	}
+	if q.getLatestRiverReadingStmt, err = db.PrepareContext(ctx, getLatestRiverReading); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestRiverReading: %w", err)
+	}
+	if q.getRainfallAggregatesByStationStmt, err = db.PrepareContext(ctx, getRainfallAggregatesByStation); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRainfallAggregatesByStation: %w", err)
+	}
+	if q.getRainfallDailyAggregatesByStationStmt, err = db.PrepareContext(ctx, getRainfallDailyAggregatesByStation); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRainfallDailyAggregatesByStation: %w", err)
+	}
+	if q.getRainfallHourlyAggregatesByStationStmt, err = db.PrepareContext(ctx, getRainfallHourlyAggregatesByStation); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRainfallHourlyAggregatesByStation: %w", err)
+	}
 	if q.getRainfallReadingsByStationStmt, err = db.PrepareContext(ctx, getRainfallReadingsByStation); err != nil {
 		return nil, fmt.Errorf("error preparing query GetRainfallReadingsByStation: %w", err)
 	}
+	if q.getRainfallReadingsByStationAfterStmt, err = db.PrepareContext(ctx, getRainfallReadingsByStationAfter); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRainfallReadingsByStationAfter: %w", err)
+	}
+	if q.getRainfallReadingsByStationAfterWithEndDateStmt, err = db.PrepareContext(ctx, getRainfallReadingsByStationAfterWithEndDate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRainfallReadingsByStationAfterWithEndDate: %w", err)
+	}
+	if q.getRainfallReadingsByStationInDateRangeStmt, err = db.PrepareContext(ctx, getRainfallReadingsByStationInDateRange); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRainfallReadingsByStationInDateRange: %w", err)
+	}
+	if q.getRainfallReadingsByStationWithEndDateStmt, err = db.PrepareContext(ctx, getRainfallReadingsByStationWithEndDate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRainfallReadingsByStationWithEndDate: %w", err)
+	}
 	if q.getRainfallReadingsByStationWithStartDateStmt, err = db.PrepareContext(ctx, getRainfallReadingsByStationWithStartDate); err != nil {
 		return nil, fmt.Errorf("error preparing query GetRainfallReadingsByStationWithStartDate: %w", err)
 	}
+	if q.getRainfallReadingsByStationsStmt, err = db.PrepareContext(ctx, getRainfallReadingsByStations); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRainfallReadingsByStations: %w", err)
+	}
+	if q.getRiverAggregatesStmt, err = db.PrepareContext(ctx, getRiverAggregates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRiverAggregates: %w", err)
+	}
 	if q.getRiverReadingsStmt, err = db.PrepareContext(ctx, getRiverReadings); err != nil {
 		return nil, fmt.Errorf("error preparing query GetRiverReadings: %w", err)
 	}
+	if q.getRiverReadingsAfterStmt, err = db.PrepareContext(ctx, getRiverReadingsAfter); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRiverReadingsAfter: %w", err)
+	}
+	if q.getRiverReadingsAfterWithEndDateStmt, err = db.PrepareContext(ctx, getRiverReadingsAfterWithEndDate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRiverReadingsAfterWithEndDate: %w", err)
+	}
+	if q.getRiverReadingsInDateRangeStmt, err = db.PrepareContext(ctx, getRiverReadingsInDateRange); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRiverReadingsInDateRange: %w", err)
+	}
+	if q.getRiverReadingsWithEndDateStmt, err = db.PrepareContext(ctx, getRiverReadingsWithEndDate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRiverReadingsWithEndDate: %w", err)
+	}
 	if q.getRiverReadingsWithStartDateStmt, err = db.PrepareContext(ctx, getRiverReadingsWithStartDate); err != nil {
 		return nil, fmt.Errorf("error preparing query GetRiverReadingsWithStartDate: %w", err)
 	}
@@ -54,6 +120,36 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getStationByNameStmt, err = db.PrepareContext(ctx, getStationByName); err != nil {
 		return nil, fmt.Errorf("error preparing query GetStationByName: %w", err)
 	}
+	if q.getStationSummaryByNameStmt, err = db.PrepareContext(ctx, getStationSummaryByName); err != nil {
+		return nil, fmt.Errorf("error preparing query GetStationSummaryByName: %w", err)
+	}
+	if q.getStationsByNamesStmt, err = db.PrepareContext(ctx, getStationsByNames); err != nil {
+		return nil, fmt.Errorf("error preparing query GetStationsByNames: %w", err)
+	}
+	if q.hasPendingRainfallRollupsStmt, err = db.PrepareContext(ctx, hasPendingRainfallRollups); err != nil {
+		return nil, fmt.Errorf("error preparing query HasPendingRainfallRollups: %w", err)
+	}
+	if q.insertRainfallReadingStmt, err = db.PrepareContext(ctx, insertRainfallReading); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertRainfallReading: %w", err)
+	}
+	if q.insertRiverReadingStmt, err = db.PrepareContext(ctx, insertRiverReading); err != nil {
+		return nil, fmt.Errorf("error preparing query InsertRiverReading: %w", err)
+	}
+	if q.listStationSummariesStmt, err = db.PrepareContext(ctx, listStationSummaries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListStationSummaries: %w", err)
+	}
+	if q.listStationsStmt, err = db.PrepareContext(ctx, listStations); err != nil {
+		return nil, fmt.Errorf("error preparing query ListStations: %w", err)
+	}
+	if q.markRainfallRollupsPendingStmt, err = db.PrepareContext(ctx, markRainfallRollupsPending); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkRainfallRollupsPending: %w", err)
+	}
+	if q.upsertRainfallReadingStmt, err = db.PrepareContext(ctx, upsertRainfallReading); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertRainfallReading: %w", err)
+	}
+	if q.upsertRiverReadingStmt, err = db.PrepareContext(ctx, upsertRiverReading); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertRiverReading: %w", err)
+	}
 	return &q, nil
 }
 
@@ -64,36 +160,146 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing countRainfallReadingsByStationStmt: %w", cerr)
 		}
 	}
+	if q.countRainfallReadingsByStationInDateRangeStmt != nil {
+		if cerr := q.countRainfallReadingsByStationInDateRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countRainfallReadingsByStationInDateRangeStmt: %w", cerr)
+		}
+	}
+	if q.countRainfallReadingsByStationWithEndDateStmt != nil {
+		if cerr := q.countRainfallReadingsByStationWithEndDateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countRainfallReadingsByStationWithEndDateStmt: %w", cerr)
+		}
+	}
 	if q.countRainfallReadingsByStationWithStartDateStmt != nil {
 		if cerr := q.countRainfallReadingsByStationWithStartDateStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing countRainfallReadingsByStationWithStartDateStmt: %w", cerr)
 		}
 	}
+	if q.countRainfallReadingsByStationsStmt != nil {
+		if cerr := q.countRainfallReadingsByStationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countRainfallReadingsByStationsStmt: %w", cerr)
+		}
+	}
 	if q.countRiverReadingsStmt != nil {
 		if cerr := q.countRiverReadingsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing countRiverReadingsStmt: %w", cerr)
 		}
 	}
+	if q.countRiverReadingsInDateRangeStmt != nil {
+		if cerr := q.countRiverReadingsInDateRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countRiverReadingsInDateRangeStmt: %w", cerr)
+		}
+	}
+	if q.countRiverReadingsWithEndDateStmt != nil {
+		if cerr := q.countRiverReadingsWithEndDateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countRiverReadingsWithEndDateStmt: %w", cerr)
+		}
+	}
 	if q.countRiverReadingsWithStartDateStmt != nil {
 		if cerr := q.countRiverReadingsWithStartDateStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing countRiverReadingsWithStartDateStmt: %w", cerr)
 		}
 	}
+	if q.getGaugeByNameStmt != nil {
+		if cerr := q.getGaugeByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGaugeByNameStmt: %w", cerr)
+		}
+	}
+	if q.getLatestRainfallReadingByStationStmt != nil {
+		if cerr := q.getLatestRainfallReadingByStationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestRainfallReadingByStationStmt: %w", cerr)
+		}
+	}
+	if q.getLatestRainfallReadingsStmt != nil {
+		if cerr := q.getLatestRainfallReadingsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestRainfallReadingsStmt: %w", cerr)
+		}
+	}
+	if q.getLatestRiverReadingStmt != nil {
+		if cerr := q.getLatestRiverReadingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestRiverReadingStmt: %w", cerr)
+		}
+	}
+	if q.getRainfallAggregatesByStationStmt != nil {
+		if cerr := q.getRainfallAggregatesByStationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRainfallAggregatesByStationStmt: %w", cerr)
+		}
+	}
+	if q.getRainfallDailyAggregatesByStationStmt != nil {
+		if cerr := q.getRainfallDailyAggregatesByStationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRainfallDailyAggregatesByStationStmt: %w", cerr)
+		}
+	}
+	if q.getRainfallHourlyAggregatesByStationStmt != nil {
+		if cerr := q.getRainfallHourlyAggregatesByStationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRainfallHourlyAggregatesByStationStmt: %w", cerr)
+		}
+	}
 	if q.getRainfallReadingsByStationStmt != nil {
 		if cerr := q.getRainfallReadingsByStationStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getRainfallReadingsByStationStmt: %w", cerr)
 		}
 	}
+	if q.getRainfallReadingsByStationAfterStmt != nil {
+		if cerr := q.getRainfallReadingsByStationAfterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRainfallReadingsByStationAfterStmt: %w", cerr)
+		}
+	}
+	if q.getRainfallReadingsByStationAfterWithEndDateStmt != nil {
+		if cerr := q.getRainfallReadingsByStationAfterWithEndDateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRainfallReadingsByStationAfterWithEndDateStmt: %w", cerr)
+		}
+	}
+	if q.getRainfallReadingsByStationInDateRangeStmt != nil {
+		if cerr := q.getRainfallReadingsByStationInDateRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRainfallReadingsByStationInDateRangeStmt: %w", cerr)
+		}
+	}
+	if q.getRainfallReadingsByStationWithEndDateStmt != nil {
+		if cerr := q.getRainfallReadingsByStationWithEndDateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRainfallReadingsByStationWithEndDateStmt: %w", cerr)
+		}
+	}
 	if q.getRainfallReadingsByStationWithStartDateStmt != nil {
 		if cerr := q.getRainfallReadingsByStationWithStartDateStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getRainfallReadingsByStationWithStartDateStmt: %w", cerr)
 		}
 	}
+	if q.getRainfallReadingsByStationsStmt != nil {
+		if cerr := q.getRainfallReadingsByStationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRainfallReadingsByStationsStmt: %w", cerr)
+		}
+	}
+	if q.getRiverAggregatesStmt != nil {
+		if cerr := q.getRiverAggregatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRiverAggregatesStmt: %w", cerr)
+		}
+	}
 	if q.getRiverReadingsStmt != nil {
 		if cerr := q.getRiverReadingsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getRiverReadingsStmt: %w", cerr)
 		}
 	}
+	if q.getRiverReadingsAfterStmt != nil {
+		if cerr := q.getRiverReadingsAfterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRiverReadingsAfterStmt: %w", cerr)
+		}
+	}
+	if q.getRiverReadingsAfterWithEndDateStmt != nil {
+		if cerr := q.getRiverReadingsAfterWithEndDateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRiverReadingsAfterWithEndDateStmt: %w", cerr)
+		}
+	}
+	if q.getRiverReadingsInDateRangeStmt != nil {
+		if cerr := q.getRiverReadingsInDateRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRiverReadingsInDateRangeStmt: %w", cerr)
+		}
+	}
+	if q.getRiverReadingsWithEndDateStmt != nil {
+		if cerr := q.getRiverReadingsWithEndDateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRiverReadingsWithEndDateStmt: %w", cerr)
+		}
+	}
 	if q.getRiverReadingsWithStartDateStmt != nil {
 		if cerr := q.getRiverReadingsWithStartDateStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getRiverReadingsWithStartDateStmt: %w", cerr)
@@ -109,6 +315,56 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getStationByNameStmt: %w", cerr)
 		}
 	}
+	if q.getStationSummaryByNameStmt != nil {
+		if cerr := q.getStationSummaryByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getStationSummaryByNameStmt: %w", cerr)
+		}
+	}
+	if q.getStationsByNamesStmt != nil {
+		if cerr := q.getStationsByNamesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getStationsByNamesStmt: %w", cerr)
+		}
+	}
+	if q.hasPendingRainfallRollupsStmt != nil {
+		if cerr := q.hasPendingRainfallRollupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing hasPendingRainfallRollupsStmt: %w", cerr)
+		}
+	}
+	if q.insertRainfallReadingStmt != nil {
+		if cerr := q.insertRainfallReadingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertRainfallReadingStmt: %w", cerr)
+		}
+	}
+	if q.insertRiverReadingStmt != nil {
+		if cerr := q.insertRiverReadingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing insertRiverReadingStmt: %w", cerr)
+		}
+	}
+	if q.listStationSummariesStmt != nil {
+		if cerr := q.listStationSummariesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listStationSummariesStmt: %w", cerr)
+		}
+	}
+	if q.listStationsStmt != nil {
+		if cerr := q.listStationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listStationsStmt: %w", cerr)
+		}
+	}
+	if q.markRainfallRollupsPendingStmt != nil {
+		if cerr := q.markRainfallRollupsPendingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markRainfallRollupsPendingStmt: %w", cerr)
+		}
+	}
+	if q.upsertRainfallReadingStmt != nil {
+		if cerr := q.upsertRainfallReadingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertRainfallReadingStmt: %w", cerr)
+		}
+	}
+	if q.upsertRiverReadingStmt != nil {
+		if cerr := q.upsertRiverReadingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertRiverReadingStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -146,18 +402,50 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                                              DBTX
-	tx                                              *sql.Tx
-	countRainfallReadingsByStationStmt              *sql.Stmt
-	countRainfallReadingsByStationWithStartDateStmt *sql.Stmt
-	countRiverReadingsStmt                          *sql.Stmt
-	countRiverReadingsWithStartDateStmt             *sql.Stmt
-	getRainfallReadingsByStationStmt                *sql.Stmt
-	getRainfallReadingsByStationWithStartDateStmt   *sql.Stmt
-	getRiverReadingsStmt                            *sql.Stmt
-	getRiverReadingsWithStartDateStmt               *sql.Stmt
-	getStationByIDStmt                              *sql.Stmt
-	getStationByNameStmt                            *sql.Stmt
+	db                                               DBTX
+	tx                                               *sql.Tx
+	countRainfallReadingsByStationStmt               *sql.Stmt
+	countRainfallReadingsByStationInDateRangeStmt    *sql.Stmt
+	countRainfallReadingsByStationWithEndDateStmt    *sql.Stmt
+	countRainfallReadingsByStationWithStartDateStmt  *sql.Stmt
+	countRainfallReadingsByStationsStmt              *sql.Stmt
+	countRiverReadingsStmt                           *sql.Stmt
+	countRiverReadingsInDateRangeStmt                *sql.Stmt
+	countRiverReadingsWithEndDateStmt                *sql.Stmt
+	countRiverReadingsWithStartDateStmt              *sql.Stmt
+	getGaugeByNameStmt                               *sql.Stmt
+	getLatestRainfallReadingByStationStmt            *sql.Stmt
+	getLatestRainfallReadingsStmt                    *sql.Stmt
+	getLatestRiverReadingStmt                        *sql.Stmt
+	getRainfallAggregatesByStationStmt               *sql.Stmt
+	getRainfallDailyAggregatesByStationStmt          *sql.Stmt
+	getRainfallHourlyAggregatesByStationStmt         *sql.Stmt
+	getRainfallReadingsByStationStmt                 *sql.Stmt
+	getRainfallReadingsByStationAfterStmt            *sql.Stmt
+	getRainfallReadingsByStationAfterWithEndDateStmt *sql.Stmt
+	getRainfallReadingsByStationInDateRangeStmt      *sql.Stmt
+	getRainfallReadingsByStationWithEndDateStmt      *sql.Stmt
+	getRainfallReadingsByStationWithStartDateStmt    *sql.Stmt
+	getRainfallReadingsByStationsStmt                *sql.Stmt
+	getRiverAggregatesStmt                           *sql.Stmt
+	getRiverReadingsStmt                             *sql.Stmt
+	getRiverReadingsAfterStmt                        *sql.Stmt
+	getRiverReadingsAfterWithEndDateStmt             *sql.Stmt
+	getRiverReadingsInDateRangeStmt                  *sql.Stmt
+	getRiverReadingsWithEndDateStmt                  *sql.Stmt
+	getRiverReadingsWithStartDateStmt                *sql.Stmt
+	getStationByIDStmt                               *sql.Stmt
+	getStationByNameStmt                             *sql.Stmt
+	getStationSummaryByNameStmt                      *sql.Stmt
+	getStationsByNamesStmt                           *sql.Stmt
+	hasPendingRainfallRollupsStmt                    *sql.Stmt
+	insertRainfallReadingStmt                        *sql.Stmt
+	insertRiverReadingStmt                           *sql.Stmt
+	listStationSummariesStmt                         *sql.Stmt
+	listStationsStmt                                 *sql.Stmt
+	markRainfallRollupsPendingStmt                   *sql.Stmt
+	upsertRainfallReadingStmt                        *sql.Stmt
+	upsertRiverReadingStmt                           *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
@@ -165,14 +453,46 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 		db:                                 tx,
 		tx:                                 tx,
 		countRainfallReadingsByStationStmt: q.countRainfallReadingsByStationStmt,
-		countRainfallReadingsByStationWithStartDateStmt: q.countRainfallReadingsByStationWithStartDateStmt,
-		countRiverReadingsStmt:                          q.countRiverReadingsStmt,
-		countRiverReadingsWithStartDateStmt:             q.countRiverReadingsWithStartDateStmt,
-		getRainfallReadingsByStationStmt:                q.getRainfallReadingsByStationStmt,
-		getRainfallReadingsByStationWithStartDateStmt:   q.getRainfallReadingsByStationWithStartDateStmt,
-		getRiverReadingsStmt:                            q.getRiverReadingsStmt,
-		getRiverReadingsWithStartDateStmt:               q.getRiverReadingsWithStartDateStmt,
-		getStationByIDStmt:                              q.getStationByIDStmt,
-		getStationByNameStmt:                            q.getStationByNameStmt,
+		countRainfallReadingsByStationInDateRangeStmt:    q.countRainfallReadingsByStationInDateRangeStmt,
+		countRainfallReadingsByStationWithEndDateStmt:    q.countRainfallReadingsByStationWithEndDateStmt,
+		countRainfallReadingsByStationWithStartDateStmt:  q.countRainfallReadingsByStationWithStartDateStmt,
+		countRainfallReadingsByStationsStmt:              q.countRainfallReadingsByStationsStmt,
+		countRiverReadingsStmt:                           q.countRiverReadingsStmt,
+		countRiverReadingsInDateRangeStmt:                q.countRiverReadingsInDateRangeStmt,
+		countRiverReadingsWithEndDateStmt:                q.countRiverReadingsWithEndDateStmt,
+		countRiverReadingsWithStartDateStmt:              q.countRiverReadingsWithStartDateStmt,
+		getGaugeByNameStmt:                               q.getGaugeByNameStmt,
+		getLatestRainfallReadingByStationStmt:            q.getLatestRainfallReadingByStationStmt,
+		getLatestRainfallReadingsStmt:                    q.getLatestRainfallReadingsStmt,
+		getLatestRiverReadingStmt:                        q.getLatestRiverReadingStmt,
+		getRainfallAggregatesByStationStmt:               q.getRainfallAggregatesByStationStmt,
+		getRainfallDailyAggregatesByStationStmt:          q.getRainfallDailyAggregatesByStationStmt,
+		getRainfallHourlyAggregatesByStationStmt:         q.getRainfallHourlyAggregatesByStationStmt,
+		getRainfallReadingsByStationStmt:                 q.getRainfallReadingsByStationStmt,
+		getRainfallReadingsByStationAfterStmt:            q.getRainfallReadingsByStationAfterStmt,
+		getRainfallReadingsByStationAfterWithEndDateStmt: q.getRainfallReadingsByStationAfterWithEndDateStmt,
+		getRainfallReadingsByStationInDateRangeStmt:      q.getRainfallReadingsByStationInDateRangeStmt,
+		getRainfallReadingsByStationWithEndDateStmt:      q.getRainfallReadingsByStationWithEndDateStmt,
+		getRainfallReadingsByStationWithStartDateStmt:    q.getRainfallReadingsByStationWithStartDateStmt,
+		getRainfallReadingsByStationsStmt:                q.getRainfallReadingsByStationsStmt,
+		getRiverAggregatesStmt:                           q.getRiverAggregatesStmt,
+		getRiverReadingsStmt:                             q.getRiverReadingsStmt,
+		getRiverReadingsAfterStmt:                        q.getRiverReadingsAfterStmt,
+		getRiverReadingsAfterWithEndDateStmt:             q.getRiverReadingsAfterWithEndDateStmt,
+		getRiverReadingsInDateRangeStmt:                  q.getRiverReadingsInDateRangeStmt,
+		getRiverReadingsWithEndDateStmt:                  q.getRiverReadingsWithEndDateStmt,
+		getRiverReadingsWithStartDateStmt:                q.getRiverReadingsWithStartDateStmt,
+		getStationByIDStmt:                               q.getStationByIDStmt,
+		getStationByNameStmt:                             q.getStationByNameStmt,
+		getStationSummaryByNameStmt:                      q.getStationSummaryByNameStmt,
+		getStationsByNamesStmt:                           q.getStationsByNamesStmt,
+		hasPendingRainfallRollupsStmt:                    q.hasPendingRainfallRollupsStmt,
+		insertRainfallReadingStmt:                        q.insertRainfallReadingStmt,
+		insertRiverReadingStmt:                           q.insertRiverReadingStmt,
+		listStationSummariesStmt:                         q.listStationSummariesStmt,
+		listStationsStmt:                                 q.listStationsStmt,
+		markRainfallRollupsPendingStmt:                   q.markRainfallRollupsPendingStmt,
+		upsertRainfallReadingStmt:                        q.upsertRainfallReadingStmt,
+		upsertRiverReadingStmt:                           q.upsertRiverReadingStmt,
 	}
 }
@@ -14,9 +14,39 @@ type Rainfall struct {
 	Timestamp time.Time `db:"timestamp"`
 }
 
+type RainfallDaily struct {
+	Stationid   string    `db:"stationid"`
+	Day         time.Time `db:"day"`
+	Total       float64   `db:"total"`
+	Maximum     float64   `db:"maximum"`
+	Minimum     float64   `db:"minimum"`
+	SampleCount int64     `db:"sample_count"`
+}
+
+type RainfallHourly struct {
+	Stationid   string    `db:"stationid"`
+	Hour        time.Time `db:"hour"`
+	Total       float64   `db:"total"`
+	Maximum     float64   `db:"maximum"`
+	Minimum     float64   `db:"minimum"`
+	SampleCount int64     `db:"sample_count"`
+}
+
+type RainfallRollupPending struct {
+	Stationid string    `db:"stationid"`
+	Hour      time.Time `db:"hour"`
+}
+
+type Rivergauge struct {
+	ID    string `db:"id"`
+	Name  string `db:"name"`
+	River string `db:"river"`
+}
+
 type Riverlevel struct {
 	Level     float64   `db:"level"`
 	Timestamp time.Time `db:"timestamp"`
+	Gaugeid   string    `db:"gaugeid"`
 }
 
 type Stationname struct {
@@ -7,7 +7,10 @@ package gen
 
 import (
 	"context"
+	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 const countRainfallReadingsByStation = `-- name: CountRainfallReadingsByStation :one
@@ -23,6 +26,43 @@ func (q *Queries) CountRainfallReadingsByStation(ctx context.Context, stationid
 	return count, err
 }
 
+const countRainfallReadingsByStationInDateRange = `-- name: CountRainfallReadingsByStationInDateRange :one
+SELECT COUNT(*) FROM rainfalls
+WHERE stationid = $1 AND timestamp >= $2 AND timestamp < $3
+`
+
+type CountRainfallReadingsByStationInDateRangeParams struct {
+	Stationid string    `db:"stationid"`
+	StartDate time.Time `db:"start_date"`
+	EndDate   time.Time `db:"end_date"`
+}
+
+// Count rainfall readings for a station within a date range
+func (q *Queries) CountRainfallReadingsByStationInDateRange(ctx context.Context, arg CountRainfallReadingsByStationInDateRangeParams) (int64, error) {
+	row := q.queryRow(ctx, q.countRainfallReadingsByStationInDateRangeStmt, countRainfallReadingsByStationInDateRange, arg.Stationid, arg.StartDate, arg.EndDate)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRainfallReadingsByStationWithEndDate = `-- name: CountRainfallReadingsByStationWithEndDate :one
+SELECT COUNT(*) FROM rainfalls
+WHERE stationid = $1 AND timestamp < $2
+`
+
+type CountRainfallReadingsByStationWithEndDateParams struct {
+	Stationid string    `db:"stationid"`
+	Timestamp time.Time `db:"timestamp"`
+}
+
+// Count rainfall readings for a station before an end date
+func (q *Queries) CountRainfallReadingsByStationWithEndDate(ctx context.Context, arg CountRainfallReadingsByStationWithEndDateParams) (int64, error) {
+	row := q.queryRow(ctx, q.countRainfallReadingsByStationWithEndDateStmt, countRainfallReadingsByStationWithEndDate, arg.Stationid, arg.Timestamp)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countRainfallReadingsByStationWithStartDate = `-- name: CountRainfallReadingsByStationWithStartDate :one
 SELECT COUNT(*) FROM rainfalls
 WHERE stationid = $1 AND timestamp >= $2
@@ -41,6 +81,272 @@ func (q *Queries) CountRainfallReadingsByStationWithStartDate(ctx context.Contex
 	return count, err
 }
 
+const countRainfallReadingsByStations = `-- name: CountRainfallReadingsByStations :one
+SELECT COUNT(*) FROM rainfalls
+WHERE stationid = ANY($1::text[])
+  AND ($2::timestamp IS NULL OR timestamp >= $2)
+  AND ($3::timestamp IS NULL OR timestamp < $3)
+`
+
+type CountRainfallReadingsByStationsParams struct {
+	Stationids []string     `db:"stationids"`
+	StartDate  sql.NullTime `db:"start_date"`
+	EndDate    sql.NullTime `db:"end_date"`
+}
+
+// Count rainfall readings for several stations within optional date bounds
+func (q *Queries) CountRainfallReadingsByStations(ctx context.Context, arg CountRainfallReadingsByStationsParams) (int64, error) {
+	row := q.queryRow(ctx, q.countRainfallReadingsByStationsStmt, countRainfallReadingsByStations, pq.Array(arg.Stationids), arg.StartDate, arg.EndDate)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getLatestRainfallReadingByStation = `-- name: GetLatestRainfallReadingByStation :one
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = $1
+ORDER BY timestamp DESC
+LIMIT 1
+`
+
+type GetLatestRainfallReadingByStationRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Stationid string    `db:"stationid"`
+}
+
+// Get the most recent rainfall reading for a station
+func (q *Queries) GetLatestRainfallReadingByStation(ctx context.Context, stationid string) (GetLatestRainfallReadingByStationRow, error) {
+	row := q.queryRow(ctx, q.getLatestRainfallReadingByStationStmt, getLatestRainfallReadingByStation, stationid)
+	var i GetLatestRainfallReadingByStationRow
+	err := row.Scan(&i.Timestamp, &i.Level, &i.Stationid)
+	return i, err
+}
+
+const getLatestRainfallReadings = `-- name: GetLatestRainfallReadings :many
+SELECT s.name, latest.timestamp, latest.level
+FROM stationnames s
+CROSS JOIN LATERAL (
+    SELECT r.timestamp, r.level
+    FROM rainfalls r
+    WHERE r.stationid = s.id
+    ORDER BY r.timestamp DESC
+    LIMIT 1
+) latest
+ORDER BY s.name ASC
+`
+
+type GetLatestRainfallReadingsRow struct {
+	Name      string    `db:"name"`
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+}
+
+// Get the most recent rainfall reading of every station, seeking each station's index separately
+func (q *Queries) GetLatestRainfallReadings(ctx context.Context) ([]GetLatestRainfallReadingsRow, error) {
+	rows, err := q.query(ctx, q.getLatestRainfallReadingsStmt, getLatestRainfallReadings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetLatestRainfallReadingsRow{}
+	for rows.Next() {
+		var i GetLatestRainfallReadingsRow
+		if err := rows.Scan(&i.Name, &i.Timestamp, &i.Level); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRainfallAggregatesByStation = `-- name: GetRainfallAggregatesByStation :many
+SELECT date_trunc($1::text, timestamp)::timestamp AS bucket,
+       SUM(level)::double precision AS total,
+       AVG(level)::double precision AS mean,
+       MAX(level)::double precision AS maximum,
+       MIN(level)::double precision AS minimum,
+       COUNT(*) AS sample_count
+FROM rainfalls
+WHERE stationid = $2
+  AND ($3::timestamp IS NULL OR timestamp >= $3)
+  AND ($4::timestamp IS NULL OR timestamp < $4)
+GROUP BY bucket
+ORDER BY bucket ASC
+`
+
+type GetRainfallAggregatesByStationParams struct {
+	Interval  string       `db:"interval"`
+	Stationid string       `db:"stationid"`
+	StartDate sql.NullTime `db:"start_date"`
+	EndDate   sql.NullTime `db:"end_date"`
+}
+
+type GetRainfallAggregatesByStationRow struct {
+	Bucket      time.Time `db:"bucket"`
+	Total       float64   `db:"total"`
+	Mean        float64   `db:"mean"`
+	Maximum     float64   `db:"maximum"`
+	Minimum     float64   `db:"minimum"`
+	SampleCount int64     `db:"sample_count"`
+}
+
+// Get rainfall summary statistics for a station per time bucket within optional date bounds
+func (q *Queries) GetRainfallAggregatesByStation(ctx context.Context, arg GetRainfallAggregatesByStationParams) ([]GetRainfallAggregatesByStationRow, error) {
+	rows, err := q.query(ctx, q.getRainfallAggregatesByStationStmt, getRainfallAggregatesByStation,
+		arg.Interval,
+		arg.Stationid,
+		arg.StartDate,
+		arg.EndDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRainfallAggregatesByStationRow{}
+	for rows.Next() {
+		var i GetRainfallAggregatesByStationRow
+		if err := rows.Scan(&i.Bucket, &i.Total, &i.Mean, &i.Maximum, &i.Minimum, &i.SampleCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRainfallDailyAggregatesByStation = `-- name: GetRainfallDailyAggregatesByStation :many
+SELECT date_trunc($1::text, day)::timestamp AS bucket,
+       SUM(total)::double precision AS total,
+       (SUM(total) / SUM(sample_count))::double precision AS mean,
+       MAX(maximum)::double precision AS maximum,
+       MIN(minimum)::double precision AS minimum,
+       SUM(sample_count)::bigint AS sample_count
+FROM rainfall_daily
+WHERE stationid = $2
+  AND ($3::timestamp IS NULL OR day >= $3)
+  AND ($4::timestamp IS NULL OR day < $4)
+GROUP BY bucket
+ORDER BY bucket ASC
+`
+
+type GetRainfallDailyAggregatesByStationParams struct {
+	Interval  string       `db:"interval"`
+	Stationid string       `db:"stationid"`
+	StartDate sql.NullTime `db:"start_date"`
+	EndDate   sql.NullTime `db:"end_date"`
+}
+
+type GetRainfallDailyAggregatesByStationRow struct {
+	Bucket      time.Time `db:"bucket"`
+	Total       float64   `db:"total"`
+	Mean        float64   `db:"mean"`
+	Maximum     float64   `db:"maximum"`
+	Minimum     float64   `db:"minimum"`
+	SampleCount int64     `db:"sample_count"`
+}
+
+// Get rainfall summary statistics for a station per time bucket from the daily rollup within optional day aligned bounds
+func (q *Queries) GetRainfallDailyAggregatesByStation(ctx context.Context, arg GetRainfallDailyAggregatesByStationParams) ([]GetRainfallDailyAggregatesByStationRow, error) {
+	rows, err := q.query(ctx, q.getRainfallDailyAggregatesByStationStmt, getRainfallDailyAggregatesByStation,
+		arg.Interval,
+		arg.Stationid,
+		arg.StartDate,
+		arg.EndDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRainfallDailyAggregatesByStationRow{}
+	for rows.Next() {
+		var i GetRainfallDailyAggregatesByStationRow
+		if err := rows.Scan(&i.Bucket, &i.Total, &i.Mean, &i.Maximum, &i.Minimum, &i.SampleCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRainfallHourlyAggregatesByStation = `-- name: GetRainfallHourlyAggregatesByStation :many
+SELECT date_trunc($1::text, hour)::timestamp AS bucket,
+       SUM(total)::double precision AS total,
+       (SUM(total) / SUM(sample_count))::double precision AS mean,
+       MAX(maximum)::double precision AS maximum,
+       MIN(minimum)::double precision AS minimum,
+       SUM(sample_count)::bigint AS sample_count
+FROM rainfall_hourly
+WHERE stationid = $2
+  AND ($3::timestamp IS NULL OR hour >= $3)
+  AND ($4::timestamp IS NULL OR hour < $4)
+GROUP BY bucket
+ORDER BY bucket ASC
+`
+
+type GetRainfallHourlyAggregatesByStationParams struct {
+	Interval  string       `db:"interval"`
+	Stationid string       `db:"stationid"`
+	StartDate sql.NullTime `db:"start_date"`
+	EndDate   sql.NullTime `db:"end_date"`
+}
+
+type GetRainfallHourlyAggregatesByStationRow struct {
+	Bucket      time.Time `db:"bucket"`
+	Total       float64   `db:"total"`
+	Mean        float64   `db:"mean"`
+	Maximum     float64   `db:"maximum"`
+	Minimum     float64   `db:"minimum"`
+	SampleCount int64     `db:"sample_count"`
+}
+
+// Get rainfall summary statistics for a station per time bucket from the hourly rollup within optional hour aligned bounds
+func (q *Queries) GetRainfallHourlyAggregatesByStation(ctx context.Context, arg GetRainfallHourlyAggregatesByStationParams) ([]GetRainfallHourlyAggregatesByStationRow, error) {
+	rows, err := q.query(ctx, q.getRainfallHourlyAggregatesByStationStmt, getRainfallHourlyAggregatesByStation,
+		arg.Interval,
+		arg.Stationid,
+		arg.StartDate,
+		arg.EndDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRainfallHourlyAggregatesByStationRow{}
+	for rows.Next() {
+		var i GetRainfallHourlyAggregatesByStationRow
+		if err := rows.Scan(&i.Bucket, &i.Total, &i.Mean, &i.Maximum, &i.Minimum, &i.SampleCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRainfallReadingsByStation = `-- name: GetRainfallReadingsByStation :many
 SELECT timestamp, level, stationid
 FROM rainfalls
@@ -85,6 +391,212 @@ func (q *Queries) GetRainfallReadingsByStation(ctx context.Context, arg GetRainf
 	return items, nil
 }
 
+const getRainfallReadingsByStationAfter = `-- name: GetRainfallReadingsByStationAfter :many
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = $1 AND timestamp > $2
+  AND ($3::timestamp IS NULL OR timestamp >= $3)
+ORDER BY timestamp ASC
+LIMIT $4
+`
+
+type GetRainfallReadingsByStationAfterParams struct {
+	Stationid string       `db:"stationid"`
+	After     time.Time    `db:"after"`
+	StartDate sql.NullTime `db:"start_date"`
+	Limit     int32        `db:"limit"`
+}
+
+type GetRainfallReadingsByStationAfterRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Stationid string    `db:"stationid"`
+}
+
+// Get the next page of rainfall readings for a station after a keyset cursor timestamp and from an optional start date
+func (q *Queries) GetRainfallReadingsByStationAfter(ctx context.Context, arg GetRainfallReadingsByStationAfterParams) ([]GetRainfallReadingsByStationAfterRow, error) {
+	rows, err := q.query(ctx, q.getRainfallReadingsByStationAfterStmt, getRainfallReadingsByStationAfter,
+		arg.Stationid,
+		arg.After,
+		arg.StartDate,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRainfallReadingsByStationAfterRow{}
+	for rows.Next() {
+		var i GetRainfallReadingsByStationAfterRow
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Stationid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRainfallReadingsByStationAfterWithEndDate = `-- name: GetRainfallReadingsByStationAfterWithEndDate :many
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = $1 AND timestamp > $2 AND timestamp < $3
+  AND ($4::timestamp IS NULL OR timestamp >= $4)
+ORDER BY timestamp ASC
+LIMIT $5
+`
+
+type GetRainfallReadingsByStationAfterWithEndDateParams struct {
+	Stationid string       `db:"stationid"`
+	After     time.Time    `db:"after"`
+	EndDate   time.Time    `db:"end_date"`
+	StartDate sql.NullTime `db:"start_date"`
+	Limit     int32        `db:"limit"`
+}
+
+type GetRainfallReadingsByStationAfterWithEndDateRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Stationid string    `db:"stationid"`
+}
+
+// Get the next page of rainfall readings for a station after a keyset cursor timestamp and from an optional start date before an end date
+func (q *Queries) GetRainfallReadingsByStationAfterWithEndDate(ctx context.Context, arg GetRainfallReadingsByStationAfterWithEndDateParams) ([]GetRainfallReadingsByStationAfterWithEndDateRow, error) {
+	rows, err := q.query(ctx, q.getRainfallReadingsByStationAfterWithEndDateStmt, getRainfallReadingsByStationAfterWithEndDate,
+		arg.Stationid,
+		arg.After,
+		arg.EndDate,
+		arg.StartDate,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRainfallReadingsByStationAfterWithEndDateRow{}
+	for rows.Next() {
+		var i GetRainfallReadingsByStationAfterWithEndDateRow
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Stationid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRainfallReadingsByStationInDateRange = `-- name: GetRainfallReadingsByStationInDateRange :many
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = $1 AND timestamp >= $2 AND timestamp < $3
+ORDER BY timestamp ASC
+LIMIT $4 OFFSET $5
+`
+
+type GetRainfallReadingsByStationInDateRangeParams struct {
+	Stationid string    `db:"stationid"`
+	StartDate time.Time `db:"start_date"`
+	EndDate   time.Time `db:"end_date"`
+	Limit     int32     `db:"limit"`
+	Offset    int32     `db:"offset"`
+}
+
+type GetRainfallReadingsByStationInDateRangeRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Stationid string    `db:"stationid"`
+}
+
+// Get rainfall readings for a station within a date range sorted in chronological order with pagination
+func (q *Queries) GetRainfallReadingsByStationInDateRange(ctx context.Context, arg GetRainfallReadingsByStationInDateRangeParams) ([]GetRainfallReadingsByStationInDateRangeRow, error) {
+	rows, err := q.query(ctx, q.getRainfallReadingsByStationInDateRangeStmt, getRainfallReadingsByStationInDateRange,
+		arg.Stationid,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRainfallReadingsByStationInDateRangeRow{}
+	for rows.Next() {
+		var i GetRainfallReadingsByStationInDateRangeRow
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Stationid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRainfallReadingsByStationWithEndDate = `-- name: GetRainfallReadingsByStationWithEndDate :many
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = $1 AND timestamp < $2
+ORDER BY timestamp ASC
+LIMIT $3 OFFSET $4
+`
+
+type GetRainfallReadingsByStationWithEndDateParams struct {
+	Stationid string    `db:"stationid"`
+	Timestamp time.Time `db:"timestamp"`
+	Limit     int32     `db:"limit"`
+	Offset    int32     `db:"offset"`
+}
+
+type GetRainfallReadingsByStationWithEndDateRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Stationid string    `db:"stationid"`
+}
+
+// Get rainfall readings for a station before an end date sorted in chronological order with pagination
+func (q *Queries) GetRainfallReadingsByStationWithEndDate(ctx context.Context, arg GetRainfallReadingsByStationWithEndDateParams) ([]GetRainfallReadingsByStationWithEndDateRow, error) {
+	rows, err := q.query(ctx, q.getRainfallReadingsByStationWithEndDateStmt, getRainfallReadingsByStationWithEndDate,
+		arg.Stationid,
+		arg.Timestamp,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRainfallReadingsByStationWithEndDateRow{}
+	for rows.Next() {
+		var i GetRainfallReadingsByStationWithEndDateRow
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Stationid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRainfallReadingsByStationWithStartDate = `-- name: GetRainfallReadingsByStationWithStartDate :many
 SELECT timestamp, level, stationid
 FROM rainfalls
@@ -135,6 +647,60 @@ func (q *Queries) GetRainfallReadingsByStationWithStartDate(ctx context.Context,
 	return items, nil
 }
 
+const getRainfallReadingsByStations = `-- name: GetRainfallReadingsByStations :many
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = ANY($1::text[])
+  AND ($2::timestamp IS NULL OR timestamp >= $2)
+  AND ($3::timestamp IS NULL OR timestamp < $3)
+ORDER BY timestamp ASC, stationid ASC
+LIMIT $4 OFFSET $5
+`
+
+type GetRainfallReadingsByStationsParams struct {
+	Stationids []string     `db:"stationids"`
+	StartDate  sql.NullTime `db:"start_date"`
+	EndDate    sql.NullTime `db:"end_date"`
+	Limit      int32        `db:"limit"`
+	Offset     int32        `db:"offset"`
+}
+
+type GetRainfallReadingsByStationsRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Stationid string    `db:"stationid"`
+}
+
+// Get rainfall readings for several stations merged in chronological order within optional date bounds with pagination
+func (q *Queries) GetRainfallReadingsByStations(ctx context.Context, arg GetRainfallReadingsByStationsParams) ([]GetRainfallReadingsByStationsRow, error) {
+	rows, err := q.query(ctx, q.getRainfallReadingsByStationsStmt, getRainfallReadingsByStations,
+		pq.Array(arg.Stationids),
+		arg.StartDate,
+		arg.EndDate,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRainfallReadingsByStationsRow{}
+	for rows.Next() {
+		var i GetRainfallReadingsByStationsRow
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Stationid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getStationByID = `-- name: GetStationByID :one
 SELECT id, name FROM stationnames
 WHERE id = $1
@@ -160,3 +726,121 @@ func (q *Queries) GetStationByName(ctx context.Context, name string) (Stationnam
 	err := row.Scan(&i.ID, &i.Name)
 	return i, err
 }
+
+const getStationsByNames = `-- name: GetStationsByNames :many
+SELECT id, name FROM stationnames
+WHERE name = ANY($1::text[])
+ORDER BY name ASC
+`
+
+// Get station information for several names at once, skipping any that don't exist
+func (q *Queries) GetStationsByNames(ctx context.Context, names []string) ([]Stationname, error) {
+	rows, err := q.query(ctx, q.getStationsByNamesStmt, getStationsByNames, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Stationname{}
+	for rows.Next() {
+		var i Stationname
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasPendingRainfallRollups = `-- name: HasPendingRainfallRollups :one
+SELECT EXISTS (
+    SELECT 1
+    FROM rainfall_rollup_pending
+    WHERE stationid = $1
+      AND ($2::timestamp IS NULL OR hour >= date_trunc('hour', $2::timestamp))
+      AND ($3::timestamp IS NULL OR hour < $3)
+) AS pending
+`
+
+type HasPendingRainfallRollupsParams struct {
+	Stationid string       `db:"stationid"`
+	StartDate sql.NullTime `db:"start_date"`
+	EndDate   sql.NullTime `db:"end_date"`
+}
+
+// Check whether a station has hours within optional date bounds whose rollups haven't been refreshed since they were written
+func (q *Queries) HasPendingRainfallRollups(ctx context.Context, arg HasPendingRainfallRollupsParams) (bool, error) {
+	row := q.queryRow(ctx, q.hasPendingRainfallRollupsStmt, hasPendingRainfallRollups, arg.Stationid, arg.StartDate, arg.EndDate)
+	var pending bool
+	err := row.Scan(&pending)
+	return pending, err
+}
+
+const listStations = `-- name: ListStations :many
+SELECT id, name FROM stationnames
+ORDER BY name ASC
+`
+
+// Get every station
+func (q *Queries) ListStations(ctx context.Context) ([]Stationname, error) {
+	rows, err := q.query(ctx, q.listStationsStmt, listStations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Stationname{}
+	for rows.Next() {
+		var i Stationname
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markRainfallRollupsPending = `-- name: MarkRainfallRollupsPending :exec
+INSERT INTO rainfall_rollup_pending (stationid, hour)
+SELECT $1::text, unnest($2::timestamp[])
+ON CONFLICT DO NOTHING
+`
+
+type MarkRainfallRollupsPendingParams struct {
+	Stationid string      `db:"stationid"`
+	Hours     []time.Time `db:"hours"`
+}
+
+// Record the hours a station's readings were written to so the next refresh recomputes their rollups
+func (q *Queries) MarkRainfallRollupsPending(ctx context.Context, arg MarkRainfallRollupsPendingParams) error {
+	_, err := q.exec(ctx, q.markRainfallRollupsPendingStmt, markRainfallRollupsPending, arg.Stationid, pq.Array(arg.Hours))
+	return err
+}
+
+const upsertRainfallReading = `-- name: UpsertRainfallReading :exec
+INSERT INTO rainfalls (stationid, timestamp, level)
+VALUES ($1, $2, $3)
+ON CONFLICT (stationid, timestamp) DO UPDATE SET level = EXCLUDED.level
+`
+
+type UpsertRainfallReadingParams struct {
+	Stationid string    `db:"stationid"`
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+}
+
+// Insert a rainfall reading for a station, replacing the level of one already stored at that timestamp
+func (q *Queries) UpsertRainfallReading(ctx context.Context, arg UpsertRainfallReadingParams) error {
+	_, err := q.exec(ctx, q.upsertRainfallReadingStmt, upsertRainfallReading, arg.Stationid, arg.Timestamp, arg.Level)
+	return err
+}
@@ -7,16 +7,55 @@ package gen
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
 const countRiverReadings = `-- name: CountRiverReadings :one
 SELECT COUNT(*) FROM riverlevels
+WHERE gaugeid = $1
 `
 
-// Count total river level readings
-func (q *Queries) CountRiverReadings(ctx context.Context) (int64, error) {
-	row := q.queryRow(ctx, q.countRiverReadingsStmt, countRiverReadings)
+// Count river level readings for a gauge
+func (q *Queries) CountRiverReadings(ctx context.Context, gaugeid string) (int64, error) {
+	row := q.queryRow(ctx, q.countRiverReadingsStmt, countRiverReadings, gaugeid)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRiverReadingsInDateRange = `-- name: CountRiverReadingsInDateRange :one
+SELECT COUNT(*) FROM riverlevels
+WHERE gaugeid = $1 AND timestamp >= $2 AND timestamp < $3
+`
+
+type CountRiverReadingsInDateRangeParams struct {
+	Gaugeid   string    `db:"gaugeid"`
+	StartDate time.Time `db:"start_date"`
+	EndDate   time.Time `db:"end_date"`
+}
+
+// Count river level readings for a gauge within a date range
+func (q *Queries) CountRiverReadingsInDateRange(ctx context.Context, arg CountRiverReadingsInDateRangeParams) (int64, error) {
+	row := q.queryRow(ctx, q.countRiverReadingsInDateRangeStmt, countRiverReadingsInDateRange, arg.Gaugeid, arg.StartDate, arg.EndDate)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRiverReadingsWithEndDate = `-- name: CountRiverReadingsWithEndDate :one
+SELECT COUNT(*) FROM riverlevels
+WHERE gaugeid = $1 AND timestamp < $2
+`
+
+type CountRiverReadingsWithEndDateParams struct {
+	Gaugeid   string    `db:"gaugeid"`
+	Timestamp time.Time `db:"timestamp"`
+}
+
+// Count river level readings for a gauge before an end date
+func (q *Queries) CountRiverReadingsWithEndDate(ctx context.Context, arg CountRiverReadingsWithEndDateParams) (int64, error) {
+	row := q.queryRow(ctx, q.countRiverReadingsWithEndDateStmt, countRiverReadingsWithEndDate, arg.Gaugeid, arg.Timestamp)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -24,37 +63,140 @@ func (q *Queries) CountRiverReadings(ctx context.Context) (int64, error) {
 
 const countRiverReadingsWithStartDate = `-- name: CountRiverReadingsWithStartDate :one
 SELECT COUNT(*) FROM riverlevels
-WHERE timestamp >= $1
+WHERE gaugeid = $1 AND timestamp >= $2
 `
 
-// Count river level readings from a start date
-func (q *Queries) CountRiverReadingsWithStartDate(ctx context.Context, timestamp time.Time) (int64, error) {
-	row := q.queryRow(ctx, q.countRiverReadingsWithStartDateStmt, countRiverReadingsWithStartDate, timestamp)
+type CountRiverReadingsWithStartDateParams struct {
+	Gaugeid   string    `db:"gaugeid"`
+	Timestamp time.Time `db:"timestamp"`
+}
+
+// Count river level readings for a gauge from a start date
+func (q *Queries) CountRiverReadingsWithStartDate(ctx context.Context, arg CountRiverReadingsWithStartDateParams) (int64, error) {
+	row := q.queryRow(ctx, q.countRiverReadingsWithStartDateStmt, countRiverReadingsWithStartDate, arg.Gaugeid, arg.Timestamp)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
+const getGaugeByName = `-- name: GetGaugeByName :one
+SELECT id, name, river FROM rivergauges
+WHERE name = $1
+`
+
+// Get river gauge information by name for API lookups
+func (q *Queries) GetGaugeByName(ctx context.Context, name string) (Rivergauge, error) {
+	row := q.queryRow(ctx, q.getGaugeByNameStmt, getGaugeByName, name)
+	var i Rivergauge
+	err := row.Scan(&i.ID, &i.Name, &i.River)
+	return i, err
+}
+
+const getLatestRiverReading = `-- name: GetLatestRiverReading :one
+SELECT timestamp, level, gaugeid
+FROM riverlevels
+WHERE gaugeid = $1
+ORDER BY timestamp DESC
+LIMIT 1
+`
+
+type GetLatestRiverReadingRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Gaugeid   string    `db:"gaugeid"`
+}
+
+// Get the most recent river level reading for a gauge
+func (q *Queries) GetLatestRiverReading(ctx context.Context, gaugeid string) (GetLatestRiverReadingRow, error) {
+	row := q.queryRow(ctx, q.getLatestRiverReadingStmt, getLatestRiverReading, gaugeid)
+	var i GetLatestRiverReadingRow
+	err := row.Scan(&i.Timestamp, &i.Level, &i.Gaugeid)
+	return i, err
+}
+
+const getRiverAggregates = `-- name: GetRiverAggregates :many
+SELECT date_trunc($1::text, timestamp)::timestamp AS bucket,
+       SUM(level)::double precision AS total,
+       AVG(level)::double precision AS mean,
+       MAX(level)::double precision AS maximum,
+       MIN(level)::double precision AS minimum,
+       COUNT(*) AS sample_count
+FROM riverlevels
+WHERE gaugeid = $2
+  AND ($3::timestamp IS NULL OR timestamp >= $3)
+  AND ($4::timestamp IS NULL OR timestamp < $4)
+GROUP BY bucket
+ORDER BY bucket ASC
+`
+
+type GetRiverAggregatesParams struct {
+	Interval  string       `db:"interval"`
+	Gaugeid   string       `db:"gaugeid"`
+	StartDate sql.NullTime `db:"start_date"`
+	EndDate   sql.NullTime `db:"end_date"`
+}
+
+type GetRiverAggregatesRow struct {
+	Bucket      time.Time `db:"bucket"`
+	Total       float64   `db:"total"`
+	Mean        float64   `db:"mean"`
+	Maximum     float64   `db:"maximum"`
+	Minimum     float64   `db:"minimum"`
+	SampleCount int64     `db:"sample_count"`
+}
+
+// Get river level summary statistics for a gauge per time bucket within optional date bounds
+func (q *Queries) GetRiverAggregates(ctx context.Context, arg GetRiverAggregatesParams) ([]GetRiverAggregatesRow, error) {
+	rows, err := q.query(ctx, q.getRiverAggregatesStmt, getRiverAggregates,
+		arg.Interval,
+		arg.Gaugeid,
+		arg.StartDate,
+		arg.EndDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRiverAggregatesRow{}
+	for rows.Next() {
+		var i GetRiverAggregatesRow
+		if err := rows.Scan(&i.Bucket, &i.Total, &i.Mean, &i.Maximum, &i.Minimum, &i.SampleCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRiverReadings = `-- name: GetRiverReadings :many
-SELECT timestamp, level
+SELECT timestamp, level, gaugeid
 FROM riverlevels
+WHERE gaugeid = $1
 ORDER BY timestamp ASC
-LIMIT $1 OFFSET $2
+LIMIT $2 OFFSET $3
 `
 
 type GetRiverReadingsParams struct {
-	Limit  int32 `db:"limit"`
-	Offset int32 `db:"offset"`
+	Gaugeid string `db:"gaugeid"`
+	Limit   int32  `db:"limit"`
+	Offset  int32  `db:"offset"`
 }
 
 type GetRiverReadingsRow struct {
 	Timestamp time.Time `db:"timestamp"`
 	Level     float64   `db:"level"`
+	Gaugeid   string    `db:"gaugeid"`
 }
 
-// Get river level readings sorted in chronological order with pagination
+// Get river level readings for a gauge sorted in chronological order with pagination
 func (q *Queries) GetRiverReadings(ctx context.Context, arg GetRiverReadingsParams) ([]GetRiverReadingsRow, error) {
-	rows, err := q.query(ctx, q.getRiverReadingsStmt, getRiverReadings, arg.Limit, arg.Offset)
+	rows, err := q.query(ctx, q.getRiverReadingsStmt, getRiverReadings, arg.Gaugeid, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +204,213 @@ func (q *Queries) GetRiverReadings(ctx context.Context, arg GetRiverReadingsPara
 	items := []GetRiverReadingsRow{}
 	for rows.Next() {
 		var i GetRiverReadingsRow
-		if err := rows.Scan(&i.Timestamp, &i.Level); err != nil {
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Gaugeid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRiverReadingsAfter = `-- name: GetRiverReadingsAfter :many
+SELECT timestamp, level, gaugeid
+FROM riverlevels
+WHERE gaugeid = $1 AND timestamp > $2
+  AND ($3::timestamp IS NULL OR timestamp >= $3)
+ORDER BY timestamp ASC
+LIMIT $4
+`
+
+type GetRiverReadingsAfterParams struct {
+	Gaugeid   string       `db:"gaugeid"`
+	After     time.Time    `db:"after"`
+	StartDate sql.NullTime `db:"start_date"`
+	Limit     int32        `db:"limit"`
+}
+
+type GetRiverReadingsAfterRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Gaugeid   string    `db:"gaugeid"`
+}
+
+// Get the next page of river level readings for a gauge after a keyset cursor timestamp and from an optional start date
+func (q *Queries) GetRiverReadingsAfter(ctx context.Context, arg GetRiverReadingsAfterParams) ([]GetRiverReadingsAfterRow, error) {
+	rows, err := q.query(ctx, q.getRiverReadingsAfterStmt, getRiverReadingsAfter,
+		arg.Gaugeid,
+		arg.After,
+		arg.StartDate,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRiverReadingsAfterRow{}
+	for rows.Next() {
+		var i GetRiverReadingsAfterRow
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Gaugeid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRiverReadingsAfterWithEndDate = `-- name: GetRiverReadingsAfterWithEndDate :many
+SELECT timestamp, level, gaugeid
+FROM riverlevels
+WHERE gaugeid = $1 AND timestamp > $2 AND timestamp < $3
+  AND ($4::timestamp IS NULL OR timestamp >= $4)
+ORDER BY timestamp ASC
+LIMIT $5
+`
+
+type GetRiverReadingsAfterWithEndDateParams struct {
+	Gaugeid   string       `db:"gaugeid"`
+	After     time.Time    `db:"after"`
+	EndDate   time.Time    `db:"end_date"`
+	StartDate sql.NullTime `db:"start_date"`
+	Limit     int32        `db:"limit"`
+}
+
+type GetRiverReadingsAfterWithEndDateRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Gaugeid   string    `db:"gaugeid"`
+}
+
+// Get the next page of river level readings for a gauge after a keyset cursor timestamp and from an optional start date before an end date
+func (q *Queries) GetRiverReadingsAfterWithEndDate(ctx context.Context, arg GetRiverReadingsAfterWithEndDateParams) ([]GetRiverReadingsAfterWithEndDateRow, error) {
+	rows, err := q.query(ctx, q.getRiverReadingsAfterWithEndDateStmt, getRiverReadingsAfterWithEndDate,
+		arg.Gaugeid,
+		arg.After,
+		arg.EndDate,
+		arg.StartDate,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRiverReadingsAfterWithEndDateRow{}
+	for rows.Next() {
+		var i GetRiverReadingsAfterWithEndDateRow
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Gaugeid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRiverReadingsInDateRange = `-- name: GetRiverReadingsInDateRange :many
+SELECT timestamp, level, gaugeid
+FROM riverlevels
+WHERE gaugeid = $1 AND timestamp >= $2 AND timestamp < $3
+ORDER BY timestamp ASC
+LIMIT $4 OFFSET $5
+`
+
+type GetRiverReadingsInDateRangeParams struct {
+	Gaugeid   string    `db:"gaugeid"`
+	StartDate time.Time `db:"start_date"`
+	EndDate   time.Time `db:"end_date"`
+	Limit     int32     `db:"limit"`
+	Offset    int32     `db:"offset"`
+}
+
+type GetRiverReadingsInDateRangeRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Gaugeid   string    `db:"gaugeid"`
+}
+
+// Get river level readings for a gauge within a date range sorted in chronological order with pagination
+func (q *Queries) GetRiverReadingsInDateRange(ctx context.Context, arg GetRiverReadingsInDateRangeParams) ([]GetRiverReadingsInDateRangeRow, error) {
+	rows, err := q.query(ctx, q.getRiverReadingsInDateRangeStmt, getRiverReadingsInDateRange,
+		arg.Gaugeid,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRiverReadingsInDateRangeRow{}
+	for rows.Next() {
+		var i GetRiverReadingsInDateRangeRow
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Gaugeid); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRiverReadingsWithEndDate = `-- name: GetRiverReadingsWithEndDate :many
+SELECT timestamp, level, gaugeid
+FROM riverlevels
+WHERE gaugeid = $1 AND timestamp < $2
+ORDER BY timestamp ASC
+LIMIT $3 OFFSET $4
+`
+
+type GetRiverReadingsWithEndDateParams struct {
+	Gaugeid   string    `db:"gaugeid"`
+	Timestamp time.Time `db:"timestamp"`
+	Limit     int32     `db:"limit"`
+	Offset    int32     `db:"offset"`
+}
+
+type GetRiverReadingsWithEndDateRow struct {
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+	Gaugeid   string    `db:"gaugeid"`
+}
+
+// Get river level readings for a gauge before an end date sorted in chronological order with pagination
+func (q *Queries) GetRiverReadingsWithEndDate(ctx context.Context, arg GetRiverReadingsWithEndDateParams) ([]GetRiverReadingsWithEndDateRow, error) {
+	rows, err := q.query(ctx, q.getRiverReadingsWithEndDateStmt, getRiverReadingsWithEndDate,
+		arg.Gaugeid,
+		arg.Timestamp,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetRiverReadingsWithEndDateRow{}
+	for rows.Next() {
+		var i GetRiverReadingsWithEndDateRow
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Gaugeid); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -77,14 +425,15 @@ func (q *Queries) GetRiverReadings(ctx context.Context, arg GetRiverReadingsPara
 }
 
 const getRiverReadingsWithStartDate = `-- name: GetRiverReadingsWithStartDate :many
-SELECT timestamp, level
+SELECT timestamp, level, gaugeid
 FROM riverlevels
-WHERE timestamp >= $1
+WHERE gaugeid = $1 AND timestamp >= $2
 ORDER BY timestamp ASC
-LIMIT $2 OFFSET $3
+LIMIT $3 OFFSET $4
 `
 
 type GetRiverReadingsWithStartDateParams struct {
+	Gaugeid   string    `db:"gaugeid"`
 	Timestamp time.Time `db:"timestamp"`
 	Limit     int32     `db:"limit"`
 	Offset    int32     `db:"offset"`
@@ -93,11 +442,17 @@ type GetRiverReadingsWithStartDateParams struct {
 type GetRiverReadingsWithStartDateRow struct {
 	Timestamp time.Time `db:"timestamp"`
 	Level     float64   `db:"level"`
+	Gaugeid   string    `db:"gaugeid"`
 }
 
-// Get river level readings from a start date sorted in chronological order with pagination
+// Get river level readings for a gauge from a start date sorted in chronological order with pagination
 func (q *Queries) GetRiverReadingsWithStartDate(ctx context.Context, arg GetRiverReadingsWithStartDateParams) ([]GetRiverReadingsWithStartDateRow, error) {
-	rows, err := q.query(ctx, q.getRiverReadingsWithStartDateStmt, getRiverReadingsWithStartDate, arg.Timestamp, arg.Limit, arg.Offset)
+	rows, err := q.query(ctx, q.getRiverReadingsWithStartDateStmt, getRiverReadingsWithStartDate,
+		arg.Gaugeid,
+		arg.Timestamp,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +460,7 @@ func (q *Queries) GetRiverReadingsWithStartDate(ctx context.Context, arg GetRive
 	items := []GetRiverReadingsWithStartDateRow{}
 	for rows.Next() {
 		var i GetRiverReadingsWithStartDateRow
-		if err := rows.Scan(&i.Timestamp, &i.Level); err != nil {
+		if err := rows.Scan(&i.Timestamp, &i.Level, &i.Gaugeid); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -118,3 +473,21 @@ func (q *Queries) GetRiverReadingsWithStartDate(ctx context.Context, arg GetRive
 	}
 	return items, nil
 }
+
+const upsertRiverReading = `-- name: UpsertRiverReading :exec
+INSERT INTO riverlevels (gaugeid, timestamp, level)
+VALUES ($1, $2, $3)
+ON CONFLICT (gaugeid, timestamp) DO UPDATE SET level = EXCLUDED.level
+`
+
+type UpsertRiverReadingParams struct {
+	Gaugeid   string    `db:"gaugeid"`
+	Timestamp time.Time `db:"timestamp"`
+	Level     float64   `db:"level"`
+}
+
+// Insert a river level reading for a gauge, replacing the level of one already stored at that timestamp
+func (q *Queries) UpsertRiverReading(ctx context.Context, arg UpsertRiverReadingParams) error {
+	_, err := q.exec(ctx, q.upsertRiverReadingStmt, upsertRiverReading, arg.Gaugeid, arg.Timestamp, arg.Level)
+	return err
+}
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+	"github.com/oliverslade/flood-api/internal/repository/postgres/gen"
+)
+
+// Imports COPY into a staging table and merge from there, as COPY itself can't skip rows that already exist.
+// The merges are plain SQL rather than sqlc as they read the temporary staging table.
+const (
+	mergeStationsQuery = `INSERT INTO stationnames (id, name)
+SELECT DISTINCT ON (id) id, name
+FROM import_staging s
+WHERE NOT EXISTS (SELECT 1 FROM stationnames n WHERE n.id = s.id)
+ORDER BY id`
+
+	countUnknownStationsQuery = `SELECT COUNT(*)
+FROM import_staging s
+WHERE NOT EXISTS (SELECT 1 FROM stationnames n WHERE n.id = s.stationid)`
+
+	mergeRainfallQuery = `INSERT INTO rainfalls (stationid, timestamp, level)
+SELECT DISTINCT ON (stationid, timestamp) stationid, timestamp, level
+FROM import_staging s
+WHERE EXISTS (SELECT 1 FROM stationnames n WHERE n.id = s.stationid)
+ORDER BY stationid, timestamp
+ON CONFLICT (stationid, timestamp) DO NOTHING`
+
+	markStagedRollupsPendingQuery = `INSERT INTO rainfall_rollup_pending (stationid, hour)
+SELECT DISTINCT stationid, date_trunc('hour', timestamp)
+FROM import_staging s
+WHERE EXISTS (SELECT 1 FROM stationnames n WHERE n.id = s.stationid)
+ON CONFLICT DO NOTHING`
+
+	mergeRiverLevelsQuery = `INSERT INTO riverlevels (gaugeid, timestamp, level)
+SELECT DISTINCT ON (gaugeid, timestamp) gaugeid, timestamp, level
+FROM import_staging
+ORDER BY gaugeid, timestamp
+ON CONFLICT (gaugeid, timestamp) DO NOTHING`
+)
+
+type ImportRepo struct {
+	db         *sql.DB
+	queries    *gen.Queries
+	partitions *partitions
+}
+
+func NewImportRepo(db *sql.DB) repository.ImportRepository {
+	return &ImportRepo{
+		db:         db,
+		queries:    newQueries(db),
+		partitions: newPartitions(db),
+	}
+}
+
+// ImportStations adds stations whose id isn't already stored
+func (r *ImportRepo) ImportStations(ctx context.Context, load func(add func(domain.Station) error) error) (domain.ImportResult, error) {
+	var result domain.ImportResult
+	err := bulkLoad(ctx, r.db, "stationnames", []string{"id", "name"},
+		func(add func(...interface{}) error) error {
+			return load(func(s domain.Station) error {
+				return add(s.ID, s.Name)
+			})
+		},
+		func(tx *sql.Tx, copied int64) error {
+			imported, err := execRows(ctx, tx, mergeStationsQuery)
+			if err != nil {
+				return err
+			}
+			result = domain.ImportResult{Imported: imported, Skipped: copied - imported}
+			return nil
+		})
+	return result, err
+}
+
+// ImportRainfall adds rainfall readings for known stations that aren't already stored, marking
+// their hours for the next rollup refresh
+func (r *ImportRepo) ImportRainfall(ctx context.Context, load func(add func(stationID string, m domain.Measurement) error) error) (domain.ImportResult, error) {
+	var result domain.ImportResult
+	err := bulkLoad(ctx, r.db, "rainfalls", []string{"stationid", "timestamp", "level"},
+		func(add func(...interface{}) error) error {
+			return load(func(stationID string, m domain.Measurement) error {
+				return add(stationID, m.Timestamp, m.Level)
+			})
+		},
+		func(tx *sql.Tx, copied int64) error {
+			if err := r.ensureStagedPartitions(ctx, tx, rainfallsTable); err != nil {
+				return err
+			}
+			var unknown int64
+			if err := tx.QueryRowContext(ctx, countUnknownStationsQuery).Scan(&unknown); err != nil {
+				return err
+			}
+			imported, err := execRows(ctx, tx, mergeRainfallQuery)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, markStagedRollupsPendingQuery); err != nil {
+				return err
+			}
+			result = domain.ImportResult{Imported: imported, Skipped: copied - imported - unknown, Rejected: unknown}
+			return nil
+		})
+	return result, err
+}
+
+// ImportRiverLevels adds river level readings for a gauge that aren't already stored
+func (r *ImportRepo) ImportRiverLevels(ctx context.Context, gaugeName string, load func(add func(domain.Measurement) error) error) (domain.ImportResult, error) {
+	gauge, err := lookupGauge(ctx, r.queries, gaugeName)
+	if err != nil {
+		return domain.ImportResult{}, err
+	}
+
+	var result domain.ImportResult
+	err = bulkLoad(ctx, r.db, "riverlevels", []string{"gaugeid", "timestamp", "level"},
+		func(add func(...interface{}) error) error {
+			return load(func(m domain.Measurement) error {
+				return add(gauge.ID, m.Timestamp, m.Level)
+			})
+		},
+		func(tx *sql.Tx, copied int64) error {
+			if err := r.ensureStagedPartitions(ctx, tx, riverLevelsTable); err != nil {
+				return err
+			}
+			imported, err := execRows(ctx, tx, mergeRiverLevelsQuery)
+			if err != nil {
+				return err
+			}
+			result = domain.ImportResult{Imported: imported, Skipped: copied - imported}
+			return nil
+		})
+	return result, err
+}
+
+// ensureStagedPartitions creates the partitions for the months of the staged readings before they are merged
+func (r *ImportRepo) ensureStagedPartitions(ctx context.Context, tx *sql.Tx, table string) error {
+	var first, last sql.NullTime
+	if err := tx.QueryRowContext(ctx, `SELECT min("timestamp"), max("timestamp") FROM import_staging`).Scan(&first, &last); err != nil {
+		return err
+	}
+	if !first.Valid {
+		return nil
+	}
+	return r.partitions.ensure(ctx, table, first.Time, last.Time)
+}
+
+// bulkLoad COPYs the rows load adds into import_staging, a temporary copy of table's columns,
+// then calls merge to move them into place. Everything runs in one transaction, so a failed
+// import leaves the table as it was.
+func bulkLoad(ctx context.Context, db *sql.DB, table string, columns []string, load func(add func(...interface{}) error) error, merge func(tx *sql.Tx, copied int64) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "CREATE TEMPORARY TABLE import_staging (LIKE "+pq.QuoteIdentifier(table)+") ON COMMIT DROP"); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("import_staging", columns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	var copied int64
+	err = load(func(values ...interface{}) error {
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return err
+		}
+		copied++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// an Exec without arguments flushes the buffered rows and ends the COPY
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return err
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+
+	if err := merge(tx, copied); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func execRows(ctx context.Context, tx *sql.Tx, query string) (int64, error) {
+	res, err := tx.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
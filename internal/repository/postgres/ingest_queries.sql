@@ -0,0 +1,11 @@
+-- name: InsertRainfallReading :execrows
+-- Insert a rainfall reading unless the station already has one at that timestamp, so re-polling is idempotent
+INSERT INTO rainfalls (stationid, timestamp, level)
+VALUES ($1, $2, $3)
+ON CONFLICT (stationid, timestamp) DO NOTHING;
+
+-- name: InsertRiverReading :execrows
+-- Insert a river level reading unless the gauge already has one at that timestamp, so re-polling is idempotent
+INSERT INTO riverlevels (gaugeid, timestamp, level)
+VALUES ($1, $2, $3)
+ON CONFLICT (gaugeid, timestamp) DO NOTHING;
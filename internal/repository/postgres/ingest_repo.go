@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+	"github.com/oliverslade/flood-api/internal/repository/postgres/gen"
+)
+
+type IngestRepo struct {
+	db         *sql.DB
+	queries    *gen.Queries
+	partitions *partitions
+}
+
+func NewIngestRepo(db *sql.DB) repository.IngestRepository {
+	return &IngestRepo{
+		db:         db,
+		queries:    newQueries(db),
+		partitions: newPartitions(db),
+	}
+}
+
+// ListStationIDs returns the id of every rainfall station
+func (r *IngestRepo) ListStationIDs(ctx context.Context) ([]string, error) {
+	stations, err := countRows(ctx, r.queries.ListStations)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(stations))
+	for _, station := range stations {
+		ids = append(ids, station.ID)
+	}
+	return ids, nil
+}
+
+// GetLatestRainfallTimestamp returns when a station last reported, or the zero time when it never has
+func (r *IngestRepo) GetLatestRainfallTimestamp(ctx context.Context, stationID string) (time.Time, error) {
+	dbReading, err := r.queries.GetLatestRainfallReadingByStation(ctx, stationID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return dbReading.Timestamp, nil
+}
+
+// GetLatestRiverTimestamp returns when a gauge last reported, or the zero time when it never has
+func (r *IngestRepo) GetLatestRiverTimestamp(ctx context.Context, gaugeName string) (time.Time, error) {
+	gauge, err := lookupGauge(ctx, r.queries, gaugeName)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	dbReading, err := r.queries.GetLatestRiverReading(ctx, gauge.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return dbReading.Timestamp, nil
+}
+
+// InsertRainfallReadings stores a batch of readings for a station in one transaction, creating the
+// partitions for their months first
+func (r *IngestRepo) InsertRainfallReadings(ctx context.Context, stationID string, readings []domain.Measurement) (int64, error) {
+	if err := r.partitions.ensureFor(ctx, rainfallsTable, readings); err != nil {
+		return 0, err
+	}
+	return r.insert(ctx, readings, func(q *gen.Queries, m domain.Measurement) (int64, error) {
+		return q.InsertRainfallReading(ctx, gen.InsertRainfallReadingParams{
+			Stationid: stationID,
+			Timestamp: m.Timestamp.UTC(),
+			Level:     m.Level,
+		})
+	}, func(q *gen.Queries) error {
+		return markRollupsPending(ctx, q, stationID, readings)
+	})
+}
+
+// InsertRiverReadings stores a batch of readings for a gauge in one transaction, creating the
+// partitions for their months first
+func (r *IngestRepo) InsertRiverReadings(ctx context.Context, gaugeName string, readings []domain.Measurement) (int64, error) {
+	gauge, err := lookupGauge(ctx, r.queries, gaugeName)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.partitions.ensureFor(ctx, riverLevelsTable, readings); err != nil {
+		return 0, err
+	}
+
+	return r.insert(ctx, readings, func(q *gen.Queries, m domain.Measurement) (int64, error) {
+		return q.InsertRiverReading(ctx, gen.InsertRiverReadingParams{
+			Gaugeid:   gauge.ID,
+			Timestamp: m.Timestamp.UTC(),
+			Level:     m.Level,
+		})
+	}, nil)
+}
+
+// RefreshRollups brings the rainfall rollups up to date with the readings inserted since the last refresh
+func (r *IngestRepo) RefreshRollups(ctx context.Context) error {
+	_, err := RefreshRollups(ctx, r.db)
+	return err
+}
+
+// insert runs one insert per reading inside a transaction and totals the rows each one added,
+// then runs after, when there is one, in the same transaction
+func (r *IngestRepo) insert(ctx context.Context, readings []domain.Measurement, insertOne func(*gen.Queries, domain.Measurement) (int64, error), after func(*gen.Queries) error) (int64, error) {
+	if len(readings) == 0 {
+		return 0, nil
+	}
+
+	var inserted int64
+	err := withTx(ctx, r.db, func(q *gen.Queries) error {
+		for _, m := range readings {
+			n, err := insertOne(q, m)
+			if err != nil {
+				return err
+			}
+			inserted += n
+		}
+		if after != nil {
+			return after(q)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
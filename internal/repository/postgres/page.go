@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository/postgres/gen"
+)
+
+// Page queries stream a page of readings rather than collecting it like the sqlc queries do. Every
+// row also carries the page's row count and last timestamp, which the window functions work out
+// over the page alone, so the links to the pages either side can be sent before the first reading.
+// They keep the sqlc name comment so timedDB records them like the generated queries.
+const (
+	riverReadingsPageQuery = `-- name: StreamRiverReadingsPage :many
+SELECT timestamp, level, count(*) OVER (), max(timestamp) OVER ()
+FROM (
+    SELECT timestamp, level
+    FROM riverlevels
+    WHERE gaugeid = $1
+      AND ($2::timestamp IS NULL OR timestamp > $2)
+      AND ($3::timestamp IS NULL OR timestamp >= $3)
+      AND ($4::timestamp IS NULL OR timestamp < $4)
+    ORDER BY timestamp ASC
+    LIMIT $5 OFFSET $6
+) page
+ORDER BY timestamp ASC`
+
+	rainfallReadingsPageQuery = `-- name: StreamRainfallReadingsPageByStation :many
+SELECT timestamp, level, count(*) OVER (), max(timestamp) OVER ()
+FROM (
+    SELECT timestamp, level
+    FROM rainfalls
+    WHERE stationid = $1
+      AND ($2::timestamp IS NULL OR timestamp > $2)
+      AND ($3::timestamp IS NULL OR timestamp >= $3)
+      AND ($4::timestamp IS NULL OR timestamp < $4)
+    ORDER BY timestamp ASC
+    LIMIT $5 OFFSET $6
+) page
+ORDER BY timestamp ASC`
+
+	rainfallStationsReadingsPageQuery = `-- name: StreamRainfallReadingsPageByStations :many
+SELECT timestamp, level, stationid, count(*) OVER (), max(timestamp) OVER ()
+FROM (
+    SELECT timestamp, level, stationid
+    FROM rainfalls
+    WHERE stationid = ANY($1::text[])
+      AND ($2::timestamp IS NULL OR timestamp > $2)
+      AND ($3::timestamp IS NULL OR timestamp >= $3)
+      AND ($4::timestamp IS NULL OR timestamp < $4)
+    ORDER BY timestamp ASC, stationid ASC
+    LIMIT $5 OFFSET $6
+) page
+ORDER BY timestamp ASC, stationid ASC`
+)
+
+// pageArgs returns the arguments of a page query after its key. A keyset cursor takes precedence
+// over the offset but not the start date, like the sqlc queries.
+func pageArgs(key interface{}, params domain.GetReadingsParams) []interface{} {
+	offset := (params.Pagination.Page - 1) * params.Pagination.PageSize
+	if params.Pagination.After != nil {
+		offset = 0
+	}
+	return []interface{}{key, nullTime(params.Pagination.After), nullTime(params.StartDate), nullTime(params.EndDate), params.Pagination.PageSize, offset}
+}
+
+// streamPage runs a page query, passing the bounds scanned from the first row to start, or empty
+// bounds when there are no rows, before each reading reaches fn. The query's span records how many
+// readings were streamed.
+func streamPage[T any](ctx context.Context, db gen.DBTX, query string, args []interface{}, start func(domain.PageBounds) error, fn func(T) error, scan func(rows *sql.Rows, bounds *domain.PageBounds) (T, error)) (err error) {
+	count, streamed := &rowCount{}, 0
+	defer func() { count.end(streamed, err) }()
+
+	rows, err := db.QueryContext(context.WithValue(ctx, rowCountKey{}, count), query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	started := false
+	for rows.Next() {
+		var bounds domain.PageBounds
+		reading, err := scan(rows, &bounds)
+		if err != nil {
+			return err
+		}
+		if !started {
+			started = true
+			if err := start(bounds); err != nil {
+				return err
+			}
+		}
+		if err := fn(reading); err != nil {
+			return err
+		}
+		streamed++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if !started {
+		return start(domain.PageBounds{})
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// Migration 009 range partitions the reading tables by month, with a default partition catching
+// readings for months that have no partition yet. A migration only runs once but new months keep
+// coming, so flood-api creates their partitions ahead of time with CreatePartitions.
+const (
+	rainfallsTable   = "rainfalls"
+	riverLevelsTable = "riverlevels"
+)
+
+// readingColumns are the columns of each partitioned table, in any order that names them all
+var readingColumns = map[string]string{
+	rainfallsTable:   `stationid, level, "timestamp"`,
+	riverLevelsTable: `level, "timestamp", gaugeid`,
+}
+
+// PartitionMonthsAhead is how many months after the current one have their partitions created
+// in advance, so new readings keep finding one even if the scheduled job misses a few runs
+const PartitionMonthsAhead = 2
+
+// partitions creates monthly partitions, remembering the ones it has seen so each is only looked
+// up once
+type partitions struct {
+	db          *sql.DB
+	mu          sync.Mutex
+	partitioned map[string]bool // by table, false for a database migrated before 009
+	existing    map[string]bool // by partition name
+}
+
+func newPartitions(db *sql.DB) *partitions {
+	return &partitions{
+		db:          db,
+		partitioned: make(map[string]bool),
+		existing:    make(map[string]bool),
+	}
+}
+
+// ensureFor creates the partitions for the months readings fall in, and the month after the last,
+// so a partition is in place before the readings for a new month arrive
+func (p *partitions) ensureFor(ctx context.Context, table string, readings []domain.Measurement) error {
+	if len(readings) == 0 {
+		return nil
+	}
+	first, last := readings[0].Timestamp, readings[0].Timestamp
+	for _, m := range readings[1:] {
+		if m.Timestamp.Before(first) {
+			first = m.Timestamp
+		}
+		if m.Timestamp.After(last) {
+			last = m.Timestamp
+		}
+	}
+	return p.ensure(ctx, table, first, monthStart(last).AddDate(0, 1, 0))
+}
+
+// ensure creates the partitions for every month from the one holding from to the one holding to
+func (p *partitions) ensure(ctx context.Context, table string, from, to time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	partitioned, known := p.partitioned[table]
+	if !known {
+		if err := p.db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", "public."+table+"_default").Scan(&partitioned); err != nil {
+			return err
+		}
+		p.partitioned[table] = partitioned
+	}
+	if !partitioned {
+		return nil
+	}
+
+	for month := monthStart(from); !month.After(to); month = month.AddDate(0, 1, 0) {
+		name := partitionName(table, month)
+		if p.existing[name] {
+			continue
+		}
+		if err := p.createMonth(ctx, table, month); err != nil {
+			return fmt.Errorf("creating partition %s: %w", name, err)
+		}
+		p.existing[name] = true
+	}
+	return nil
+}
+
+// createMonth adds the partition for a month unless another process already has, moving any of
+// its readings out of the default partition first as Postgres won't attach a range the default holds
+func (p *partitions) createMonth(ctx context.Context, table string, month time.Time) error {
+	name := partitionName(table, month)
+	return withPartitionLock(ctx, p.db, table, func(tx *sql.Tx) error {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", "public."+name).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+
+		parent, partition, def := qualified(table), qualified(name), qualified(table+"_default")
+		columns := readingColumns[table]
+		next := month.AddDate(0, 1, 0)
+
+		if _, err := tx.ExecContext(ctx, "CREATE TABLE "+partition+" (LIKE "+parent+")"); err != nil {
+			return err
+		}
+		moveQuery := "WITH moved AS (DELETE FROM " + def + ` WHERE "timestamp" >= $1 AND "timestamp" < $2 RETURNING ` + columns + ")" +
+			" INSERT INTO " + partition + " (" + columns + ") SELECT " + columns + ` FROM moved ORDER BY "timestamp"`
+		if _, err := tx.ExecContext(ctx, moveQuery, month, next); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "ALTER TABLE "+parent+" ATTACH PARTITION "+partition+" FOR VALUES "+monthBounds(month))
+		return err
+	})
+}
+
+// PartitionReadings moves every reading out of the default partitions into monthly ones, then
+// creates the partitions ahead like CreatePartitions. flood-api migrate up runs it, as migration 009
+// leaves every existing reading in the default partitions.
+func PartitionReadings(ctx context.Context, db *sql.DB, now time.Time) error {
+	p := newPartitions(db)
+	for _, table := range []string{rainfallsTable, riverLevelsTable} {
+		if err := p.splitDefault(ctx, table); err != nil {
+			return fmt.Errorf("partitioning %s: %w", table, err)
+		}
+	}
+	return p.ensureAhead(ctx, now)
+}
+
+// CreatePartitions creates the partitions for the month holding now and the PartitionMonthsAhead
+// after it. flood-api runs it on start and then daily, so its own writes rarely find a month
+// without one. It does nothing to a database migrated before 009.
+func CreatePartitions(ctx context.Context, db *sql.DB, now time.Time) error {
+	return newPartitions(db).ensureAhead(ctx, now)
+}
+
+func (p *partitions) ensureAhead(ctx context.Context, now time.Time) error {
+	for _, table := range []string{rainfallsTable, riverLevelsTable} {
+		if err := p.ensure(ctx, table, now, monthStart(now).AddDate(0, PartitionMonthsAhead, 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitDefault swaps the default partition for an empty one, creates a partition for every month
+// the old one held readings for, and routes its readings into them. Creating partitions one at a
+// time would scan the whole default partition for every month.
+func (p *partitions) splitDefault(ctx context.Context, table string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var partitioned bool
+	if err := p.db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", "public."+table+"_default").Scan(&partitioned); err != nil {
+		return err
+	}
+	p.partitioned[table] = partitioned
+	if !partitioned {
+		return nil
+	}
+
+	return withPartitionLock(ctx, p.db, table, func(tx *sql.Tx) error {
+		parent, def, old := qualified(table), qualified(table+"_default"), qualified(table+"_default_split")
+
+		var first, last sql.NullTime
+		if err := tx.QueryRowContext(ctx, `SELECT min("timestamp"), max("timestamp") FROM `+def).Scan(&first, &last); err != nil {
+			return err
+		}
+		if !first.Valid {
+			return nil
+		}
+
+		stmts := []string{
+			"ALTER TABLE " + parent + " DETACH PARTITION " + def,
+			"ALTER TABLE " + def + " RENAME TO " + pq.QuoteIdentifier(table+"_default_split"),
+			"CREATE TABLE " + def + " PARTITION OF " + parent + " DEFAULT",
+		}
+		for month := monthStart(first.Time); !month.After(last.Time); month = month.AddDate(0, 1, 0) {
+			stmts = append(stmts, "CREATE TABLE IF NOT EXISTS "+qualified(partitionName(table, month))+" PARTITION OF "+parent+" FOR VALUES "+monthBounds(month))
+		}
+		columns := readingColumns[table]
+		stmts = append(stmts,
+			"INSERT INTO "+parent+" ("+columns+") SELECT "+columns+" FROM "+old+` ORDER BY "timestamp"`,
+			"DROP TABLE "+old,
+			"ANALYZE "+parent,
+		)
+
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withPartitionLock runs fn in a transaction holding an advisory lock for table, so other processes
+// don't create the same partition or move readings out of the default at once
+func withPartitionLock(ctx context.Context, db *sql.DB, table string, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", "partition "+table); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// partitionName names a month's partition like rainfalls_2024_01
+func partitionName(table string, month time.Time) string {
+	return fmt.Sprintf("%s_%04d_%02d", table, month.Year(), int(month.Month()))
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// monthBounds is the partition bound spec for the month starting at month
+func monthBounds(month time.Time) string {
+	from := pq.QuoteLiteral(month.Format(time.DateOnly))
+	to := pq.QuoteLiteral(month.AddDate(0, 1, 0).Format(time.DateOnly))
+	return "FROM (" + from + ") TO (" + to + ")"
+}
+
+func qualified(name string) string {
+	return "public." + pq.QuoteIdentifier(name)
+}
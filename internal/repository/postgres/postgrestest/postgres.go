@@ -1,4 +1,5 @@
-package testutil
+// Package postgrestest runs the Postgres container the integration and handler tests share
+package postgrestest
 
 import (
 	"context"
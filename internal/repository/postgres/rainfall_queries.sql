@@ -14,6 +14,61 @@ WHERE stationid = $1 AND timestamp >= $2
 ORDER BY timestamp ASC
 LIMIT $3 OFFSET $4;
 
+-- name: GetRainfallReadingsByStationWithEndDate :many
+-- Get rainfall readings for a station before an end date sorted in chronological order with pagination
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = $1 AND timestamp < $2
+ORDER BY timestamp ASC
+LIMIT $3 OFFSET $4;
+
+-- name: GetRainfallReadingsByStationInDateRange :many
+-- Get rainfall readings for a station within a date range sorted in chronological order with pagination
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = sqlc.arg(stationid) AND timestamp >= sqlc.arg(start_date) AND timestamp < sqlc.arg(end_date)
+ORDER BY timestamp ASC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: GetRainfallReadingsByStationAfter :many
+-- Get the next page of rainfall readings for a station after a keyset cursor timestamp and from an optional start date
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = sqlc.arg(stationid) AND timestamp > sqlc.arg(after)
+  AND (sqlc.narg(start_date)::timestamp IS NULL OR timestamp >= sqlc.narg(start_date))
+ORDER BY timestamp ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetRainfallReadingsByStationAfterWithEndDate :many
+-- Get the next page of rainfall readings for a station after a keyset cursor timestamp and from an optional start date before an end date
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = sqlc.arg(stationid) AND timestamp > sqlc.arg(after) AND timestamp < sqlc.arg(end_date)
+  AND (sqlc.narg(start_date)::timestamp IS NULL OR timestamp >= sqlc.narg(start_date))
+ORDER BY timestamp ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetLatestRainfallReadingByStation :one
+-- Get the most recent rainfall reading for a station
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = $1
+ORDER BY timestamp DESC
+LIMIT 1;
+
+-- name: GetLatestRainfallReadings :many
+-- Get the most recent rainfall reading of every station, seeking each station's index separately
+SELECT s.name, latest.timestamp, latest.level
+FROM stationnames s
+CROSS JOIN LATERAL (
+    SELECT r.timestamp, r.level
+    FROM rainfalls r
+    WHERE r.stationid = s.id
+    ORDER BY r.timestamp DESC
+    LIMIT 1
+) latest
+ORDER BY s.name ASC;
+
 -- name: CountRainfallReadingsByStation :one
 -- Count rainfall readings for a station
 SELECT COUNT(*) FROM rainfalls
@@ -24,6 +79,77 @@ WHERE stationid = $1;
 SELECT COUNT(*) FROM rainfalls
 WHERE stationid = $1 AND timestamp >= $2;
 
+-- name: CountRainfallReadingsByStationWithEndDate :one
+-- Count rainfall readings for a station before an end date
+SELECT COUNT(*) FROM rainfalls
+WHERE stationid = $1 AND timestamp < $2;
+
+-- name: CountRainfallReadingsByStationInDateRange :one
+-- Count rainfall readings for a station within a date range
+SELECT COUNT(*) FROM rainfalls
+WHERE stationid = sqlc.arg(stationid) AND timestamp >= sqlc.arg(start_date) AND timestamp < sqlc.arg(end_date);
+
+-- name: GetRainfallAggregatesByStation :many
+-- Get rainfall summary statistics for a station per time bucket within optional date bounds
+SELECT date_trunc(sqlc.arg(interval)::text, timestamp)::timestamp AS bucket,
+       SUM(level)::double precision AS total,
+       AVG(level)::double precision AS mean,
+       MAX(level)::double precision AS maximum,
+       MIN(level)::double precision AS minimum,
+       COUNT(*) AS sample_count
+FROM rainfalls
+WHERE stationid = sqlc.arg(stationid)
+  AND (sqlc.narg(start_date)::timestamp IS NULL OR timestamp >= sqlc.narg(start_date))
+  AND (sqlc.narg(end_date)::timestamp IS NULL OR timestamp < sqlc.narg(end_date))
+GROUP BY bucket
+ORDER BY bucket ASC;
+
+-- name: GetRainfallHourlyAggregatesByStation :many
+-- Get rainfall summary statistics for a station per time bucket from the hourly rollup within optional hour aligned bounds
+SELECT date_trunc(sqlc.arg(interval)::text, hour)::timestamp AS bucket,
+       SUM(total)::double precision AS total,
+       (SUM(total) / SUM(sample_count))::double precision AS mean,
+       MAX(maximum)::double precision AS maximum,
+       MIN(minimum)::double precision AS minimum,
+       SUM(sample_count)::bigint AS sample_count
+FROM rainfall_hourly
+WHERE stationid = sqlc.arg(stationid)
+  AND (sqlc.narg(start_date)::timestamp IS NULL OR hour >= sqlc.narg(start_date))
+  AND (sqlc.narg(end_date)::timestamp IS NULL OR hour < sqlc.narg(end_date))
+GROUP BY bucket
+ORDER BY bucket ASC;
+
+-- name: GetRainfallDailyAggregatesByStation :many
+-- Get rainfall summary statistics for a station per time bucket from the daily rollup within optional day aligned bounds
+SELECT date_trunc(sqlc.arg(interval)::text, day)::timestamp AS bucket,
+       SUM(total)::double precision AS total,
+       (SUM(total) / SUM(sample_count))::double precision AS mean,
+       MAX(maximum)::double precision AS maximum,
+       MIN(minimum)::double precision AS minimum,
+       SUM(sample_count)::bigint AS sample_count
+FROM rainfall_daily
+WHERE stationid = sqlc.arg(stationid)
+  AND (sqlc.narg(start_date)::timestamp IS NULL OR day >= sqlc.narg(start_date))
+  AND (sqlc.narg(end_date)::timestamp IS NULL OR day < sqlc.narg(end_date))
+GROUP BY bucket
+ORDER BY bucket ASC;
+
+-- name: HasPendingRainfallRollups :one
+-- Check whether a station has hours within optional date bounds whose rollups haven't been refreshed since they were written
+SELECT EXISTS (
+    SELECT 1
+    FROM rainfall_rollup_pending
+    WHERE stationid = sqlc.arg(stationid)
+      AND (sqlc.narg(start_date)::timestamp IS NULL OR hour >= date_trunc('hour', sqlc.narg(start_date)::timestamp))
+      AND (sqlc.narg(end_date)::timestamp IS NULL OR hour < sqlc.narg(end_date))
+) AS pending;
+
+-- name: MarkRainfallRollupsPending :exec
+-- Record the hours a station's readings were written to so the next refresh recomputes their rollups
+INSERT INTO rainfall_rollup_pending (stationid, hour)
+SELECT sqlc.arg(stationid)::text, unnest(sqlc.arg(hours)::timestamp[])
+ON CONFLICT DO NOTHING;
+
 -- name: GetStationByID :one
 -- Get station information by ID for validation
 SELECT id, name FROM stationnames
@@ -33,3 +159,37 @@ WHERE id = $1;
 -- Get station information by name for API lookups
 SELECT id, name FROM stationnames
 WHERE name = $1;
+
+-- name: GetStationsByNames :many
+-- Get station information for several names at once, skipping any that don't exist
+SELECT id, name FROM stationnames
+WHERE name = ANY(sqlc.arg(names)::text[])
+ORDER BY name ASC;
+
+-- name: ListStations :many
+-- Get every station
+SELECT id, name FROM stationnames
+ORDER BY name ASC;
+
+-- name: GetRainfallReadingsByStations :many
+-- Get rainfall readings for several stations merged in chronological order within optional date bounds with pagination
+SELECT timestamp, level, stationid
+FROM rainfalls
+WHERE stationid = ANY(sqlc.arg(stationids)::text[])
+  AND (sqlc.narg(start_date)::timestamp IS NULL OR timestamp >= sqlc.narg(start_date))
+  AND (sqlc.narg(end_date)::timestamp IS NULL OR timestamp < sqlc.narg(end_date))
+ORDER BY timestamp ASC, stationid ASC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountRainfallReadingsByStations :one
+-- Count rainfall readings for several stations within optional date bounds
+SELECT COUNT(*) FROM rainfalls
+WHERE stationid = ANY(sqlc.arg(stationids)::text[])
+  AND (sqlc.narg(start_date)::timestamp IS NULL OR timestamp >= sqlc.narg(start_date))
+  AND (sqlc.narg(end_date)::timestamp IS NULL OR timestamp < sqlc.narg(end_date));
+
+-- name: UpsertRainfallReading :exec
+-- Insert a rainfall reading for a station, replacing the level of one already stored at that timestamp
+INSERT INTO rainfalls (stationid, timestamp, level)
+VALUES ($1, $2, $3)
+ON CONFLICT (stationid, timestamp) DO UPDATE SET level = EXCLUDED.level;
@@ -6,18 +6,22 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/lib/pq"
+
 	"github.com/oliverslade/flood-api/internal/domain"
 	"github.com/oliverslade/flood-api/internal/repository"
 	"github.com/oliverslade/flood-api/internal/repository/postgres/gen"
 )
 
 type RainfallRepo struct {
+	db      *sql.DB
 	queries *gen.Queries
 }
 
 func NewRainfallRepo(db *sql.DB) repository.RainfallRepository {
 	return &RainfallRepo{
-		queries: gen.New(db),
+		db:      db,
+		queries: newQueries(db),
 	}
 }
 
@@ -30,36 +34,266 @@ func (r *RainfallRepo) GetReadingsByStation(ctx context.Context, params domain.G
 
 	// Calculate offset for pagination
 	offset := (params.Pagination.Page - 1) * params.Pagination.PageSize
+	limit := int32(params.Pagination.PageSize)
+
+	// All query variants return the same columns, so normalise them to one row type
+	var dbReadings []gen.GetRainfallReadingsByStationRow
 
-	if params.StartDate != nil {
-		queryParams := gen.GetRainfallReadingsByStationWithStartDateParams{
+	switch {
+	case params.Pagination.After != nil && params.EndDate != nil:
+		rows, err := countRowsOf(ctx, r.queries.GetRainfallReadingsByStationAfterWithEndDate, gen.GetRainfallReadingsByStationAfterWithEndDateParams{
+			Stationid: station.ID,
+			After:     *params.Pagination.After,
+			EndDate:   *params.EndDate,
+			StartDate: nullTime(params.StartDate),
+			Limit:     limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			dbReadings = append(dbReadings, gen.GetRainfallReadingsByStationRow(row))
+		}
+	case params.Pagination.After != nil:
+		// keyset pagination seeks straight to the cursor on the station/timestamp index instead of skipping rows
+		rows, err := countRowsOf(ctx, r.queries.GetRainfallReadingsByStationAfter, gen.GetRainfallReadingsByStationAfterParams{
+			Stationid: station.ID,
+			After:     *params.Pagination.After,
+			StartDate: nullTime(params.StartDate),
+			Limit:     limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			dbReadings = append(dbReadings, gen.GetRainfallReadingsByStationRow(row))
+		}
+	case params.StartDate != nil && params.EndDate != nil:
+		rows, err := countRowsOf(ctx, r.queries.GetRainfallReadingsByStationInDateRange, gen.GetRainfallReadingsByStationInDateRangeParams{
+			Stationid: station.ID,
+			StartDate: *params.StartDate,
+			EndDate:   *params.EndDate,
+			Limit:     limit,
+			Offset:    int32(offset),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			dbReadings = append(dbReadings, gen.GetRainfallReadingsByStationRow(row))
+		}
+	case params.StartDate != nil:
+		rows, err := countRowsOf(ctx, r.queries.GetRainfallReadingsByStationWithStartDate, gen.GetRainfallReadingsByStationWithStartDateParams{
 			Stationid: station.ID,
 			Timestamp: *params.StartDate,
-			Limit:     int32(params.Pagination.PageSize),
+			Limit:     limit,
 			Offset:    int32(offset),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			dbReadings = append(dbReadings, gen.GetRainfallReadingsByStationRow(row))
+		}
+	case params.EndDate != nil:
+		rows, err := countRowsOf(ctx, r.queries.GetRainfallReadingsByStationWithEndDate, gen.GetRainfallReadingsByStationWithEndDateParams{
+			Stationid: station.ID,
+			Timestamp: *params.EndDate,
+			Limit:     limit,
+			Offset:    int32(offset),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			dbReadings = append(dbReadings, gen.GetRainfallReadingsByStationRow(row))
 		}
-		dbReadings, err := r.queries.GetRainfallReadingsByStationWithStartDate(ctx, queryParams)
+	default:
+		rows, err := countRowsOf(ctx, r.queries.GetRainfallReadingsByStation, gen.GetRainfallReadingsByStationParams{
+			Stationid: station.ID,
+			Limit:     limit,
+			Offset:    int32(offset),
+		})
 		if err != nil {
 			return nil, err
 		}
+		dbReadings = rows
+	}
 
-		readings := make([]domain.RainfallReading, len(dbReadings))
-		for i, dbReading := range dbReadings {
-			readings[i] = domain.RainfallReading{
-				Timestamp:   dbReading.Timestamp,
-				Level:       dbReading.Level,
-				StationName: params.StationName,
-			}
+	readings := make([]domain.RainfallReading, len(dbReadings))
+	for i, dbReading := range dbReadings {
+		readings[i] = domain.RainfallReading{
+			Timestamp:   dbReading.Timestamp,
+			Level:       dbReading.Level,
+			StationName: params.StationName,
 		}
-		return readings, nil
+	}
+	return readings, nil
+}
+
+// StreamReadingsPageByStation passes a page of rainfall readings for a station to fn as it is read, after its bounds to start
+func (r *RainfallRepo) StreamReadingsPageByStation(ctx context.Context, params domain.GetRainfallParams, start func(domain.PageBounds) error, fn func(domain.RainfallReading) error) error {
+	station, err := r.getStationByName(ctx, params.StationName)
+	if err != nil {
+		return err
+	}
+
+	args := pageArgs(station.ID, params.GetReadingsParams)
+	return streamPage(ctx, timedDB{db: r.db}, rainfallReadingsPageQuery, args, start, fn, func(rows *sql.Rows, bounds *domain.PageBounds) (domain.RainfallReading, error) {
+		reading := domain.RainfallReading{StationName: params.StationName}
+		err := rows.Scan(&reading.Timestamp, &reading.Level, &bounds.Count, &bounds.Last)
+		return reading, err
+	})
+}
+
+// CountReadingsByStation returns the number of rainfall readings for a station matching the date filters
+func (r *RainfallRepo) CountReadingsByStation(ctx context.Context, params domain.GetRainfallParams) (int64, error) {
+	station, err := r.getStationByName(ctx, params.StationName)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case params.StartDate != nil && params.EndDate != nil:
+		return r.queries.CountRainfallReadingsByStationInDateRange(ctx, gen.CountRainfallReadingsByStationInDateRangeParams{
+			Stationid: station.ID,
+			StartDate: *params.StartDate,
+			EndDate:   *params.EndDate,
+		})
+	case params.StartDate != nil:
+		return r.queries.CountRainfallReadingsByStationWithStartDate(ctx, gen.CountRainfallReadingsByStationWithStartDateParams{
+			Stationid: station.ID,
+			Timestamp: *params.StartDate,
+		})
+	case params.EndDate != nil:
+		return r.queries.CountRainfallReadingsByStationWithEndDate(ctx, gen.CountRainfallReadingsByStationWithEndDateParams{
+			Stationid: station.ID,
+			Timestamp: *params.EndDate,
+		})
+	default:
+		return r.queries.CountRainfallReadingsByStation(ctx, station.ID)
+	}
+}
+
+// GetReadingsByStations returns rainfall readings for several stations merged in chronological order
+func (r *RainfallRepo) GetReadingsByStations(ctx context.Context, params domain.GetRainfallStationsParams) ([]domain.RainfallReading, error) {
+	stationNames, err := r.getStationNamesByID(ctx, params.StationNames)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (params.Pagination.Page - 1) * params.Pagination.PageSize
+	dbReadings, err := countRowsOf(ctx, r.queries.GetRainfallReadingsByStations, gen.GetRainfallReadingsByStationsParams{
+		Stationids: stationIDs(stationNames),
+		StartDate:  nullTime(params.StartDate),
+		EndDate:    nullTime(params.EndDate),
+		Limit:      int32(params.Pagination.PageSize),
+		Offset:     int32(offset),
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	queryParams := gen.GetRainfallReadingsByStationParams{
+	readings := make([]domain.RainfallReading, len(dbReadings))
+	for i, dbReading := range dbReadings {
+		readings[i] = domain.RainfallReading{
+			Timestamp:   dbReading.Timestamp,
+			Level:       dbReading.Level,
+			StationName: stationNames[dbReading.Stationid],
+		}
+	}
+	return readings, nil
+}
+
+// StreamReadingsPageByStations passes a page of the merged rainfall readings of several stations to fn as it is read, after its bounds to start
+func (r *RainfallRepo) StreamReadingsPageByStations(ctx context.Context, params domain.GetRainfallStationsParams, start func(domain.PageBounds) error, fn func(domain.RainfallReading) error) error {
+	stationNames, err := r.getStationNamesByID(ctx, params.StationNames)
+	if err != nil {
+		return err
+	}
+
+	args := pageArgs(pq.Array(stationIDs(stationNames)), params.GetReadingsParams)
+	return streamPage(ctx, timedDB{db: r.db}, rainfallStationsReadingsPageQuery, args, start, fn, func(rows *sql.Rows, bounds *domain.PageBounds) (domain.RainfallReading, error) {
+		var reading domain.RainfallReading
+		var stationID string
+		err := rows.Scan(&reading.Timestamp, &reading.Level, &stationID, &bounds.Count, &bounds.Last)
+		reading.StationName = stationNames[stationID]
+		return reading, err
+	})
+}
+
+// CountReadingsByStations returns the number of rainfall readings for several stations matching the date filters
+func (r *RainfallRepo) CountReadingsByStations(ctx context.Context, params domain.GetRainfallStationsParams) (int64, error) {
+	stationNames, err := r.getStationNamesByID(ctx, params.StationNames)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.queries.CountRainfallReadingsByStations(ctx, gen.CountRainfallReadingsByStationsParams{
+		Stationids: stationIDs(stationNames),
+		StartDate:  nullTime(params.StartDate),
+		EndDate:    nullTime(params.EndDate),
+	})
+}
+
+// GetAggregatesByStation returns rainfall readings for a station grouped into time buckets,
+// from the hourly or daily rollup when one is up to date for the date bounds
+func (r *RainfallRepo) GetAggregatesByStation(ctx context.Context, params domain.GetRainfallAggregateParams) ([]domain.AggregateBucket, error) {
+	station, err := r.getStationByName(ctx, params.StationName)
+	if err != nil {
+		return nil, err
+	}
+
+	rolledUp, ok, err := r.rollupAggregates(ctx, station.ID, params.AggregateParams)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return toAggregateBuckets(rolledUp, params.Func), nil
+	}
+
+	rows, err := countRowsOf(ctx, r.queries.GetRainfallAggregatesByStation, gen.GetRainfallAggregatesByStationParams{
+		Interval:  params.Interval,
 		Stationid: station.ID,
-		Limit:     int32(params.Pagination.PageSize),
-		Offset:    int32(offset),
+		StartDate: nullTime(params.StartDate),
+		EndDate:   nullTime(params.EndDate),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	riverRows := make([]gen.GetRiverAggregatesRow, len(rows))
+	for i, row := range rows {
+		riverRows[i] = gen.GetRiverAggregatesRow(row)
 	}
-	dbReadings, err := r.queries.GetRainfallReadingsByStation(ctx, queryParams)
+	return toAggregateBuckets(riverRows, params.Func), nil
+}
+
+// GetLatestReadingByStation returns the most recent rainfall reading for a station
+func (r *RainfallRepo) GetLatestReadingByStation(ctx context.Context, stationName string) (domain.RainfallReading, error) {
+	station, err := r.getStationByName(ctx, stationName)
+	if err != nil {
+		return domain.RainfallReading{}, err
+	}
+
+	dbReading, err := r.queries.GetLatestRainfallReadingByStation(ctx, station.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.RainfallReading{}, domain.ErrNotFound
+		}
+		return domain.RainfallReading{}, err
+	}
+
+	return domain.RainfallReading{
+		Timestamp:   dbReading.Timestamp,
+		Level:       dbReading.Level,
+		StationName: stationName,
+	}, nil
+}
+
+// GetLatestReadings returns the most recent rainfall reading of every station
+func (r *RainfallRepo) GetLatestReadings(ctx context.Context) ([]domain.RainfallReading, error) {
+	dbReadings, err := countRows(ctx, r.queries.GetLatestRainfallReadings)
 	if err != nil {
 		return nil, err
 	}
@@ -69,12 +303,94 @@ func (r *RainfallRepo) GetReadingsByStation(ctx context.Context, params domain.G
 		readings[i] = domain.RainfallReading{
 			Timestamp:   dbReading.Timestamp,
 			Level:       dbReading.Level,
-			StationName: params.StationName,
+			StationName: dbReading.Name,
 		}
 	}
 	return readings, nil
 }
 
+// StreamReadingsByStation passes every rainfall reading for a station within the date filters to fn through a server-side cursor
+func (r *RainfallRepo) StreamReadingsByStation(ctx context.Context, params domain.ExportRainfallParams, fn func(domain.RainfallReading) error) error {
+	station, err := r.getStationByName(ctx, params.StationName)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{station.ID, nullTime(params.StartDate), nullTime(params.EndDate)}
+	return streamCursor(ctx, r.db, exportRainfallReadingsQuery, args, func(rows *sql.Rows) error {
+		reading := domain.RainfallReading{StationName: params.StationName}
+		if err := rows.Scan(&reading.Timestamp, &reading.Level); err != nil {
+			return err
+		}
+		return fn(reading)
+	})
+}
+
+// UpsertReadingsByStation writes a batch of readings for a station in one transaction, marking
+// their hours for the next rollup refresh
+func (r *RainfallRepo) UpsertReadingsByStation(ctx context.Context, stationName string, readings []domain.Measurement) error {
+	station, err := r.getStationByName(ctx, stationName)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(q *gen.Queries) error {
+		for _, m := range readings {
+			err := q.UpsertRainfallReading(ctx, gen.UpsertRainfallReadingParams{
+				Stationid: station.ID,
+				Timestamp: m.Timestamp.UTC(),
+				Level:     m.Level,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return markRollupsPending(ctx, q, station.ID, readings)
+	})
+}
+
+// getStationNamesByID resolves station names to a map of ID to name, or every station when names is nil.
+// Any names that don't exist are reported together in an UnknownStationsError.
+func (r *RainfallRepo) getStationNamesByID(ctx context.Context, names []string) (map[string]string, error) {
+	var dbStations []gen.Stationname
+	var err error
+	if names == nil {
+		dbStations, err = countRows(ctx, r.queries.ListStations)
+	} else {
+		dbStations, err = countRowsOf(ctx, r.queries.GetStationsByNames, names)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stationNames := make(map[string]string, len(dbStations))
+	found := make(map[string]bool, len(dbStations))
+	for _, dbStation := range dbStations {
+		stationNames[dbStation.ID] = dbStation.Name
+		found[dbStation.Name] = true
+	}
+
+	var unknown []string
+	for _, name := range names {
+		if !found[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, &domain.UnknownStationsError{Names: unknown}
+	}
+
+	return stationNames, nil
+}
+
+func stationIDs(stationNames map[string]string) []string {
+	ids := make([]string, 0, len(stationNames))
+	for id := range stationNames {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 // getStationByName returns station information by name (internal helper for validation)
 func (r *RainfallRepo) getStationByName(ctx context.Context, stationName string) (*domain.Station, error) {
 	dbStation, err := r.queries.GetStationByName(ctx, stationName)
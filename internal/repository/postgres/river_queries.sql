@@ -1,23 +1,103 @@
 -- name: GetRiverReadings :many
--- Get river level readings sorted in chronological order with pagination
-SELECT timestamp, level
+-- Get river level readings for a gauge sorted in chronological order with pagination
+SELECT timestamp, level, gaugeid
 FROM riverlevels
+WHERE gaugeid = $1
 ORDER BY timestamp ASC
-LIMIT $1 OFFSET $2;
+LIMIT $2 OFFSET $3;
 
 -- name: GetRiverReadingsWithStartDate :many
--- Get river level readings from a start date sorted in chronological order with pagination
-SELECT timestamp, level
+-- Get river level readings for a gauge from a start date sorted in chronological order with pagination
+SELECT timestamp, level, gaugeid
 FROM riverlevels
-WHERE timestamp >= $1
+WHERE gaugeid = $1 AND timestamp >= $2
 ORDER BY timestamp ASC
-LIMIT $2 OFFSET $3;
+LIMIT $3 OFFSET $4;
+
+-- name: GetRiverReadingsWithEndDate :many
+-- Get river level readings for a gauge before an end date sorted in chronological order with pagination
+SELECT timestamp, level, gaugeid
+FROM riverlevels
+WHERE gaugeid = $1 AND timestamp < $2
+ORDER BY timestamp ASC
+LIMIT $3 OFFSET $4;
+
+-- name: GetRiverReadingsInDateRange :many
+-- Get river level readings for a gauge within a date range sorted in chronological order with pagination
+SELECT timestamp, level, gaugeid
+FROM riverlevels
+WHERE gaugeid = sqlc.arg(gaugeid) AND timestamp >= sqlc.arg(start_date) AND timestamp < sqlc.arg(end_date)
+ORDER BY timestamp ASC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: GetRiverReadingsAfter :many
+-- Get the next page of river level readings for a gauge after a keyset cursor timestamp and from an optional start date
+SELECT timestamp, level, gaugeid
+FROM riverlevels
+WHERE gaugeid = sqlc.arg(gaugeid) AND timestamp > sqlc.arg(after)
+  AND (sqlc.narg(start_date)::timestamp IS NULL OR timestamp >= sqlc.narg(start_date))
+ORDER BY timestamp ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetRiverReadingsAfterWithEndDate :many
+-- Get the next page of river level readings for a gauge after a keyset cursor timestamp and from an optional start date before an end date
+SELECT timestamp, level, gaugeid
+FROM riverlevels
+WHERE gaugeid = sqlc.arg(gaugeid) AND timestamp > sqlc.arg(after) AND timestamp < sqlc.arg(end_date)
+  AND (sqlc.narg(start_date)::timestamp IS NULL OR timestamp >= sqlc.narg(start_date))
+ORDER BY timestamp ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetLatestRiverReading :one
+-- Get the most recent river level reading for a gauge
+SELECT timestamp, level, gaugeid
+FROM riverlevels
+WHERE gaugeid = $1
+ORDER BY timestamp DESC
+LIMIT 1;
 
 -- name: CountRiverReadings :one
--- Count total river level readings
-SELECT COUNT(*) FROM riverlevels;
+-- Count river level readings for a gauge
+SELECT COUNT(*) FROM riverlevels
+WHERE gaugeid = $1;
 
 -- name: CountRiverReadingsWithStartDate :one
--- Count river level readings from a start date
+-- Count river level readings for a gauge from a start date
+SELECT COUNT(*) FROM riverlevels
+WHERE gaugeid = $1 AND timestamp >= $2;
+
+-- name: CountRiverReadingsWithEndDate :one
+-- Count river level readings for a gauge before an end date
 SELECT COUNT(*) FROM riverlevels
-WHERE timestamp >= $1;
+WHERE gaugeid = $1 AND timestamp < $2;
+
+-- name: CountRiverReadingsInDateRange :one
+-- Count river level readings for a gauge within a date range
+SELECT COUNT(*) FROM riverlevels
+WHERE gaugeid = sqlc.arg(gaugeid) AND timestamp >= sqlc.arg(start_date) AND timestamp < sqlc.arg(end_date);
+
+-- name: GetRiverAggregates :many
+-- Get river level summary statistics for a gauge per time bucket within optional date bounds
+SELECT date_trunc(sqlc.arg(interval)::text, timestamp)::timestamp AS bucket,
+       SUM(level)::double precision AS total,
+       AVG(level)::double precision AS mean,
+       MAX(level)::double precision AS maximum,
+       MIN(level)::double precision AS minimum,
+       COUNT(*) AS sample_count
+FROM riverlevels
+WHERE gaugeid = sqlc.arg(gaugeid)
+  AND (sqlc.narg(start_date)::timestamp IS NULL OR timestamp >= sqlc.narg(start_date))
+  AND (sqlc.narg(end_date)::timestamp IS NULL OR timestamp < sqlc.narg(end_date))
+GROUP BY bucket
+ORDER BY bucket ASC;
+
+-- name: GetGaugeByName :one
+-- Get river gauge information by name for API lookups
+SELECT id, name, river FROM rivergauges
+WHERE name = $1;
+
+-- name: UpsertRiverReading :exec
+-- Insert a river level reading for a gauge, replacing the level of one already stored at that timestamp
+INSERT INTO riverlevels (gaugeid, timestamp, level)
+VALUES ($1, $2, $3)
+ON CONFLICT (gaugeid, timestamp) DO UPDATE SET level = EXCLUDED.level;
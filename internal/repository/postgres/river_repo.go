@@ -5,55 +5,119 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"slices"
 
+	"github.com/oliverslade/flood-api/internal/constants"
 	"github.com/oliverslade/flood-api/internal/domain"
 	"github.com/oliverslade/flood-api/internal/repository"
 	"github.com/oliverslade/flood-api/internal/repository/postgres/gen"
 )
 
 type RiverRepo struct {
+	db      *sql.DB
 	queries *gen.Queries
 }
 
 func NewRiverRepo(db *sql.DB) repository.RiverRepository {
 	return &RiverRepo{
-		queries: gen.New(db),
+		db:      db,
+		queries: newQueries(db),
 	}
 }
 
-// GetReadings returns slice of river level readings with pagination and optional date filtering
-func (r *RiverRepo) GetReadings(ctx context.Context, params domain.GetReadingsParams) ([]domain.RiverReading, error) {
+// GetReadings returns slice of river level readings for a gauge with pagination and optional date filtering
+func (r *RiverRepo) GetReadings(ctx context.Context, params domain.GetRiverParams) ([]domain.RiverReading, error) {
+	gauge, err := r.getGaugeByName(ctx, params.GaugeName)
+	if err != nil {
+		return nil, err
+	}
+
 	// Calculate how many records to skip for pagination
 	offset := (params.Pagination.Page - 1) * params.Pagination.PageSize
+	limit := int32(params.Pagination.PageSize)
+
+	// All query variants return the same columns, so normalise them to one row type
+	var dbReadings []gen.GetRiverReadingsRow
 
-	if params.StartDate != nil {
-		queryParams := gen.GetRiverReadingsWithStartDateParams{
+	switch {
+	case params.Pagination.After != nil && params.EndDate != nil:
+		rows, err := countRowsOf(ctx, r.queries.GetRiverReadingsAfterWithEndDate, gen.GetRiverReadingsAfterWithEndDateParams{
+			Gaugeid:   gauge.ID,
+			After:     *params.Pagination.After,
+			EndDate:   *params.EndDate,
+			StartDate: nullTime(params.StartDate),
+			Limit:     limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			dbReadings = append(dbReadings, gen.GetRiverReadingsRow(row))
+		}
+	case params.Pagination.After != nil:
+		// keyset pagination seeks straight to the cursor on the gauge/timestamp index instead of skipping rows
+		rows, err := countRowsOf(ctx, r.queries.GetRiverReadingsAfter, gen.GetRiverReadingsAfterParams{
+			Gaugeid:   gauge.ID,
+			After:     *params.Pagination.After,
+			StartDate: nullTime(params.StartDate),
+			Limit:     limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			dbReadings = append(dbReadings, gen.GetRiverReadingsRow(row))
+		}
+	case params.StartDate != nil && params.EndDate != nil:
+		rows, err := countRowsOf(ctx, r.queries.GetRiverReadingsInDateRange, gen.GetRiverReadingsInDateRangeParams{
+			Gaugeid:   gauge.ID,
+			StartDate: *params.StartDate,
+			EndDate:   *params.EndDate,
+			Limit:     limit,
+			Offset:    int32(offset),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			dbReadings = append(dbReadings, gen.GetRiverReadingsRow(row))
+		}
+	case params.StartDate != nil:
+		rows, err := countRowsOf(ctx, r.queries.GetRiverReadingsWithStartDate, gen.GetRiverReadingsWithStartDateParams{
+			Gaugeid:   gauge.ID,
 			Timestamp: *params.StartDate,
-			Limit:     int32(params.Pagination.PageSize),
+			Limit:     limit,
 			Offset:    int32(offset),
+		})
+		if err != nil {
+			return nil, err
 		}
-		dbReadings, err := r.queries.GetRiverReadingsWithStartDate(ctx, queryParams)
+		for _, row := range rows {
+			dbReadings = append(dbReadings, gen.GetRiverReadingsRow(row))
+		}
+	case params.EndDate != nil:
+		rows, err := countRowsOf(ctx, r.queries.GetRiverReadingsWithEndDate, gen.GetRiverReadingsWithEndDateParams{
+			Gaugeid:   gauge.ID,
+			Timestamp: *params.EndDate,
+			Limit:     limit,
+			Offset:    int32(offset),
+		})
 		if err != nil {
 			return nil, err
 		}
-
-		readings := make([]domain.RiverReading, len(dbReadings))
-		for i, dbReading := range dbReadings {
-			readings[i] = domain.RiverReading{
-				Timestamp: dbReading.Timestamp,
-				Level:     dbReading.Level,
-			}
+		for _, row := range rows {
+			dbReadings = append(dbReadings, gen.GetRiverReadingsRow(row))
 		}
-		return readings, nil
-	}
-
-	queryParams := gen.GetRiverReadingsParams{
-		Limit:  int32(params.Pagination.PageSize),
-		Offset: int32(offset),
-	}
-	dbReadings, err := r.queries.GetRiverReadings(ctx, queryParams)
-	if err != nil {
-		return nil, err
+	default:
+		rows, err := countRowsOf(ctx, r.queries.GetRiverReadings, gen.GetRiverReadingsParams{
+			Gaugeid: gauge.ID,
+			Limit:   limit,
+			Offset:  int32(offset),
+		})
+		if err != nil {
+			return nil, err
+		}
+		dbReadings = rows
 	}
 
 	readings := make([]domain.RiverReading, len(dbReadings))
@@ -61,7 +125,158 @@ func (r *RiverRepo) GetReadings(ctx context.Context, params domain.GetReadingsPa
 		readings[i] = domain.RiverReading{
 			Timestamp: dbReading.Timestamp,
 			Level:     dbReading.Level,
+			GaugeName: params.GaugeName,
 		}
 	}
 	return readings, nil
 }
+
+// StreamReadingsPage passes a page of river level readings for a gauge to fn as it is read, after its bounds to start
+func (r *RiverRepo) StreamReadingsPage(ctx context.Context, params domain.GetRiverParams, start func(domain.PageBounds) error, fn func(domain.RiverReading) error) error {
+	gauge, err := r.getGaugeByName(ctx, params.GaugeName)
+	if err != nil {
+		return err
+	}
+
+	args := pageArgs(gauge.ID, params.GetReadingsParams)
+	return streamPage(ctx, timedDB{db: r.db}, riverReadingsPageQuery, args, start, fn, func(rows *sql.Rows, bounds *domain.PageBounds) (domain.RiverReading, error) {
+		reading := domain.RiverReading{GaugeName: params.GaugeName}
+		err := rows.Scan(&reading.Timestamp, &reading.Level, &bounds.Count, &bounds.Last)
+		return reading, err
+	})
+}
+
+// CountReadings returns the number of river level readings for a gauge matching the date filters
+func (r *RiverRepo) CountReadings(ctx context.Context, params domain.GetRiverParams) (int64, error) {
+	gauge, err := r.getGaugeByName(ctx, params.GaugeName)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case params.StartDate != nil && params.EndDate != nil:
+		return r.queries.CountRiverReadingsInDateRange(ctx, gen.CountRiverReadingsInDateRangeParams{
+			Gaugeid:   gauge.ID,
+			StartDate: *params.StartDate,
+			EndDate:   *params.EndDate,
+		})
+	case params.StartDate != nil:
+		return r.queries.CountRiverReadingsWithStartDate(ctx, gen.CountRiverReadingsWithStartDateParams{
+			Gaugeid:   gauge.ID,
+			Timestamp: *params.StartDate,
+		})
+	case params.EndDate != nil:
+		return r.queries.CountRiverReadingsWithEndDate(ctx, gen.CountRiverReadingsWithEndDateParams{
+			Gaugeid:   gauge.ID,
+			Timestamp: *params.EndDate,
+		})
+	default:
+		return r.queries.CountRiverReadings(ctx, gauge.ID)
+	}
+}
+
+// GetAggregates returns river levels for a gauge grouped into time buckets
+func (r *RiverRepo) GetAggregates(ctx context.Context, params domain.GetRiverAggregateParams) ([]domain.AggregateBucket, error) {
+	gauge, err := r.getGaugeByName(ctx, params.GaugeName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := countRowsOf(ctx, r.queries.GetRiverAggregates, gen.GetRiverAggregatesParams{
+		Interval:  params.Interval,
+		Gaugeid:   gauge.ID,
+		StartDate: nullTime(params.StartDate),
+		EndDate:   nullTime(params.EndDate),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toAggregateBuckets(rows, params.Func), nil
+}
+
+// GetLatestReading returns the most recent river level reading for a gauge
+func (r *RiverRepo) GetLatestReading(ctx context.Context, gaugeName string) (domain.RiverReading, error) {
+	gauge, err := r.getGaugeByName(ctx, gaugeName)
+	if err != nil {
+		return domain.RiverReading{}, err
+	}
+
+	dbReading, err := r.queries.GetLatestRiverReading(ctx, gauge.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.RiverReading{}, domain.ErrNotFound
+		}
+		return domain.RiverReading{}, err
+	}
+
+	return domain.RiverReading{
+		Timestamp: dbReading.Timestamp,
+		Level:     dbReading.Level,
+		GaugeName: gaugeName,
+	}, nil
+}
+
+// StreamReadings passes every river level reading for a gauge within the date filters to fn through a server-side cursor
+func (r *RiverRepo) StreamReadings(ctx context.Context, params domain.ExportRiverParams, fn func(domain.RiverReading) error) error {
+	gauge, err := r.getGaugeByName(ctx, params.GaugeName)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{gauge.ID, nullTime(params.StartDate), nullTime(params.EndDate)}
+	return streamCursor(ctx, r.db, exportRiverReadingsQuery, args, func(rows *sql.Rows) error {
+		reading := domain.RiverReading{GaugeName: params.GaugeName}
+		if err := rows.Scan(&reading.Timestamp, &reading.Level); err != nil {
+			return err
+		}
+		return fn(reading)
+	})
+}
+
+// UpsertReadings writes a batch of readings for a gauge in one transaction
+func (r *RiverRepo) UpsertReadings(ctx context.Context, gaugeName string, readings []domain.Measurement) error {
+	gauge, err := r.getGaugeByName(ctx, gaugeName)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(q *gen.Queries) error {
+		for _, m := range readings {
+			err := q.UpsertRiverReading(ctx, gen.UpsertRiverReadingParams{
+				Gaugeid:   gauge.ID,
+				Timestamp: m.Timestamp.UTC(),
+				Level:     m.Level,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// getGaugeByName returns gauge information by name (internal helper for validation)
+func (r *RiverRepo) getGaugeByName(ctx context.Context, gaugeName string) (*domain.Gauge, error) {
+	return lookupGauge(ctx, r.queries, gaugeName)
+}
+
+// lookupGauge resolves a gauge name to its row, mapping a missing or reserved gauge to ErrNotFound
+func lookupGauge(ctx context.Context, queries *gen.Queries, gaugeName string) (*domain.Gauge, error) {
+	if slices.Contains(constants.ReservedGaugeNames, gaugeName) {
+		return nil, domain.ErrNotFound
+	}
+	dbGauge, err := queries.GetGaugeByName(ctx, gaugeName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &domain.Gauge{
+		ID:    dbGauge.ID,
+		Name:  dbGauge.Name,
+		River: dbGauge.River,
+	}, nil
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository/postgres/gen"
+)
+
+// Migration 010 adds hourly and daily rainfall rollups. Writes mark the hours they touch as pending
+// in the same transaction as the readings, and RefreshRollups recomputes them. The refresh is plain
+// SQL rather than sqlc as it works through a temporary table of the hours it took.
+const (
+	takePendingRollupsQuery = `WITH taken AS (
+    DELETE FROM rainfall_rollup_pending RETURNING stationid, hour
+)
+INSERT INTO rollup_refresh (stationid, hour)
+SELECT stationid, hour FROM taken`
+
+	deleteRefreshedHoursQuery = `DELETE FROM rainfall_hourly h
+USING rollup_refresh r
+WHERE h.stationid = r.stationid AND h.hour = r.hour`
+
+	refreshHoursQuery = `INSERT INTO rainfall_hourly (stationid, hour, total, maximum, minimum, sample_count)
+SELECT f.stationid, date_trunc('hour', f.timestamp), SUM(f.level), MAX(f.level), MIN(f.level), COUNT(*)
+FROM rainfalls f
+JOIN rollup_refresh r ON f.stationid = r.stationid AND f.timestamp >= r.hour AND f.timestamp < r.hour + interval '1 hour'
+GROUP BY 1, 2`
+
+	deleteRefreshedDaysQuery = `DELETE FROM rainfall_daily d
+USING (SELECT DISTINCT stationid, date_trunc('day', hour) AS day FROM rollup_refresh) r
+WHERE d.stationid = r.stationid AND d.day = r.day`
+
+	refreshDaysQuery = `INSERT INTO rainfall_daily (stationid, day, total, maximum, minimum, sample_count)
+SELECT h.stationid, date_trunc('day', h.hour), SUM(h.total), MAX(h.maximum), MIN(h.minimum), SUM(h.sample_count)
+FROM rainfall_hourly h
+JOIN (SELECT DISTINCT stationid, date_trunc('day', hour) AS day FROM rollup_refresh) r
+  ON h.stationid = r.stationid AND h.hour >= r.day AND h.hour < r.day + interval '1 day'
+GROUP BY 1, 2`
+
+	rebuildHoursQuery = `INSERT INTO rainfall_hourly (stationid, hour, total, maximum, minimum, sample_count)
+SELECT stationid, date_trunc('hour', timestamp), SUM(level), MAX(level), MIN(level), COUNT(*)
+FROM rainfalls
+GROUP BY 1, 2`
+
+	rebuildDaysQuery = `INSERT INTO rainfall_daily (stationid, day, total, maximum, minimum, sample_count)
+SELECT stationid, date_trunc('day', hour), SUM(total), MAX(maximum), MIN(minimum), SUM(sample_count)
+FROM rainfall_hourly
+GROUP BY 1, 2`
+)
+
+// RefreshRollups recomputes the hourly and daily rainfall rollups for every hour written to since
+// the last refresh, and returns how many station hours that was. Hours written while it runs stay
+// pending for the next refresh.
+func RefreshRollups(ctx context.Context, db *sql.DB) (int64, error) {
+	var refreshed int64
+	err := withRollupLock(ctx, db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "CREATE TEMPORARY TABLE rollup_refresh (LIKE rainfall_rollup_pending) ON COMMIT DROP"); err != nil {
+			return err
+		}
+		var err error
+		if refreshed, err = execRows(ctx, tx, takePendingRollupsQuery); err != nil || refreshed == 0 {
+			return err
+		}
+		for _, query := range []string{deleteRefreshedHoursQuery, refreshHoursQuery, deleteRefreshedDaysQuery, refreshDaysQuery} {
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return refreshed, nil
+}
+
+// RebuildRollups recomputes every rainfall rollup from the raw readings, for readings written
+// with plain SQL that never marked their hours as pending
+func RebuildRollups(ctx context.Context, db *sql.DB) error {
+	return withRollupLock(ctx, db, func(tx *sql.Tx) error {
+		for _, query := range []string{"TRUNCATE rainfall_hourly, rainfall_daily, rainfall_rollup_pending", rebuildHoursQuery, rebuildDaysQuery} {
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withRollupLock runs fn in a transaction holding an advisory lock, so refreshes in other
+// processes don't recompute the same hours at once
+func withRollupLock(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", "rollup "+rainfallsTable); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// markRollupsPending records the hours of a station's readings for the next refresh
+func markRollupsPending(ctx context.Context, q *gen.Queries, stationID string, readings []domain.Measurement) error {
+	if len(readings) == 0 {
+		return nil
+	}
+	seen := make(map[time.Time]bool)
+	var hours []time.Time
+	for _, m := range readings {
+		hour := m.Timestamp.UTC().Truncate(time.Hour)
+		if !seen[hour] {
+			seen[hour] = true
+			hours = append(hours, hour)
+		}
+	}
+	return q.MarkRainfallRollupsPending(ctx, gen.MarkRainfallRollupsPendingParams{
+		Stationid: stationID,
+		Hours:     hours,
+	})
+}
+
+// rollupAggregates answers an aggregate from the coarsest rollup its date bounds line up with,
+// reporting false when neither does or some of the hours it covers haven't been refreshed yet
+func (r *RainfallRepo) rollupAggregates(ctx context.Context, stationID string, params domain.AggregateParams) ([]gen.GetRiverAggregatesRow, bool, error) {
+	daily := params.Interval != domain.IntervalHour && alignedTo(params, 24*time.Hour)
+	if !daily && !alignedTo(params, time.Hour) {
+		return nil, false, nil
+	}
+
+	pending, err := r.queries.HasPendingRainfallRollups(ctx, gen.HasPendingRainfallRollupsParams{
+		Stationid: stationID,
+		StartDate: nullTime(params.StartDate),
+		EndDate:   nullTime(params.EndDate),
+	})
+	if err != nil || pending {
+		return nil, false, err
+	}
+
+	var rows []gen.GetRiverAggregatesRow
+	if daily {
+		dbRows, err := countRowsOf(ctx, r.queries.GetRainfallDailyAggregatesByStation, gen.GetRainfallDailyAggregatesByStationParams{
+			Interval:  params.Interval,
+			Stationid: stationID,
+			StartDate: nullTime(params.StartDate),
+			EndDate:   nullTime(params.EndDate),
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		for _, row := range dbRows {
+			rows = append(rows, gen.GetRiverAggregatesRow(row))
+		}
+	} else {
+		dbRows, err := countRowsOf(ctx, r.queries.GetRainfallHourlyAggregatesByStation, gen.GetRainfallHourlyAggregatesByStationParams{
+			Interval:  params.Interval,
+			Stationid: stationID,
+			StartDate: nullTime(params.StartDate),
+			EndDate:   nullTime(params.EndDate),
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		for _, row := range dbRows {
+			rows = append(rows, gen.GetRiverAggregatesRow(row))
+		}
+	}
+	return rows, true, nil
+}
+
+// alignedTo reports whether the date bounds fall on the boundaries of a rollup's buckets, as the
+// rollups can't answer for part of a bucket
+func alignedTo(params domain.AggregateParams, bucket time.Duration) bool {
+	for _, bound := range []*time.Time{params.StartDate, params.EndDate} {
+		if bound != nil && !bound.UTC().Truncate(bucket).Equal(*bound) {
+			return false
+		}
+	}
+	return true
+}
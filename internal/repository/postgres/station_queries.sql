@@ -0,0 +1,21 @@
+-- name: ListStationSummaries :many
+-- List all stations with the span and number of their rainfall readings
+SELECT s.id, s.name,
+       MIN(r.timestamp) AS first_reading,
+       MAX(r.timestamp) AS last_reading,
+       COUNT(r.timestamp) AS reading_count
+FROM stationnames s
+LEFT JOIN rainfalls r ON r.stationid = s.id
+GROUP BY s.id, s.name
+ORDER BY s.name ASC;
+
+-- name: GetStationSummaryByName :one
+-- Get a station by name with the span and number of its rainfall readings
+SELECT s.id, s.name,
+       MIN(r.timestamp) AS first_reading,
+       MAX(r.timestamp) AS last_reading,
+       COUNT(r.timestamp) AS reading_count
+FROM stationnames s
+LEFT JOIN rainfalls r ON r.stationid = s.id
+WHERE s.name = $1
+GROUP BY s.id, s.name;
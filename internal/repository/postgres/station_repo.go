@@ -0,0 +1,65 @@
+//go:generate sqlc generate
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+	"github.com/oliverslade/flood-api/internal/repository/postgres/gen"
+)
+
+type StationRepo struct {
+	queries *gen.Queries
+}
+
+func NewStationRepo(db *sql.DB) repository.StationRepository {
+	return &StationRepo{
+		queries: newQueries(db),
+	}
+}
+
+// ListStations returns all stations with a summary of their rainfall readings
+func (r *StationRepo) ListStations(ctx context.Context) ([]domain.StationSummary, error) {
+	dbStations, err := countRows(ctx, r.queries.ListStationSummaries)
+	if err != nil {
+		return nil, err
+	}
+
+	stations := make([]domain.StationSummary, len(dbStations))
+	for i, dbStation := range dbStations {
+		stations[i] = toStationSummary(gen.GetStationSummaryByNameRow(dbStation))
+	}
+	return stations, nil
+}
+
+// GetStation returns a summary of a single station's rainfall readings
+func (r *StationRepo) GetStation(ctx context.Context, stationName string) (domain.StationSummary, error) {
+	dbStation, err := r.queries.GetStationSummaryByName(ctx, stationName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.StationSummary{}, domain.ErrNotFound
+		}
+		return domain.StationSummary{}, err
+	}
+
+	return toStationSummary(dbStation), nil
+}
+
+func toStationSummary(dbStation gen.GetStationSummaryByNameRow) domain.StationSummary {
+	summary := domain.StationSummary{
+		ID:           dbStation.ID,
+		Name:         dbStation.Name,
+		ReadingCount: dbStation.ReadingCount,
+	}
+	// stations without readings have no first or last timestamp
+	if dbStation.FirstReading.Valid {
+		summary.FirstReading = &dbStation.FirstReading.Time
+	}
+	if dbStation.LastReading.Valid {
+		summary.LastReading = &dbStation.LastReading.Time
+	}
+	return summary
+}
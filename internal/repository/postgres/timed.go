@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/oliverslade/flood-api/internal/metrics"
+	"github.com/oliverslade/flood-api/internal/repository/postgres/gen"
+	"github.com/oliverslade/flood-api/internal/tracing"
+)
+
+// timedDB records how long each sqlc query takes, by the name in the "-- name: GetX :many" comment
+// every generated query starts with, and traces it as a span of that name under the caller's span.
+// Queries returning rows are timed until their first row. Exec spans carry the rows affected, and
+// the spans of queries run through countRows the rows returned.
+type timedDB struct {
+	db gen.DBTX
+}
+
+// newQueries returns sqlc queries that run against db and record their durations and spans
+func newQueries(db gen.DBTX) *gen.Queries {
+	return gen.New(timedDB{db: db})
+}
+
+func (t timedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, run := startQuery(ctx, query)
+	result, err := t.db.ExecContext(ctx, query, args...)
+	if err == nil {
+		if n, err := result.RowsAffected(); err == nil {
+			run.span.SetAttributes(tracing.RowCountKey.Int64(n))
+		}
+	}
+	run.end(err)
+	return result, err
+}
+
+func (t timedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.db.PrepareContext(ctx, query)
+}
+
+func (t timedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, run := startQuery(ctx, query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	run.end(err)
+	return rows, err
+}
+
+func (t timedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, run := startQuery(ctx, query)
+	row := t.db.QueryRowContext(ctx, query, args...)
+	run.end(row.Err())
+	return row
+}
+
+// queryRun is a query in flight
+type queryRun struct {
+	name    string
+	start   time.Time
+	span    trace.Span
+	counted bool // the span is left for a rowCount to end
+}
+
+func startQuery(ctx context.Context, query string) (context.Context, queryRun) {
+	name := queryName(query)
+	ctx, span := otel.Tracer("github.com/oliverslade/flood-api/internal/repository/postgres").Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperationName(name)),
+	)
+	run := queryRun{name: name, start: time.Now(), span: span}
+	if count, ok := ctx.Value(rowCountKey{}).(*rowCount); ok && count.span == nil {
+		count.span, run.counted = span, true
+	}
+	return ctx, run
+}
+
+func (q queryRun) end(err error) {
+	metrics.ObserveQuery(q.name, time.Since(q.start))
+	if err != nil && err != sql.ErrNoRows {
+		q.span.RecordError(err)
+		q.span.SetStatus(codes.Error, err.Error())
+	}
+	if !q.counted {
+		q.span.End()
+	}
+}
+
+// rowCountKey is the context key of a rowCount
+type rowCountKey struct{}
+
+// rowCount keeps the span of the first query run under it open until the rows the caller read
+// from it are counted, as the generated code reads the *sql.Rows itself
+type rowCount struct {
+	span trace.Span
+}
+
+// countRows runs a :many query, recording how many rows it returned on the query's span
+func countRows[T any](ctx context.Context, query func(context.Context) ([]T, error)) ([]T, error) {
+	count := &rowCount{}
+	result, err := query(context.WithValue(ctx, rowCountKey{}, count))
+	count.end(len(result), err)
+	return result, err
+}
+
+// countRowsOf is countRows for a query taking an argument
+func countRowsOf[A, T any](ctx context.Context, query func(context.Context, A) ([]T, error), arg A) ([]T, error) {
+	return countRows(ctx, func(ctx context.Context) ([]T, error) {
+		return query(ctx, arg)
+	})
+}
+
+func (c *rowCount) end(n int, err error) {
+	if c.span == nil {
+		return
+	}
+	if err == nil {
+		c.span.SetAttributes(tracing.RowCountKey.Int(n))
+	}
+	c.span.End()
+}
+
+// queryName is the sqlc name of a generated query
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "unnamed"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/oliverslade/flood-api/internal/repository/postgres/gen"
+)
+
+// withTx runs fn with queries bound to a transaction, committing only if fn succeeds so a failed batch leaves nothing behind
+func withTx(ctx context.Context, db *sql.DB, fn func(q *gen.Queries) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(newQueries(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
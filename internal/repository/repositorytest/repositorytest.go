@@ -0,0 +1,584 @@
+// Package repositorytest checks that every storage backend implements the repository interfaces
+// with the same ordering, paging, date filtering and not found semantics. Each backend runs Run
+// from its own tests with a constructor that loads the seed data into a fresh store.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+)
+
+// Repositories are the stores of one backend under test
+type Repositories struct {
+	River    repository.RiverRepository
+	Rainfall repository.RainfallRepository
+	Station  repository.StationRepository
+}
+
+// Constructor returns repositories holding data. It is called for every test, which may write to
+// them, so each call must start again from data alone.
+type Constructor func(t *testing.T, data inmemory.Dataset) Repositories
+
+// Run checks the repositories returned by newRepos against what data says they should hold
+func Run(t *testing.T, newRepos Constructor, data inmemory.Dataset) {
+	river, rainfall := expectedSeries(data)
+
+	t.Run("River", func(t *testing.T) {
+		for _, gauge := range data.Gauges {
+			t.Run(gauge.Name, func(t *testing.T) {
+				testSeries(t, river[gauge.Name], func(t *testing.T) series {
+					return riverSeries(t, newRepos(t, data).River, gauge.Name)
+				})
+			})
+		}
+		t.Run("unknown gauge", func(t *testing.T) {
+			testUnknownGauge(t, newRepos(t, data).River)
+		})
+	})
+
+	t.Run("Rainfall", func(t *testing.T) {
+		for _, station := range data.Stations {
+			t.Run(station.Name, func(t *testing.T) {
+				testSeries(t, rainfall[station.Name], func(t *testing.T) series {
+					return rainfallSeries(t, newRepos(t, data).Rainfall, station.Name)
+				})
+			})
+		}
+		t.Run("unknown station", func(t *testing.T) {
+			testUnknownStation(t, newRepos(t, data).Rainfall)
+		})
+		t.Run("several stations", func(t *testing.T) {
+			testStations(t, newRepos(t, data).Rainfall, data)
+		})
+		t.Run("latest of every station", func(t *testing.T) {
+			testLatestReadings(t, newRepos(t, data).Rainfall, rainfall)
+		})
+	})
+
+	t.Run("Stations", func(t *testing.T) {
+		testStationSummaries(t, newRepos(t, data).Station, data, rainfall)
+	})
+}
+
+// series reads and writes the readings of one gauge or station, so both are checked the same way
+type series struct {
+	get    func(params domain.GetReadingsParams) ([]domain.Measurement, error)
+	count  func(start, end *time.Time) (int64, error)
+	stream func(start, end *time.Time) ([]domain.Measurement, error)
+	latest func() (domain.Measurement, error)
+	upsert func(readings []domain.Measurement) error
+}
+
+func riverSeries(t *testing.T, repo repository.RiverRepository, gauge string) series {
+	ctx := context.Background()
+	measurement := func(reading domain.RiverReading) domain.Measurement {
+		assert.Equal(t, gauge, reading.GaugeName)
+		return domain.Measurement{Timestamp: reading.Timestamp.UTC(), Level: reading.Level}
+	}
+
+	return series{
+		get: func(params domain.GetReadingsParams) ([]domain.Measurement, error) {
+			readings, err := repo.GetReadings(ctx, domain.GetRiverParams{GaugeName: gauge, GetReadingsParams: params})
+			if err != nil {
+				return nil, err
+			}
+			require.NotNil(t, readings, "an empty page should be an empty slice")
+			measurements := []domain.Measurement{}
+			for _, reading := range readings {
+				measurements = append(measurements, measurement(reading))
+			}
+
+			// the page streamed as it is read holds the same readings
+			page := &pageRecorder{t: t}
+			streamed := []domain.Measurement{}
+			err = repo.StreamReadingsPage(ctx, domain.GetRiverParams{GaugeName: gauge, GetReadingsParams: params}, page.start, func(reading domain.RiverReading) error {
+				page.readings++
+				streamed = append(streamed, measurement(reading))
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, measurements, streamed, "streamed page")
+			page.assertBounds(measurements)
+			return measurements, nil
+		},
+		count: func(start, end *time.Time) (int64, error) {
+			return repo.CountReadings(ctx, domain.GetRiverParams{GaugeName: gauge, GetReadingsParams: domain.GetReadingsParams{StartDate: start, EndDate: end}})
+		},
+		stream: func(start, end *time.Time) ([]domain.Measurement, error) {
+			measurements := []domain.Measurement{}
+			err := repo.StreamReadings(ctx, domain.ExportRiverParams{GaugeName: gauge, ExportParams: domain.ExportParams{StartDate: start, EndDate: end}}, func(reading domain.RiverReading) error {
+				measurements = append(measurements, measurement(reading))
+				return nil
+			})
+			return measurements, err
+		},
+		latest: func() (domain.Measurement, error) {
+			reading, err := repo.GetLatestReading(ctx, gauge)
+			if err != nil {
+				return domain.Measurement{}, err
+			}
+			return measurement(reading), nil
+		},
+		upsert: func(readings []domain.Measurement) error {
+			return repo.UpsertReadings(ctx, gauge, readings)
+		},
+	}
+}
+
+func rainfallSeries(t *testing.T, repo repository.RainfallRepository, station string) series {
+	ctx := context.Background()
+	measurement := func(reading domain.RainfallReading) domain.Measurement {
+		assert.Equal(t, station, reading.StationName)
+		return domain.Measurement{Timestamp: reading.Timestamp.UTC(), Level: reading.Level}
+	}
+
+	return series{
+		get: func(params domain.GetReadingsParams) ([]domain.Measurement, error) {
+			readings, err := repo.GetReadingsByStation(ctx, domain.GetRainfallParams{StationName: station, GetReadingsParams: params})
+			if err != nil {
+				return nil, err
+			}
+			require.NotNil(t, readings, "an empty page should be an empty slice")
+			measurements := []domain.Measurement{}
+			for _, reading := range readings {
+				measurements = append(measurements, measurement(reading))
+			}
+
+			// the page streamed as it is read holds the same readings
+			page := &pageRecorder{t: t}
+			streamed := []domain.Measurement{}
+			err = repo.StreamReadingsPageByStation(ctx, domain.GetRainfallParams{StationName: station, GetReadingsParams: params}, page.start, func(reading domain.RainfallReading) error {
+				page.readings++
+				streamed = append(streamed, measurement(reading))
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, measurements, streamed, "streamed page")
+			page.assertBounds(measurements)
+			return measurements, nil
+		},
+		count: func(start, end *time.Time) (int64, error) {
+			return repo.CountReadingsByStation(ctx, domain.GetRainfallParams{StationName: station, GetReadingsParams: domain.GetReadingsParams{StartDate: start, EndDate: end}})
+		},
+		stream: func(start, end *time.Time) ([]domain.Measurement, error) {
+			measurements := []domain.Measurement{}
+			err := repo.StreamReadingsByStation(ctx, domain.ExportRainfallParams{StationName: station, ExportParams: domain.ExportParams{StartDate: start, EndDate: end}}, func(reading domain.RainfallReading) error {
+				measurements = append(measurements, measurement(reading))
+				return nil
+			})
+			return measurements, err
+		},
+		latest: func() (domain.Measurement, error) {
+			reading, err := repo.GetLatestReadingByStation(ctx, station)
+			if err != nil {
+				return domain.Measurement{}, err
+			}
+			return measurement(reading), nil
+		},
+		upsert: func(readings []domain.Measurement) error {
+			return repo.UpsertReadingsByStation(ctx, station, readings)
+		},
+	}
+}
+
+// testSeries checks one gauge or station holds want, in order, however it is paged or filtered
+func testSeries(t *testing.T, want []domain.Measurement, open func(t *testing.T) series) {
+	all := domain.PaginationParams{Page: 1, PageSize: len(want) + 1}
+
+	t.Run("orders readings by timestamp", func(t *testing.T) {
+		got, err := open(t).get(domain.GetReadingsParams{Pagination: all})
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("splits readings into pages", func(t *testing.T) {
+		s := open(t)
+		for size := 1; size <= 3; size++ {
+			pages := (len(want) + size - 1) / size
+			var got []domain.Measurement
+			for page := 1; page <= pages; page++ {
+				readings, err := s.get(domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: page, PageSize: size}})
+				require.NoError(t, err)
+				assert.Len(t, readings, min(size, len(want)-(page-1)*size), "page %d of size %d", page, size)
+				got = append(got, readings...)
+			}
+			assert.Equal(t, want, append([]domain.Measurement{}, got...), "pages of size %d", size)
+		}
+	})
+
+	t.Run("returns an empty page past the last", func(t *testing.T) {
+		s := open(t)
+		for _, page := range []int{len(want) + 1, len(want) + 10} {
+			got, err := s.get(domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: page, PageSize: 1}})
+			require.NoError(t, err)
+			assert.Empty(t, got, "page %d", page)
+		}
+	})
+
+	t.Run("includes the start date and excludes the end date", func(t *testing.T) {
+		s := open(t)
+		for i, reading := range want {
+			ts := reading.Timestamp
+			assertFiltered(t, s, all, &ts, nil, want[i:])
+			assertFiltered(t, s, all, nil, &ts, want[:i])
+
+			// a date between readings falls on neither side
+			between := ts.Add(time.Second)
+			assertFiltered(t, s, all, &between, nil, want[i+1:])
+			assertFiltered(t, s, all, nil, &between, want[:i+1])
+		}
+		if len(want) > 2 {
+			assertFiltered(t, s, all, &want[1].Timestamp, &want[len(want)-1].Timestamp, want[1:len(want)-1])
+		}
+	})
+
+	t.Run("pages after a keyset cursor", func(t *testing.T) {
+		s := open(t)
+		for i, reading := range want {
+			after := reading.Timestamp
+			got, err := s.get(domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: 1, PageSize: 2, After: &after}})
+			require.NoError(t, err)
+			assert.Equal(t, want[i+1:min(i+3, len(want))], got, "after %s", after)
+		}
+
+		// the cursor wins over a page number but keeps to the start date, whichever comes later
+		if len(want) > 2 {
+			after := want[0].Timestamp
+			start := want[len(want)-1].Timestamp
+			got, err := s.get(domain.GetReadingsParams{
+				Pagination: domain.PaginationParams{Page: 3, PageSize: len(want), After: &after},
+				StartDate:  &start,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, want[len(want)-1:], got, "start after the cursor")
+
+			after, start = want[1].Timestamp, want[0].Timestamp
+			got, err = s.get(domain.GetReadingsParams{
+				Pagination: domain.PaginationParams{Page: 3, PageSize: len(want), After: &after},
+				StartDate:  &start,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, want[2:], got, "start before the cursor")
+		}
+	})
+
+	t.Run("returns the latest reading", func(t *testing.T) {
+		got, err := open(t).latest()
+		if len(want) == 0 {
+			assert.ErrorIs(t, err, domain.ErrNotFound)
+			return
+		}
+		require.NoError(t, err)
+		assert.Equal(t, want[len(want)-1], got)
+	})
+
+	t.Run("upserts readings in timestamp order", func(t *testing.T) {
+		s := open(t)
+		earliest := time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC)
+		written := []domain.Measurement{{Timestamp: earliest, Level: 0.05}}
+		expected := append([]domain.Measurement{{Timestamp: earliest, Level: 0.05}}, want...)
+		if len(want) > 0 {
+			// the same instant in another zone replaces the stored reading
+			written = append(written, domain.Measurement{Timestamp: want[0].Timestamp.In(time.FixedZone("+01:00", 3600)), Level: 9.5})
+			expected[1].Level = 9.5
+		}
+
+		require.NoError(t, s.upsert(written))
+		require.NoError(t, s.upsert(written))
+
+		got, err := s.get(domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: 1, PageSize: len(expected) + 1}})
+		require.NoError(t, err)
+		assert.Equal(t, expected, got)
+	})
+}
+
+// pageRecorder checks a page stream passes its bounds to start once, before any reading
+type pageRecorder struct {
+	t        *testing.T
+	bounds   []domain.PageBounds
+	readings int
+}
+
+func (p *pageRecorder) start(bounds domain.PageBounds) error {
+	assert.Zero(p.t, p.readings, "bounds should come before the first reading")
+	bounds.Last = bounds.Last.UTC()
+	p.bounds = append(p.bounds, bounds)
+	return nil
+}
+
+func (p *pageRecorder) assertBounds(want []domain.Measurement) {
+	p.t.Helper()
+	expected := domain.PageBounds{Count: len(want)}
+	if len(want) > 0 {
+		expected.Last = want[len(want)-1].Timestamp
+	}
+	assert.Equal(p.t, []domain.PageBounds{expected}, p.bounds)
+}
+
+// assertFiltered checks the read, count and stream of s agree on the readings between start and end
+func assertFiltered(t *testing.T, s series, page domain.PaginationParams, start, end *time.Time, want []domain.Measurement) {
+	t.Helper()
+	want = append([]domain.Measurement{}, want...)
+
+	got, err := s.get(domain.GetReadingsParams{Pagination: page, StartDate: start, EndDate: end})
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "read from %v to %v", start, end)
+
+	count, err := s.count(start, end)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(want)), count, "count from %v to %v", start, end)
+
+	streamed, err := s.stream(start, end)
+	require.NoError(t, err)
+	assert.Equal(t, want, streamed, "stream from %v to %v", start, end)
+}
+
+func testUnknownGauge(t *testing.T, repo repository.RiverRepository) {
+	ctx := context.Background()
+	page := domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: 1, PageSize: 10}}
+
+	_, err := repo.GetReadings(ctx, domain.GetRiverParams{GaugeName: "nonexistent", GetReadingsParams: page})
+	assert.ErrorIs(t, err, domain.ErrNotFound, "GetReadings")
+	_, err = repo.CountReadings(ctx, domain.GetRiverParams{GaugeName: "nonexistent"})
+	assert.ErrorIs(t, err, domain.ErrNotFound, "CountReadings")
+	_, err = repo.GetAggregates(ctx, domain.GetRiverAggregateParams{GaugeName: "nonexistent", AggregateParams: domain.AggregateParams{Interval: domain.IntervalDay, Func: domain.AggregateMean}})
+	assert.ErrorIs(t, err, domain.ErrNotFound, "GetAggregates")
+	_, err = repo.GetLatestReading(ctx, "nonexistent")
+	assert.ErrorIs(t, err, domain.ErrNotFound, "GetLatestReading")
+	err = repo.StreamReadings(ctx, domain.ExportRiverParams{GaugeName: "nonexistent"}, func(domain.RiverReading) error { return nil })
+	assert.ErrorIs(t, err, domain.ErrNotFound, "StreamReadings")
+	err = repo.StreamReadingsPage(ctx, domain.GetRiverParams{GaugeName: "nonexistent", GetReadingsParams: page}, (&pageRecorder{t: t}).start, func(domain.RiverReading) error { return nil })
+	assert.ErrorIs(t, err, domain.ErrNotFound, "StreamReadingsPage")
+	err = repo.UpsertReadings(ctx, "nonexistent", []domain.Measurement{{Timestamp: time.Now(), Level: 1}})
+	assert.ErrorIs(t, err, domain.ErrNotFound, "UpsertReadings")
+}
+
+func testUnknownStation(t *testing.T, repo repository.RainfallRepository) {
+	ctx := context.Background()
+	page := domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: 1, PageSize: 10}}
+
+	_, err := repo.GetReadingsByStation(ctx, domain.GetRainfallParams{StationName: "nonexistent", GetReadingsParams: page})
+	assert.ErrorIs(t, err, domain.ErrNotFound, "GetReadingsByStation")
+	_, err = repo.CountReadingsByStation(ctx, domain.GetRainfallParams{StationName: "nonexistent"})
+	assert.ErrorIs(t, err, domain.ErrNotFound, "CountReadingsByStation")
+	_, err = repo.GetAggregatesByStation(ctx, domain.GetRainfallAggregateParams{StationName: "nonexistent", AggregateParams: domain.AggregateParams{Interval: domain.IntervalDay, Func: domain.AggregateMean}})
+	assert.ErrorIs(t, err, domain.ErrNotFound, "GetAggregatesByStation")
+	_, err = repo.GetLatestReadingByStation(ctx, "nonexistent")
+	assert.ErrorIs(t, err, domain.ErrNotFound, "GetLatestReadingByStation")
+	err = repo.StreamReadingsByStation(ctx, domain.ExportRainfallParams{StationName: "nonexistent"}, func(domain.RainfallReading) error { return nil })
+	assert.ErrorIs(t, err, domain.ErrNotFound, "StreamReadingsByStation")
+	err = repo.StreamReadingsPageByStation(ctx, domain.GetRainfallParams{StationName: "nonexistent", GetReadingsParams: page}, (&pageRecorder{t: t}).start, func(domain.RainfallReading) error { return nil })
+	assert.ErrorIs(t, err, domain.ErrNotFound, "StreamReadingsPageByStation")
+	err = repo.UpsertReadingsByStation(ctx, "nonexistent", []domain.Measurement{{Timestamp: time.Now(), Level: 1}})
+	assert.ErrorIs(t, err, domain.ErrNotFound, "UpsertReadingsByStation")
+}
+
+// testStations checks readings of several stations are merged by timestamp then station id
+func testStations(t *testing.T, repo repository.RainfallRepository, data inmemory.Dataset) {
+	ctx := context.Background()
+	ids := map[string]string{}
+	for _, station := range data.Stations {
+		ids[station.Name] = station.ID
+	}
+	merged := make([]domain.RainfallReading, 0, len(data.Rainfall))
+	for _, reading := range data.Rainfall {
+		reading.Timestamp = reading.Timestamp.UTC()
+		merged = append(merged, reading)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if !merged[i].Timestamp.Equal(merged[j].Timestamp) {
+			return merged[i].Timestamp.Before(merged[j].Timestamp)
+		}
+		return ids[merged[i].StationName] < ids[merged[j].StationName]
+	})
+
+	read := func(params domain.GetRainfallStationsParams) []domain.RainfallReading {
+		t.Helper()
+		readings, err := repo.GetReadingsByStations(ctx, params)
+		require.NoError(t, err)
+		require.NotNil(t, readings, "an empty page should be an empty slice")
+		measurements := make([]domain.Measurement, len(readings))
+		for i := range readings {
+			readings[i].Timestamp = readings[i].Timestamp.UTC()
+			measurements[i] = domain.Measurement{Timestamp: readings[i].Timestamp, Level: readings[i].Level}
+		}
+
+		page := &pageRecorder{t: t}
+		streamed := []domain.RainfallReading{}
+		err = repo.StreamReadingsPageByStations(ctx, params, page.start, func(reading domain.RainfallReading) error {
+			page.readings++
+			reading.Timestamp = reading.Timestamp.UTC()
+			streamed = append(streamed, reading)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, readings, streamed, "streamed page")
+		page.assertBounds(measurements)
+		return readings
+	}
+
+	t.Run("merges every station in order", func(t *testing.T) {
+		var got []domain.RainfallReading
+		pages := len(merged)/3 + 1
+		for page := 1; page <= pages; page++ {
+			got = append(got, read(domain.GetRainfallStationsParams{GetReadingsParams: domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: page, PageSize: 3}}})...)
+		}
+		assert.Equal(t, merged, append([]domain.RainfallReading{}, got...))
+		assert.Empty(t, read(domain.GetRainfallStationsParams{GetReadingsParams: domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: pages + 1, PageSize: 3}}}))
+
+		count, err := repo.CountReadingsByStations(ctx, domain.GetRainfallStationsParams{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(merged)), count)
+	})
+
+	t.Run("filters by station and date", func(t *testing.T) {
+		for _, station := range data.Stations {
+			for _, reading := range merged {
+				start := reading.Timestamp
+				want := []domain.RainfallReading{}
+				for _, r := range merged {
+					if r.StationName == station.Name && !r.Timestamp.Before(start) {
+						want = append(want, r)
+					}
+				}
+
+				params := domain.GetRainfallStationsParams{
+					StationNames: []string{station.Name},
+					GetReadingsParams: domain.GetReadingsParams{
+						Pagination: domain.PaginationParams{Page: 1, PageSize: len(merged) + 1},
+						StartDate:  &start,
+					},
+				}
+				assert.Equal(t, want, read(params), "%s from %s", station.Name, start)
+
+				count, err := repo.CountReadingsByStations(ctx, params)
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(want)), count, "%s from %s", station.Name, start)
+			}
+		}
+	})
+
+	t.Run("reports every unknown station", func(t *testing.T) {
+		params := domain.GetRainfallStationsParams{
+			StationNames:      []string{data.Stations[0].Name, "nonexistent", "missing"},
+			GetReadingsParams: domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: 1, PageSize: 10}},
+		}
+
+		var unknown *domain.UnknownStationsError
+		_, err := repo.GetReadingsByStations(ctx, params)
+		require.True(t, errors.As(err, &unknown), "got %v", err)
+		assert.Equal(t, []string{"nonexistent", "missing"}, unknown.Names)
+
+		_, err = repo.CountReadingsByStations(ctx, params)
+		require.True(t, errors.As(err, &unknown), "got %v", err)
+		assert.Equal(t, []string{"nonexistent", "missing"}, unknown.Names)
+
+		err = repo.StreamReadingsPageByStations(ctx, params, (&pageRecorder{t: t}).start, func(domain.RainfallReading) error { return nil })
+		require.True(t, errors.As(err, &unknown), "got %v", err)
+		assert.Equal(t, []string{"nonexistent", "missing"}, unknown.Names)
+	})
+}
+
+func testLatestReadings(t *testing.T, repo repository.RainfallRepository, rainfall map[string][]domain.Measurement) {
+	want := []domain.RainfallReading{}
+	for name, readings := range rainfall {
+		if len(readings) > 0 {
+			latest := readings[len(readings)-1]
+			want = append(want, domain.RainfallReading{Timestamp: latest.Timestamp, Level: latest.Level, StationName: name})
+		}
+	}
+	sort.Slice(want, func(i, j int) bool {
+		return want[i].StationName < want[j].StationName
+	})
+
+	got, err := repo.GetLatestReadings(context.Background())
+	require.NoError(t, err)
+	for i := range got {
+		got[i].Timestamp = got[i].Timestamp.UTC()
+	}
+	assert.Equal(t, want, got)
+}
+
+func testStationSummaries(t *testing.T, repo repository.StationRepository, data inmemory.Dataset, rainfall map[string][]domain.Measurement) {
+	ctx := context.Background()
+	want := []domain.StationSummary{}
+	for _, station := range data.Stations {
+		summary := domain.StationSummary{ID: station.ID, Name: station.Name}
+		if readings := rainfall[station.Name]; len(readings) > 0 {
+			summary.FirstReading = &readings[0].Timestamp
+			summary.LastReading = &readings[len(readings)-1].Timestamp
+			summary.ReadingCount = int64(len(readings))
+		}
+		want = append(want, summary)
+	}
+	sort.Slice(want, func(i, j int) bool {
+		return want[i].Name < want[j].Name
+	})
+
+	got, err := repo.ListStations(ctx)
+	require.NoError(t, err)
+	for i := range got {
+		got[i] = utcSummary(got[i])
+	}
+	assert.Equal(t, want, got)
+
+	for _, summary := range want {
+		got, err := repo.GetStation(ctx, summary.Name)
+		require.NoError(t, err)
+		assert.Equal(t, summary, utcSummary(got))
+	}
+
+	_, err = repo.GetStation(ctx, "nonexistent")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func utcSummary(summary domain.StationSummary) domain.StationSummary {
+	if summary.FirstReading != nil {
+		first := summary.FirstReading.UTC()
+		summary.FirstReading = &first
+	}
+	if summary.LastReading != nil {
+		last := summary.LastReading.UTC()
+		summary.LastReading = &last
+	}
+	return summary
+}
+
+// expectedSeries returns the readings of every gauge and station in data in timestamp order, the
+// ones without readings included as empty series
+func expectedSeries(data inmemory.Dataset) (river, rainfall map[string][]domain.Measurement) {
+	river = map[string][]domain.Measurement{}
+	for _, gauge := range data.Gauges {
+		river[gauge.Name] = []domain.Measurement{}
+	}
+	for _, reading := range data.River {
+		river[reading.GaugeName] = append(river[reading.GaugeName], domain.Measurement{Timestamp: reading.Timestamp.UTC(), Level: reading.Level})
+	}
+
+	rainfall = map[string][]domain.Measurement{}
+	for _, station := range data.Stations {
+		rainfall[station.Name] = []domain.Measurement{}
+	}
+	for _, reading := range data.Rainfall {
+		rainfall[reading.StationName] = append(rainfall[reading.StationName], domain.Measurement{Timestamp: reading.Timestamp.UTC(), Level: reading.Level})
+	}
+
+	for _, series := range []map[string][]domain.Measurement{river, rainfall} {
+		for _, readings := range series {
+			sort.Slice(readings, func(i, j int) bool {
+				return readings[i].Timestamp.Before(readings[j].Timestamp)
+			})
+		}
+	}
+	return river, rainfall
+}
@@ -0,0 +1,81 @@
+package repositorytest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+)
+
+// Seed returns a small dataset with the cases backends tend to disagree on: readings stored out of
+// order, readings on page and month boundaries, stations sharing a timestamp whose ids sort the
+// other way to their names, and a station and gauge without any readings
+func Seed() inmemory.Dataset {
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	return inmemory.Dataset{
+		Stations: []domain.Station{
+			{ID: "200", Name: "alpha"},
+			{ID: "100", Name: "bravo"},
+			{ID: "300", Name: "charlie"},
+		},
+		Gauges: []domain.Gauge{
+			{ID: "rede-bridge", Name: "rede-bridge", River: "River Rede"},
+			{ID: "kielder", Name: "kielder", River: "North Tyne"},
+			{ID: "falstone", Name: "falstone", River: "North Tyne"},
+		},
+		Rainfall: []domain.RainfallReading{
+			{Timestamp: at(time.January, 31, 23, 45), Level: 0.4, StationName: "alpha"},
+			{Timestamp: at(time.January, 1, 0, 0), Level: 0.1, StationName: "alpha"},
+			{Timestamp: at(time.February, 1, 0, 0), Level: 0.5, StationName: "alpha"},
+			{Timestamp: at(time.January, 1, 0, 15), Level: 0.2, StationName: "alpha"},
+			{Timestamp: at(time.January, 15, 12, 0), Level: 0.3, StationName: "alpha"},
+			{Timestamp: at(time.January, 1, 0, 30), Level: 1.2, StationName: "bravo"},
+			{Timestamp: at(time.January, 1, 0, 0), Level: 1.1, StationName: "bravo"},
+			{Timestamp: at(time.February, 1, 0, 0), Level: 1.3, StationName: "bravo"},
+		},
+		River: []domain.RiverReading{
+			{Timestamp: at(time.January, 1, 12, 0), Level: 1.3, GaugeName: "rede-bridge"},
+			{Timestamp: at(time.January, 1, 9, 0), Level: 1.1, GaugeName: "rede-bridge"},
+			{Timestamp: at(time.March, 1, 0, 0), Level: 1.6, GaugeName: "rede-bridge"},
+			{Timestamp: at(time.January, 1, 10, 30), Level: 1.2, GaugeName: "rede-bridge"},
+			{Timestamp: at(time.February, 29, 23, 59), Level: 1.5, GaugeName: "rede-bridge"},
+			{Timestamp: at(time.January, 2, 0, 0), Level: 1.4, GaugeName: "rede-bridge"},
+			{Timestamp: at(time.January, 1, 9, 0), Level: 0.7, GaugeName: "kielder"},
+			{Timestamp: at(time.January, 1, 12, 0), Level: 0.8, GaugeName: "kielder"},
+		},
+	}
+}
+
+// Load inserts the stations and gauges of data into a SQL backend, using placeholder for its bind
+// parameter syntax, then writes the readings through repos so each backend stores them its own way.
+// Gauges the backend already has are left as they are.
+func Load(t testing.TB, db *sql.DB, placeholder func(n int) string, repos Repositories, data inmemory.Dataset) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, station := range data.Stations {
+		_, err := db.ExecContext(ctx, "INSERT INTO stationnames (id, name) VALUES ("+placeholder(1)+", "+placeholder(2)+")", station.ID, station.Name)
+		require.NoError(t, err)
+	}
+	for _, gauge := range data.Gauges {
+		_, err := db.ExecContext(ctx, "INSERT INTO rivergauges (id, name, river) VALUES ("+placeholder(1)+", "+placeholder(2)+", "+placeholder(3)+") ON CONFLICT DO NOTHING", gauge.ID, gauge.Name, gauge.River)
+		require.NoError(t, err)
+	}
+
+	for _, reading := range data.Rainfall {
+		err := repos.Rainfall.UpsertReadingsByStation(ctx, reading.StationName, []domain.Measurement{{Timestamp: reading.Timestamp, Level: reading.Level}})
+		require.NoError(t, err)
+	}
+	for _, reading := range data.River {
+		err := repos.River.UpsertReadings(ctx, reading.GaugeName, []domain.Measurement{{Timestamp: reading.Timestamp, Level: reading.Level}})
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// bucketExpressions truncate a text timestamp like Postgres date_trunc, weeks start on Monday
+var bucketExpressions = map[string]string{
+	domain.IntervalHour:  "strftime('%Y-%m-%d %H:00:00', timestamp)",
+	domain.IntervalDay:   "strftime('%Y-%m-%d 00:00:00', timestamp)",
+	domain.IntervalWeek:  "strftime('%Y-%m-%d 00:00:00', timestamp, 'weekday 0', '-6 days')",
+	domain.IntervalMonth: "strftime('%Y-%m-01 00:00:00', timestamp)",
+}
+
+// queryAggregates groups the readings of table matching filter into time buckets. Every statistic is
+// computed in one pass and the requested one picked out, as the Postgres repositories do.
+func queryAggregates(ctx context.Context, db *sql.DB, table string, filter *readingFilter, params domain.AggregateParams) ([]domain.AggregateBucket, error) {
+	bucket, ok := bucketExpressions[params.Interval]
+	if !ok {
+		bucket = bucketExpressions[domain.IntervalDay]
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT `+bucket+` AS bucket,
+       SUM(level), AVG(level), MAX(level), MIN(level), COUNT(*)
+FROM `+table+filter.where()+`
+GROUP BY bucket
+ORDER BY bucket ASC`, filter.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []domain.AggregateBucket{}
+	for rows.Next() {
+		var start string
+		var total, mean, maximum, minimum float64
+		var b domain.AggregateBucket
+		if err := rows.Scan(&start, &total, &mean, &maximum, &minimum, &b.Count); err != nil {
+			return nil, err
+		}
+		if b.Start, err = parseTimestamp(start); err != nil {
+			return nil, err
+		}
+
+		switch params.Func {
+		case domain.AggregateSum:
+			b.Value = total
+		case domain.AggregateMax:
+			b.Value = maximum
+		case domain.AggregateMin:
+			b.Value = minimum
+		default:
+			b.Value = mean
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
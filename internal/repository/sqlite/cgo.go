@@ -0,0 +1,6 @@
+//go:build cgo
+
+package sqlite
+
+// cgoBuild reports whether the mattn/go-sqlite3 driver was built with cgo and can open databases
+const cgoBuild = true
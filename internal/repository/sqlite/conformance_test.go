@@ -0,0 +1,30 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+	"github.com/oliverslade/flood-api/internal/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T, data inmemory.Dataset) repositorytest.Repositories {
+		path := filepath.Join(t.TempDir(), "flood.db")
+		require.NoError(t, Migrate(context.Background(), path))
+		db, err := Open(context.Background(), path, false)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		repos := repositorytest.Repositories{
+			River:    NewRiverRepo(db),
+			Rainfall: NewRainfallRepo(db),
+			Station:  NewStationRepo(db),
+		}
+		repositorytest.Load(t, db, func(int) string { return "?" }, repos, data)
+		return repos
+	}, repositorytest.Seed())
+}
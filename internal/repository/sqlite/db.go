@@ -0,0 +1,321 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/oliverslade/flood-api/internal/constants"
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+// validReading skips the rows of the challenge data that migrations 003 and 004 couldn't convert
+const validReading = "timestamp <> '' AND level IS NOT NULL"
+
+// timestampLayout is how the challenge data stores timestamps as text. Stored timestamps are
+// compared and ordered as text, which is only correct because every field is zero padded and
+// every row uses this layout.
+const timestampLayout = "2006-01-02 15:04:05.999999999"
+
+// errNoCGO is returned when the binary was built without cgo, which the SQLite driver needs
+var errNoCGO = errors.New("sqlite backend needs a cgo build")
+
+// parseLayouts also accepts the T separated form some exports of the challenge data use. Those rows
+// sort after every space separated row of the same day, so Migrate rewrites them to
+// timestampLayout before any are compared.
+var parseLayouts = []string{
+	timestampLayout,
+	"2006-01-02T15:04:05.999999999",
+}
+
+// tables and indexes bring the challenge tables up to what the repositories need, the same way
+// migrations 002 to 007 do for Postgres. The timestamp and level columns keep their text and real
+// types, with T separated timestamps normalised to timestampLayout, and every existing river level
+// belongs to the default gauge.
+var (
+	tables = []string{
+		`CREATE TABLE IF NOT EXISTS stationnames (id text, name text)`,
+		`CREATE TABLE IF NOT EXISTS rainfalls (stationid text, timestamp text, level real)`,
+		`CREATE TABLE IF NOT EXISTS riverlevels (timestamp text, level real)`,
+		`CREATE TABLE IF NOT EXISTS rivergauges (
+    id text NOT NULL PRIMARY KEY,
+    name text NOT NULL UNIQUE CHECK (name NOT IN ('latest', 'aggregate')),
+    river text NOT NULL
+)`,
+		`INSERT OR IGNORE INTO rivergauges (id, name, river) VALUES ('` + constants.DefaultRiverGauge + `', '` + constants.DefaultRiverGauge + `', 'River Rede')`,
+		`UPDATE rainfalls SET timestamp = replace(timestamp, 'T', ' ') WHERE instr(timestamp, 'T') = 11`,
+		`UPDATE riverlevels SET timestamp = replace(timestamp, 'T', ' ') WHERE instr(timestamp, 'T') = 11`,
+	}
+
+	indexes = []string{
+		`CREATE INDEX IF NOT EXISTS idx_rainfalls_station_timestamp ON rainfalls (stationid, timestamp)`,
+		`CREATE INDEX IF NOT EXISTS idx_riverlevels_gauge_timestamp ON riverlevels (gaugeid, timestamp)`,
+		`CREATE INDEX IF NOT EXISTS idx_stationnames_name ON stationnames (name)`,
+	}
+)
+
+// Migrate brings the challenge SQLite file at path up to what the repositories need, creating it if
+// it doesn't exist: the river gauge dimension, normalised timestamps and the read indexes. It can be
+// run again safely. Indexing a full copy of the challenge data takes a while the first time.
+func Migrate(ctx context.Context, path string) error {
+	if !cgoBuild {
+		return errNoCGO
+	}
+	db, err := sql.Open("sqlite3", dsn(path, "rwc"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := prepareSchema(ctx, db); err != nil {
+		return fmt.Errorf("preparing %s: %w", path, err)
+	}
+	return db.Close()
+}
+
+// Open opens a SQLite file that Migrate has prepared, read only unless writes are needed. It never
+// changes the schema, and fails when the file is missing or hasn't been migrated.
+func Open(ctx context.Context, path string, readOnly bool) (*sql.DB, error) {
+	if !cgoBuild {
+		return nil, errNoCGO
+	}
+	mode := "rw"
+	if readOnly {
+		mode = "ro"
+	}
+	db, err := sql.Open("sqlite3", dsn(path, mode))
+	if err != nil {
+		return nil, err
+	}
+
+	var prepared bool
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) = 2 FROM (
+    SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'rivergauges'
+    UNION ALL
+    SELECT 1 FROM pragma_table_info('riverlevels') WHERE name = 'gaugeid'
+)`).Scan(&prepared)
+	if err == nil && !prepared {
+		err = errors.New("it hasn't been migrated, run flood-api migrate up -backend=sqlite -sqlite " + path)
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// dsn names the file at path opened in mode, which is ro, rw or rwc to create it
+func dsn(path, mode string) string {
+	return (&url.URL{Scheme: "file", Opaque: path, RawQuery: "mode=" + mode + "&_busy_timeout=5000&_txlock=immediate"}).String()
+}
+
+func prepareSchema(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range tables {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	var hasGauge bool
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) > 0 FROM pragma_table_info('riverlevels') WHERE name = 'gaugeid'`).Scan(&hasGauge); err != nil {
+		return err
+	}
+	if !hasGauge {
+		stmt := `ALTER TABLE riverlevels ADD COLUMN gaugeid text NOT NULL DEFAULT '` + constants.DefaultRiverGauge + `'`
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	// the gauge column has to exist before its index is created
+	for _, stmt := range indexes {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+// parseTimestamp reads a stored timestamp as UTC, matching the naive timestamps Postgres returns
+func parseTimestamp(s string) (time.Time, error) {
+	for _, layout := range parseLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("timestamp %q is not a datetime", s)
+}
+
+// scanMeasurements runs a query returning timestamp and level columns and passes each row to fn,
+// stopping at the first error fn returns
+func scanMeasurements(ctx context.Context, db *sql.DB, query string, args []interface{}, fn func(domain.Measurement) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts string
+		var m domain.Measurement
+		if err := rows.Scan(&ts, &m.Level); err != nil {
+			return err
+		}
+		if m.Timestamp, err = parseTimestamp(ts); err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// pageQuery wraps a query for a page of timestamp, level and key columns so every row also carries
+// the page's row count and last timestamp, which the window functions work out over the page alone
+func pageQuery(query, order string) string {
+	return "SELECT *, count(*) OVER (), max(timestamp) OVER () FROM (" + query + ") ORDER BY " + order
+}
+
+// streamPage runs a pageQuery, passing the bounds on the first row to start, or empty bounds when
+// there are no rows, before each measurement and its key reach fn
+func streamPage(ctx context.Context, db *sql.DB, query string, args []interface{}, start func(domain.PageBounds) error, fn func(key string, m domain.Measurement) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	started := false
+	for rows.Next() {
+		var ts, key, last string
+		var m domain.Measurement
+		var bounds domain.PageBounds
+		if err := rows.Scan(&ts, &m.Level, &key, &bounds.Count, &last); err != nil {
+			return err
+		}
+		if m.Timestamp, err = parseTimestamp(ts); err != nil {
+			return err
+		}
+		if !started {
+			started = true
+			if bounds.Last, err = parseTimestamp(last); err != nil {
+				return err
+			}
+			if err := start(bounds); err != nil {
+				return err
+			}
+		}
+		if err := fn(key, m); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if !started {
+		return start(domain.PageBounds{})
+	}
+	return nil
+}
+
+// readingFilter builds the WHERE clause shared by the reading queries of both tables
+type readingFilter struct {
+	conditions []string
+	args       []interface{}
+}
+
+// newReadingFilter selects the valid readings of one station or gauge
+func newReadingFilter(keyColumn, key string) *readingFilter {
+	f := &readingFilter{}
+	f.add(keyColumn+" = ?", key)
+	f.add(validReading)
+	return f
+}
+
+func (f *readingFilter) add(condition string, args ...interface{}) {
+	f.conditions = append(f.conditions, condition)
+	f.args = append(f.args, args...)
+}
+
+// dates adds the inclusive start and exclusive end filters, either of which may be nil
+func (f *readingFilter) dates(start, end *time.Time) {
+	if start != nil {
+		f.add("timestamp >= ?", formatTimestamp(*start))
+	}
+	if end != nil {
+		f.add("timestamp < ?", formatTimestamp(*end))
+	}
+}
+
+// page adds the filters for a page of readings. A keyset cursor takes precedence over the offset
+// but still keeps to the start date, like the Postgres queries.
+func (f *readingFilter) page(params domain.GetReadingsParams) string {
+	f.dates(params.StartDate, params.EndDate)
+	if params.Pagination.After != nil {
+		f.add("timestamp > ?", formatTimestamp(*params.Pagination.After))
+		return fmt.Sprintf(" LIMIT %d", params.Pagination.PageSize)
+	}
+
+	offset := (params.Pagination.Page - 1) * params.Pagination.PageSize
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", params.Pagination.PageSize, offset)
+}
+
+func (f *readingFilter) where() string {
+	return " WHERE " + strings.Join(f.conditions, " AND ")
+}
+
+// placeholders returns n comma separated bind parameters for an IN list
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// withTx runs fn in a transaction, committing only if it succeeds
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// upsertReading replaces the level stored at a timestamp, or adds the reading when there isn't one.
+// The challenge tables have no unique constraint to upsert against, and may hold duplicate rows.
+func upsertReading(ctx context.Context, tx *sql.Tx, table, keyColumn, key string, m domain.Measurement) error {
+	ts := formatTimestamp(m.Timestamp)
+	res, err := tx.ExecContext(ctx, "UPDATE "+table+" SET level = ? WHERE "+keyColumn+" = ? AND timestamp = ?", m.Level, key, ts)
+	if err != nil {
+		return err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated > 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO "+table+" ("+keyColumn+", timestamp, level) VALUES (?, ?, ?)", key, ts, m.Level)
+	return err
+}
@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/constants"
+	"github.com/oliverslade/flood-api/internal/domain"
+)
+
+func TestMigrateUpgradesChallengeFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "challenge.db")
+
+	// the challenge file has no gauges, and some rows migrations 003 and 004 would reject
+	challenge, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		`CREATE TABLE riverlevels (timestamp text, level real)`,
+		`INSERT INTO riverlevels VALUES ('2024-01-01 09:00:00', 0.5), ('2024-01-01 10:00:00', NULL), ('', 0.7)`,
+	} {
+		_, err := challenge.ExecContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, challenge.Close())
+
+	_, err = Open(ctx, path, true)
+	assert.ErrorContains(t, err, "hasn't been migrated")
+
+	// migrating twice checks the upgrade can be repeated
+	for i := 0; i < 2; i++ {
+		require.NoError(t, Migrate(ctx, path))
+		db, err := Open(ctx, path, true)
+		require.NoError(t, err)
+
+		readings, err := NewRiverRepo(db).GetReadings(ctx, domain.GetRiverParams{
+			GaugeName:         constants.DefaultRiverGauge,
+			GetReadingsParams: domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: 1, PageSize: 10}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.RiverReading{
+			{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 0.5, GaugeName: constants.DefaultRiverGauge},
+		}, readings)
+		require.NoError(t, db.Close())
+	}
+}
+
+func TestMigrateReservesRouteGaugeNames(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "flood.db")
+	require.NoError(t, Migrate(ctx, path))
+
+	db, err := Open(ctx, path, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, name := range constants.ReservedGaugeNames {
+		_, err := db.ExecContext(ctx, "INSERT INTO rivergauges (id, name, river) VALUES (?, ?, 'River Rede')", name, name)
+		assert.ErrorContains(t, err, "CHECK constraint failed", name)
+	}
+}
+
+func TestMigrateNormalisesMixedTimestamps(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "challenge.db")
+
+	// compared as text, T09:00 would sort after 10:00 and pass a 09:30 start filter
+	challenge, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		`CREATE TABLE riverlevels (timestamp text, level real)`,
+		`INSERT INTO riverlevels VALUES ('2024-01-01T09:00:00', 0.5), ('2024-01-01 10:00:00', 0.6), ('2024-01-01T11:00:00.5', 0.7)`,
+	} {
+		_, err := challenge.ExecContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, challenge.Close())
+
+	require.NoError(t, Migrate(ctx, path))
+	db, err := Open(ctx, path, true)
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewRiverRepo(db)
+
+	params := domain.GetRiverParams{
+		GaugeName:         constants.DefaultRiverGauge,
+		GetReadingsParams: domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: 1, PageSize: 10}},
+	}
+	readings, err := repo.GetReadings(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.RiverReading{
+		{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 0.5, GaugeName: constants.DefaultRiverGauge},
+		{Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Level: 0.6, GaugeName: constants.DefaultRiverGauge},
+		{Timestamp: time.Date(2024, 1, 1, 11, 0, 0, 500000000, time.UTC), Level: 0.7, GaugeName: constants.DefaultRiverGauge},
+	}, readings)
+
+	start, end := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC), time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	params.StartDate, params.EndDate = &start, &end
+	readings, err = repo.GetReadings(ctx, params)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.RiverReading{
+		{Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), Level: 0.6, GaugeName: constants.DefaultRiverGauge},
+	}, readings)
+
+	latest, err := repo.GetLatestReading(ctx, constants.DefaultRiverGauge)
+	require.NoError(t, err)
+	assert.Equal(t, 0.7, latest.Level)
+}
+
+func TestOpenLeavesFileUnchanged(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "flood.db")
+
+	_, err := Open(ctx, path, false)
+	assert.Error(t, err, "a missing file isn't created")
+	assert.NoFileExists(t, path)
+
+	require.NoError(t, Migrate(ctx, path))
+	db, err := Open(ctx, path, true)
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = NewRiverRepo(db).UpsertReadings(ctx, constants.DefaultRiverGauge, []domain.Measurement{{Timestamp: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), Level: 0.5}})
+	assert.ErrorContains(t, err, "readonly")
+}
@@ -0,0 +1,7 @@
+//go:build !cgo
+
+package sqlite
+
+// The mattn/go-sqlite3 driver is a stub without cgo, so a CGO_ENABLED=0 binary still builds for the
+// other backends but Open and Migrate refuse to run
+const cgoBuild = false
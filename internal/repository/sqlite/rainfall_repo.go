@@ -0,0 +1,291 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+)
+
+type RainfallRepo struct {
+	db *sql.DB
+}
+
+func NewRainfallRepo(db *sql.DB) repository.RainfallRepository {
+	return &RainfallRepo{db: db}
+}
+
+// GetReadingsByStation returns rainfall readings for a specific station
+func (r *RainfallRepo) GetReadingsByStation(ctx context.Context, params domain.GetRainfallParams) ([]domain.RainfallReading, error) {
+	station, err := r.getStationByName(ctx, params.StationName)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := newReadingFilter("stationid", station.ID)
+	limit := filter.page(params.GetReadingsParams)
+	readings := []domain.RainfallReading{}
+	err = r.scanReadings(ctx, "SELECT timestamp, level FROM rainfalls"+filter.where()+" ORDER BY timestamp ASC"+limit, filter.args, params.StationName, func(reading domain.RainfallReading) error {
+		readings = append(readings, reading)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return readings, nil
+}
+
+// StreamReadingsPageByStation passes a page of rainfall readings for a station to fn as it is read, after its bounds to start
+func (r *RainfallRepo) StreamReadingsPageByStation(ctx context.Context, params domain.GetRainfallParams, start func(domain.PageBounds) error, fn func(domain.RainfallReading) error) error {
+	station, err := r.getStationByName(ctx, params.StationName)
+	if err != nil {
+		return err
+	}
+
+	filter := newReadingFilter("stationid", station.ID)
+	limit := filter.page(params.GetReadingsParams)
+	query := pageQuery("SELECT timestamp, level, stationid FROM rainfalls"+filter.where()+" ORDER BY timestamp ASC"+limit, "timestamp ASC")
+	return streamPage(ctx, r.db, query, filter.args, start, func(_ string, m domain.Measurement) error {
+		return fn(domain.RainfallReading{Timestamp: m.Timestamp, Level: m.Level, StationName: params.StationName})
+	})
+}
+
+// CountReadingsByStation returns the number of rainfall readings for a station matching the date filters
+func (r *RainfallRepo) CountReadingsByStation(ctx context.Context, params domain.GetRainfallParams) (int64, error) {
+	station, err := r.getStationByName(ctx, params.StationName)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := newReadingFilter("stationid", station.ID)
+	filter.dates(params.StartDate, params.EndDate)
+
+	var count int64
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM rainfalls"+filter.where(), filter.args...).Scan(&count)
+	return count, err
+}
+
+// GetReadingsByStations returns rainfall readings for several stations merged in chronological order
+func (r *RainfallRepo) GetReadingsByStations(ctx context.Context, params domain.GetRainfallStationsParams) ([]domain.RainfallReading, error) {
+	stationNames, filter, err := r.stationsFilter(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := (params.Pagination.Page - 1) * params.Pagination.PageSize
+	rows, err := r.db.QueryContext(ctx, "SELECT timestamp, level, stationid FROM rainfalls"+filter.where()+
+		" ORDER BY timestamp ASC, stationid ASC LIMIT ? OFFSET ?", append(filter.args, params.Pagination.PageSize, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	readings := []domain.RainfallReading{}
+	for rows.Next() {
+		var ts, stationID string
+		reading := domain.RainfallReading{}
+		if err := rows.Scan(&ts, &reading.Level, &stationID); err != nil {
+			return nil, err
+		}
+		if reading.Timestamp, err = parseTimestamp(ts); err != nil {
+			return nil, err
+		}
+		reading.StationName = stationNames[stationID]
+		readings = append(readings, reading)
+	}
+	return readings, rows.Err()
+}
+
+// StreamReadingsPageByStations passes a page of the merged rainfall readings of several stations to fn as it is read, after its bounds to start
+func (r *RainfallRepo) StreamReadingsPageByStations(ctx context.Context, params domain.GetRainfallStationsParams, start func(domain.PageBounds) error, fn func(domain.RainfallReading) error) error {
+	stationNames, filter, err := r.stationsFilter(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	offset := (params.Pagination.Page - 1) * params.Pagination.PageSize
+	query := pageQuery("SELECT timestamp, level, stationid FROM rainfalls"+filter.where()+
+		" ORDER BY timestamp ASC, stationid ASC LIMIT ? OFFSET ?", "timestamp ASC, stationid ASC")
+	return streamPage(ctx, r.db, query, append(filter.args, params.Pagination.PageSize, offset), start, func(stationID string, m domain.Measurement) error {
+		return fn(domain.RainfallReading{Timestamp: m.Timestamp, Level: m.Level, StationName: stationNames[stationID]})
+	})
+}
+
+// CountReadingsByStations returns the number of rainfall readings for several stations matching the date filters
+func (r *RainfallRepo) CountReadingsByStations(ctx context.Context, params domain.GetRainfallStationsParams) (int64, error) {
+	_, filter, err := r.stationsFilter(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM rainfalls"+filter.where(), filter.args...).Scan(&count)
+	return count, err
+}
+
+// GetAggregatesByStation returns rainfall readings for a station grouped into time buckets
+func (r *RainfallRepo) GetAggregatesByStation(ctx context.Context, params domain.GetRainfallAggregateParams) ([]domain.AggregateBucket, error) {
+	station, err := r.getStationByName(ctx, params.StationName)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := newReadingFilter("stationid", station.ID)
+	filter.dates(params.StartDate, params.EndDate)
+	return queryAggregates(ctx, r.db, "rainfalls", filter, params.AggregateParams)
+}
+
+// GetLatestReadingByStation returns the most recent rainfall reading for a station
+func (r *RainfallRepo) GetLatestReadingByStation(ctx context.Context, stationName string) (domain.RainfallReading, error) {
+	station, err := r.getStationByName(ctx, stationName)
+	if err != nil {
+		return domain.RainfallReading{}, err
+	}
+
+	filter := newReadingFilter("stationid", station.ID)
+	var latest *domain.RainfallReading
+	err = r.scanReadings(ctx, "SELECT timestamp, level FROM rainfalls"+filter.where()+" ORDER BY timestamp DESC LIMIT 1", filter.args, stationName, func(reading domain.RainfallReading) error {
+		latest = &reading
+		return nil
+	})
+	if err != nil {
+		return domain.RainfallReading{}, err
+	}
+	if latest == nil {
+		return domain.RainfallReading{}, domain.ErrNotFound
+	}
+	return *latest, nil
+}
+
+// GetLatestReadings returns the most recent rainfall reading of every station.
+// SQLite takes the bare level column from the row that has the MAX timestamp.
+func (r *RainfallRepo) GetLatestReadings(ctx context.Context) ([]domain.RainfallReading, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT s.name, MAX(r.timestamp), r.level
+FROM stationnames s
+JOIN rainfalls r ON r.stationid = s.id
+WHERE r.timestamp <> '' AND r.level IS NOT NULL
+GROUP BY s.id, s.name
+ORDER BY s.name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	readings := []domain.RainfallReading{}
+	for rows.Next() {
+		var ts string
+		reading := domain.RainfallReading{}
+		if err := rows.Scan(&reading.StationName, &ts, &reading.Level); err != nil {
+			return nil, err
+		}
+		if reading.Timestamp, err = parseTimestamp(ts); err != nil {
+			return nil, err
+		}
+		readings = append(readings, reading)
+	}
+	return readings, rows.Err()
+}
+
+// StreamReadingsByStation passes every rainfall reading for a station within the date filters to fn as it is read
+func (r *RainfallRepo) StreamReadingsByStation(ctx context.Context, params domain.ExportRainfallParams, fn func(domain.RainfallReading) error) error {
+	station, err := r.getStationByName(ctx, params.StationName)
+	if err != nil {
+		return err
+	}
+
+	filter := newReadingFilter("stationid", station.ID)
+	filter.dates(params.StartDate, params.EndDate)
+	return r.scanReadings(ctx, "SELECT timestamp, level FROM rainfalls"+filter.where()+" ORDER BY timestamp ASC", filter.args, params.StationName, fn)
+}
+
+// UpsertReadingsByStation writes a batch of readings for a station in one transaction
+func (r *RainfallRepo) UpsertReadingsByStation(ctx context.Context, stationName string, readings []domain.Measurement) error {
+	station, err := r.getStationByName(ctx, stationName)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		for _, m := range readings {
+			if err := upsertReading(ctx, tx, "rainfalls", "stationid", station.ID, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// stationsFilter resolves the requested station names, or every station when they are nil, and
+// selects their valid readings within the date filters. Any names that don't exist are reported
+// together in an UnknownStationsError.
+func (r *RainfallRepo) stationsFilter(ctx context.Context, params domain.GetRainfallStationsParams) (map[string]string, *readingFilter, error) {
+	query := "SELECT id, name FROM stationnames WHERE id IS NOT NULL AND name IS NOT NULL"
+	args := make([]interface{}, len(params.StationNames))
+	for i, name := range params.StationNames {
+		args[i] = name
+	}
+	if params.StationNames != nil {
+		query += " AND name IN (" + placeholders(len(args)) + ")"
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	stationNames := map[string]string{}
+	found := map[string]bool{}
+	for rows.Next() {
+		var station domain.Station
+		if err := rows.Scan(&station.ID, &station.Name); err != nil {
+			return nil, nil, err
+		}
+		stationNames[station.ID] = station.Name
+		found[station.Name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var unknown []string
+	for _, name := range params.StationNames {
+		if !found[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, nil, &domain.UnknownStationsError{Names: unknown}
+	}
+
+	ids := make([]interface{}, 0, len(stationNames))
+	for id := range stationNames {
+		ids = append(ids, id)
+	}
+	filter := &readingFilter{}
+	filter.add("stationid IN ("+placeholders(len(ids))+")", ids...)
+	filter.add(validReading)
+	filter.dates(params.StartDate, params.EndDate)
+	return stationNames, filter, nil
+}
+
+// scanReadings runs a query returning timestamp and level columns and passes each row to fn
+func (r *RainfallRepo) scanReadings(ctx context.Context, query string, args []interface{}, stationName string, fn func(domain.RainfallReading) error) error {
+	return scanMeasurements(ctx, r.db, query, args, func(m domain.Measurement) error {
+		return fn(domain.RainfallReading{Timestamp: m.Timestamp, Level: m.Level, StationName: stationName})
+	})
+}
+
+// getStationByName returns station information by name (internal helper for validation)
+func (r *RainfallRepo) getStationByName(ctx context.Context, stationName string) (*domain.Station, error) {
+	var station domain.Station
+	err := r.db.QueryRowContext(ctx, "SELECT id, name FROM stationnames WHERE name = ?", stationName).Scan(&station.ID, &station.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &station, nil
+}
@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/oliverslade/flood-api/internal/constants"
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+)
+
+type RiverRepo struct {
+	db *sql.DB
+}
+
+func NewRiverRepo(db *sql.DB) repository.RiverRepository {
+	return &RiverRepo{db: db}
+}
+
+// GetReadings returns river level readings for a gauge with pagination and optional date filtering
+func (r *RiverRepo) GetReadings(ctx context.Context, params domain.GetRiverParams) ([]domain.RiverReading, error) {
+	gauge, err := r.getGaugeByName(ctx, params.GaugeName)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := newReadingFilter("gaugeid", gauge.ID)
+	limit := filter.page(params.GetReadingsParams)
+	readings := []domain.RiverReading{}
+	err = r.scanReadings(ctx, "SELECT timestamp, level FROM riverlevels"+filter.where()+" ORDER BY timestamp ASC"+limit, filter.args, params.GaugeName, func(reading domain.RiverReading) error {
+		readings = append(readings, reading)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return readings, nil
+}
+
+// StreamReadingsPage passes a page of river level readings for a gauge to fn as it is read, after its bounds to start
+func (r *RiverRepo) StreamReadingsPage(ctx context.Context, params domain.GetRiverParams, start func(domain.PageBounds) error, fn func(domain.RiverReading) error) error {
+	gauge, err := r.getGaugeByName(ctx, params.GaugeName)
+	if err != nil {
+		return err
+	}
+
+	filter := newReadingFilter("gaugeid", gauge.ID)
+	limit := filter.page(params.GetReadingsParams)
+	query := pageQuery("SELECT timestamp, level, gaugeid FROM riverlevels"+filter.where()+" ORDER BY timestamp ASC"+limit, "timestamp ASC")
+	return streamPage(ctx, r.db, query, filter.args, start, func(_ string, m domain.Measurement) error {
+		return fn(domain.RiverReading{Timestamp: m.Timestamp, Level: m.Level, GaugeName: params.GaugeName})
+	})
+}
+
+// CountReadings returns the number of river level readings for a gauge matching the date filters
+func (r *RiverRepo) CountReadings(ctx context.Context, params domain.GetRiverParams) (int64, error) {
+	gauge, err := r.getGaugeByName(ctx, params.GaugeName)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := newReadingFilter("gaugeid", gauge.ID)
+	filter.dates(params.StartDate, params.EndDate)
+
+	var count int64
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM riverlevels"+filter.where(), filter.args...).Scan(&count)
+	return count, err
+}
+
+// GetAggregates returns river levels for a gauge grouped into time buckets
+func (r *RiverRepo) GetAggregates(ctx context.Context, params domain.GetRiverAggregateParams) ([]domain.AggregateBucket, error) {
+	gauge, err := r.getGaugeByName(ctx, params.GaugeName)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := newReadingFilter("gaugeid", gauge.ID)
+	filter.dates(params.StartDate, params.EndDate)
+	return queryAggregates(ctx, r.db, "riverlevels", filter, params.AggregateParams)
+}
+
+// GetLatestReading returns the most recent river level reading for a gauge
+func (r *RiverRepo) GetLatestReading(ctx context.Context, gaugeName string) (domain.RiverReading, error) {
+	gauge, err := r.getGaugeByName(ctx, gaugeName)
+	if err != nil {
+		return domain.RiverReading{}, err
+	}
+
+	filter := newReadingFilter("gaugeid", gauge.ID)
+	var latest *domain.RiverReading
+	err = r.scanReadings(ctx, "SELECT timestamp, level FROM riverlevels"+filter.where()+" ORDER BY timestamp DESC LIMIT 1", filter.args, gaugeName, func(reading domain.RiverReading) error {
+		latest = &reading
+		return nil
+	})
+	if err != nil {
+		return domain.RiverReading{}, err
+	}
+	if latest == nil {
+		return domain.RiverReading{}, domain.ErrNotFound
+	}
+	return *latest, nil
+}
+
+// StreamReadings passes every river level reading for a gauge within the date filters to fn as it is read
+func (r *RiverRepo) StreamReadings(ctx context.Context, params domain.ExportRiverParams, fn func(domain.RiverReading) error) error {
+	gauge, err := r.getGaugeByName(ctx, params.GaugeName)
+	if err != nil {
+		return err
+	}
+
+	filter := newReadingFilter("gaugeid", gauge.ID)
+	filter.dates(params.StartDate, params.EndDate)
+	return r.scanReadings(ctx, "SELECT timestamp, level FROM riverlevels"+filter.where()+" ORDER BY timestamp ASC", filter.args, params.GaugeName, fn)
+}
+
+// UpsertReadings writes a batch of readings for a gauge in one transaction
+func (r *RiverRepo) UpsertReadings(ctx context.Context, gaugeName string, readings []domain.Measurement) error {
+	gauge, err := r.getGaugeByName(ctx, gaugeName)
+	if err != nil {
+		return err
+	}
+
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		for _, m := range readings {
+			if err := upsertReading(ctx, tx, "riverlevels", "gaugeid", gauge.ID, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// scanReadings runs a query returning timestamp and level columns and passes each row to fn
+func (r *RiverRepo) scanReadings(ctx context.Context, query string, args []interface{}, gaugeName string, fn func(domain.RiverReading) error) error {
+	return scanMeasurements(ctx, r.db, query, args, func(m domain.Measurement) error {
+		return fn(domain.RiverReading{Timestamp: m.Timestamp, Level: m.Level, GaugeName: gaugeName})
+	})
+}
+
+// getGaugeByName returns gauge information by name (internal helper for validation)
+func (r *RiverRepo) getGaugeByName(ctx context.Context, gaugeName string) (*domain.Gauge, error) {
+	if slices.Contains(constants.ReservedGaugeNames, gaugeName) {
+		return nil, domain.ErrNotFound
+	}
+	var gauge domain.Gauge
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, river FROM rivergauges WHERE name = ?", gaugeName).Scan(&gauge.ID, &gauge.Name, &gauge.River)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &gauge, nil
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+)
+
+// stationSummaryQuery summarises the valid rainfall readings of each station, stations without any have a NULL span.
+// Stations missing an id or name, which migration 004 would have rejected, are skipped.
+const stationSummaryQuery = `SELECT s.id, s.name,
+       MIN(r.timestamp) AS first_reading,
+       MAX(r.timestamp) AS last_reading,
+       COUNT(r.timestamp) AS reading_count
+FROM stationnames s
+LEFT JOIN rainfalls r ON r.stationid = s.id AND r.timestamp <> '' AND r.level IS NOT NULL
+WHERE s.id IS NOT NULL AND s.name IS NOT NULL`
+
+type StationRepo struct {
+	db *sql.DB
+}
+
+func NewStationRepo(db *sql.DB) repository.StationRepository {
+	return &StationRepo{db: db}
+}
+
+// ListStations returns all stations with a summary of their rainfall readings
+func (r *StationRepo) ListStations(ctx context.Context) ([]domain.StationSummary, error) {
+	rows, err := r.db.QueryContext(ctx, stationSummaryQuery+`
+GROUP BY s.id, s.name
+ORDER BY s.name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stations := []domain.StationSummary{}
+	for rows.Next() {
+		summary, err := scanStationSummary(rows)
+		if err != nil {
+			return nil, err
+		}
+		stations = append(stations, summary)
+	}
+	return stations, rows.Err()
+}
+
+// GetStation returns a summary of a single station's rainfall readings
+func (r *StationRepo) GetStation(ctx context.Context, stationName string) (domain.StationSummary, error) {
+	summary, err := scanStationSummary(r.db.QueryRowContext(ctx, stationSummaryQuery+`
+  AND s.name = ?
+GROUP BY s.id, s.name`, stationName))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.StationSummary{}, domain.ErrNotFound
+		}
+		return domain.StationSummary{}, err
+	}
+	return summary, nil
+}
+
+func scanStationSummary(row interface{ Scan(...interface{}) error }) (domain.StationSummary, error) {
+	var summary domain.StationSummary
+	var first, last sql.NullString
+	if err := row.Scan(&summary.ID, &summary.Name, &first, &last, &summary.ReadingCount); err != nil {
+		return domain.StationSummary{}, err
+	}
+
+	// stations without readings have no first or last timestamp
+	if first.Valid {
+		ts, err := parseTimestamp(first.String)
+		if err != nil {
+			return domain.StationSummary{}, err
+		}
+		summary.FirstReading = &ts
+	}
+	if last.Valid {
+		ts, err := parseTimestamp(last.String)
+		if err != nil {
+			return domain.StationSummary{}, err
+		}
+		summary.LastReading = &ts
+	}
+	return summary, nil
+}
@@ -0,0 +1,53 @@
+package synth
+
+import (
+	"math"
+	"time"
+)
+
+// The river model is a soil store deciding how much rain runs off, and two linear reservoirs,
+// a quick one for the storm response and a slow one for baseflow, converted to a stage by a
+// rating curve. Flows are in millimetres over the catchment per step.
+const (
+	soilMM        = 120.0 // capacity of the soil store
+	dryETMMPerDay = 3.0   // evaporation from the soil in high summer, falling to nothing in midwinter
+	quickShare    = 0.8   // share of runoff taking the quick route
+	quickHours    = 8.0   // time constant of the quick reservoir
+	slowDays      = 20.0  // time constant of the slow reservoir
+	stageDatum    = 0.25  // level in metres when the river has no flow
+	stageScale    = 3.0   // rating curve level = stageDatum + stageScale * flow^stageExponent
+	stageExponent = 0.6
+)
+
+// riverLevels routes a catchment's mean rainfall through the soil and reservoirs, the runoff
+// reaching the gauge lag steps after it falls
+func riverLevels(cfg Config, rainfall []float64, lag int) []float64 {
+	hours := cfg.Interval.Hours()
+	quickSteps := quickHours / hours
+	slowSteps := slowDays * 24 / hours
+
+	runoff := make([]float64, len(rainfall))
+	soil := soilMM / 2
+	for step, rain := range rainfall {
+		wetness := soil / soilMM
+		share := 0.1 + 0.7*wetness*wetness
+		runoff[step] = rain * share
+		et := dryETMMPerDay * (1 - winterness(cfg.Start.Add(time.Duration(step)*cfg.Interval))) * hours / 24
+		soil = math.Min(soilMM, math.Max(0, soil+rain-runoff[step]-et))
+	}
+
+	levels := make([]float64, len(rainfall))
+	// start the slow reservoir at a typical baseflow rather than an empty river
+	quick, slow := 0.0, 0.002
+	for step := range levels {
+		var in float64
+		if step >= lag {
+			in = runoff[step-lag]
+		}
+		quick += (in*quickShare - quick) / quickSteps
+		slow += (in*(1-quickShare) - slow) / slowSteps
+		level := stageDatum + stageScale*math.Pow(quick+slow, stageExponent)
+		levels[step] = math.Round(level*1000) / 1000
+	}
+	return levels
+}
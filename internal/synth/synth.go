@@ -0,0 +1,145 @@
+// Package synth generates realistic rainfall and river level series for load testing. Rainfall
+// comes from regional storms that arrive in wet spells between dry ones, more often and for
+// longer in winter, and each gauge's river level responds to its catchment's rainfall after a lag.
+// The same config and seed always give the same series.
+package synth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/oliverslade/flood-api/internal/constants"
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+)
+
+// Config describes the series to generate
+type Config struct {
+	// Start is the first reading, and End the instant readings stop before
+	Start, End time.Time
+	// Interval is the spacing of readings, the 15 minutes Defra reports at by default
+	Interval time.Duration
+	// Seed picks the weather, generating again with the same seed gives the same series
+	Seed uint64
+	// Stations each get a rainfall series
+	Stations []domain.Station
+	// Catchments each get a river level series for their gauge
+	Catchments []Catchment
+}
+
+// Catchment is a river gauge and the rainfall stations upstream of it
+type Catchment struct {
+	Gauge domain.Gauge
+	// Stations are the names of the stations whose mean rainfall feeds the river
+	Stations []string
+	// Lag is how long rain takes to start raising the level at the gauge
+	Lag time.Duration
+}
+
+// Writer stores the generated series, one station or gauge at a time
+type Writer interface {
+	WriteRainfall(ctx context.Context, station domain.Station, readings []domain.Measurement) error
+	WriteRiver(ctx context.Context, gauge domain.Gauge, readings []domain.Measurement) error
+}
+
+// DefaultConfig generates three years of readings for every fixture station, with the default
+// gauge fed by the stations at the head of the Rede and North Tyne
+func DefaultConfig() Config {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	return Config{
+		Start:    start,
+		End:      start.AddDate(3, 0, 0),
+		Interval: 15 * time.Minute,
+		Seed:     1,
+		Stations: inmemory.Fixtures().Stations,
+		Catchments: []Catchment{{
+			Gauge:    domain.Gauge{ID: constants.DefaultRiverGauge, Name: constants.DefaultRiverGauge, River: "River Rede"},
+			Stations: []string{"catcleugh", "kielder-ridge-end"},
+			Lag:      6 * time.Hour,
+		}},
+	}
+}
+
+// Generate writes a rainfall series for every station, then a river level series for every
+// catchment, stopping at the first error w returns
+func Generate(ctx context.Context, cfg Config, w Writer) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	steps := int(cfg.End.Sub(cfg.Start) / cfg.Interval)
+	weather := newWeather(cfg, rand.New(rand.NewPCG(cfg.Seed, 0)), steps)
+
+	// catchment rainfall is summed as each station is generated, so only one station's readings are held at a time
+	inflow := make([][]float64, len(cfg.Catchments))
+	for i := range inflow {
+		inflow[i] = make([]float64, steps)
+	}
+
+	for i, station := range cfg.Stations {
+		rng := rand.New(rand.NewPCG(cfg.Seed, uint64(i+1)))
+		levels := weather.stationRainfall(rng)
+		for c, catchment := range cfg.Catchments {
+			if !slices.Contains(catchment.Stations, station.Name) {
+				continue
+			}
+			share := 1 / float64(len(catchment.Stations))
+			for step, level := range levels {
+				inflow[c][step] += level * share
+			}
+		}
+		if err := w.WriteRainfall(ctx, station, cfg.measurements(levels)); err != nil {
+			return fmt.Errorf("rainfall for %s: %w", station.Name, err)
+		}
+	}
+
+	for c, catchment := range cfg.Catchments {
+		lag := int(catchment.Lag / cfg.Interval)
+		levels := riverLevels(cfg, inflow[c], lag)
+		if err := w.WriteRiver(ctx, catchment.Gauge, cfg.measurements(levels)); err != nil {
+			return fmt.Errorf("river levels for %s: %w", catchment.Gauge.Name, err)
+		}
+	}
+	return nil
+}
+
+func (cfg Config) validate() error {
+	if cfg.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	if !cfg.End.After(cfg.Start) {
+		return errors.New("end must be after start")
+	}
+
+	stations := make(map[string]bool, len(cfg.Stations))
+	for _, station := range cfg.Stations {
+		stations[station.Name] = true
+	}
+	for _, catchment := range cfg.Catchments {
+		if slices.Contains(constants.ReservedGaugeNames, catchment.Gauge.Name) {
+			return fmt.Errorf("gauge name %q is reserved", catchment.Gauge.Name)
+		}
+		if len(catchment.Stations) == 0 {
+			return fmt.Errorf("catchment of %s has no stations", catchment.Gauge.Name)
+		}
+		for _, name := range catchment.Stations {
+			if !stations[name] {
+				return fmt.Errorf("catchment of %s has unknown station %q", catchment.Gauge.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// measurements pairs levels with the timestamp of each step
+func (cfg Config) measurements(levels []float64) []domain.Measurement {
+	readings := make([]domain.Measurement, len(levels))
+	for i, level := range levels {
+		readings[i] = domain.Measurement{Timestamp: cfg.Start.Add(time.Duration(i) * cfg.Interval).UTC(), Level: level}
+	}
+	return readings
+}
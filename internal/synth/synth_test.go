@@ -0,0 +1,165 @@
+package synth
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+)
+
+func generate(t *testing.T, cfg Config) inmemory.Dataset {
+	t.Helper()
+	var w DatasetWriter
+	require.NoError(t, Generate(context.Background(), cfg, &w))
+	return w.Dataset
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := DefaultConfig()
+	data := generate(t, cfg)
+	steps := int(cfg.End.Sub(cfg.Start) / cfg.Interval)
+
+	t.Run("gives every station and gauge a reading each interval", func(t *testing.T) {
+		assert.Len(t, data.Stations, len(cfg.Stations))
+		assert.Len(t, data.Rainfall, steps*len(cfg.Stations))
+		assert.Len(t, data.River, steps)
+
+		for i, reading := range data.River {
+			assert.Equal(t, cfg.Start.Add(time.Duration(i)*cfg.Interval), reading.Timestamp)
+		}
+	})
+
+	t.Run("reports rainfall in whole bucket tips", func(t *testing.T) {
+		for _, reading := range data.Rainfall {
+			require.GreaterOrEqual(t, reading.Level, 0.0)
+			tips := reading.Level / bucketMM
+			require.InDelta(t, math.Round(tips), tips, 1e-9, "%v", reading)
+		}
+	})
+
+	t.Run("rains more in winter than summer", func(t *testing.T) {
+		byMonth := map[time.Month]float64{}
+		for _, reading := range data.Rainfall {
+			byMonth[reading.Timestamp.Month()] += reading.Level
+		}
+		assert.Greater(t, byMonth[time.January], 1.5*byMonth[time.July])
+	})
+
+	t.Run("has storms and dry spells", func(t *testing.T) {
+		var total, wettestHour float64
+		longestDry, dry := 0, 0
+		for i, reading := range data.Rainfall[:steps] {
+			total += reading.Level
+			if i >= 3 {
+				hour := reading.Level + data.Rainfall[i-1].Level + data.Rainfall[i-2].Level + data.Rainfall[i-3].Level
+				wettestHour = math.Max(wettestHour, hour)
+			}
+			if reading.Level > 0 {
+				dry = 0
+				continue
+			}
+			dry++
+			longestDry = max(longestDry, dry)
+		}
+
+		years := cfg.End.Sub(cfg.Start).Hours() / 24 / 365
+		assert.InDelta(t, 1300, total/years, 600, "mm a year")
+		assert.Greater(t, wettestHour, 5.0, "mm in the wettest hour")
+		assert.Greater(t, time.Duration(longestDry)*cfg.Interval, 10*24*time.Hour, "longest dry spell")
+	})
+
+	t.Run("keeps river levels in the gauge's range", func(t *testing.T) {
+		low, high := math.Inf(1), math.Inf(-1)
+		for _, reading := range data.River {
+			low = math.Min(low, reading.Level)
+			high = math.Max(high, reading.Level)
+		}
+		assert.GreaterOrEqual(t, low, stageDatum)
+		assert.Less(t, low, 0.4)
+		assert.Greater(t, high, 1.5)
+	})
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.End = cfg.Start.AddDate(0, 2, 0)
+
+	first := generate(t, cfg)
+	assert.Equal(t, first, generate(t, cfg))
+
+	cfg.Seed++
+	assert.NotEqual(t, first.Rainfall, generate(t, cfg).Rainfall)
+}
+
+func TestRiverLevelsLagRainfall(t *testing.T) {
+	cfg := DefaultConfig()
+	const storm, lag = 100, 24
+
+	dry := make([]float64, 400)
+	wet := make([]float64, len(dry))
+	for i := storm; i < storm+8; i++ {
+		wet[i] = 3
+	}
+
+	base := riverLevels(cfg, dry, lag)
+	flood := riverLevels(cfg, wet, lag)
+	assert.Equal(t, base[:storm+lag], flood[:storm+lag], "the river doesn't rise until the lag has passed")
+
+	peak := storm + lag
+	for i := range flood {
+		if flood[i] > flood[peak] {
+			peak = i
+		}
+	}
+	assert.Greater(t, peak, storm+lag)
+	assert.Greater(t, flood[peak]-base[peak], 0.5)
+	assert.Less(t, flood[len(flood)-1], flood[peak], "the river falls again after the storm")
+}
+
+func TestGenerateRejectsInvalidConfig(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := DefaultConfig()
+	cfg.End = cfg.Start
+	assert.EqualError(t, Generate(ctx, cfg, &DatasetWriter{}), "end must be after start")
+
+	cfg = DefaultConfig()
+	cfg.Catchments[0].Stations = []string{"nowhere"}
+	assert.EqualError(t, Generate(ctx, cfg, &DatasetWriter{}), `catchment of rede-bridge has unknown station "nowhere"`)
+}
+
+func TestRepositoryWriter(t *testing.T) {
+	ctx := context.Background()
+	cfg := DefaultConfig()
+	cfg.End = cfg.Start.AddDate(0, 4, 0)
+	cfg.Stations = cfg.Stations[:3]
+	cfg.Catchments[0].Stations = []string{cfg.Stations[0].Name}
+
+	// an empty store with the stations and gauge, so the readings are all written in batches
+	var empty inmemory.Dataset
+	for _, station := range cfg.Stations {
+		empty.AddRainfall(station, nil)
+	}
+	empty.AddRiver(cfg.Catchments[0].Gauge, nil)
+	store := inmemory.NewStore(empty)
+	w := RepositoryWriter{River: inmemory.NewRiverRepoFrom(store), Rainfall: inmemory.NewRainfallRepoFrom(store)}
+	require.NoError(t, Generate(ctx, cfg, w))
+
+	steps := int64(cfg.End.Sub(cfg.Start) / cfg.Interval)
+	require.Greater(t, steps, int64(writeBatchSize))
+	count, err := w.River.CountReadings(ctx, domain.GetRiverParams{GaugeName: cfg.Catchments[0].Gauge.Name})
+	require.NoError(t, err)
+	assert.Equal(t, steps, count)
+
+	stations, err := inmemory.NewStationRepoFrom(store).ListStations(ctx)
+	require.NoError(t, err)
+	for _, station := range stations {
+		assert.Equal(t, steps, station.ReadingCount, station.Name)
+	}
+}
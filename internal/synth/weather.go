@@ -0,0 +1,86 @@
+package synth
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// The weather parameters are loosely fitted to the Pennine stations in the fixtures, which see
+// around a metre of rain a year, most of it from long winter fronts with shorter, heavier summer storms.
+const (
+	dryDays     = 3.5  // mean length of a dry spell, half as long again in high summer
+	wetDays     = 2.5  // mean length of a wet spell, a third longer in midwinter
+	stormsPerHr = 0.08 // rate storms arrive during a wet spell, 40% higher in midwinter
+	stormHours  = 3.0  // mean storm length in high summer, rising to 8 hours in midwinter
+	peakMMPerHr = 1.0  // mean peak intensity of a winter storm, rising to 2.5mm/h in high summer
+	bucketMM    = 0.2  // rain gauges tip a bucket of this many millimetres
+)
+
+// weather is the rainfall a region sees at each step, which every station's rainfall is drawn from
+type weather struct {
+	cfg       Config
+	intensity []float64 // mm per step
+}
+
+// newWeather alternates dry and wet spells, adding storms that start during wet spells
+func newWeather(cfg Config, rng *rand.Rand, steps int) *weather {
+	w := &weather{cfg: cfg, intensity: make([]float64, steps)}
+	hours := cfg.Interval.Hours()
+
+	wet := rng.Float64() < 0.5
+	spellEnd := 0
+	for step := 0; step < steps; step++ {
+		winter := winterness(w.time(step))
+		if step >= spellEnd {
+			wet = !wet
+			days := dryDays * (1 + 0.5*(1-winter))
+			if wet {
+				days = wetDays * (1 + 0.3*winter)
+			}
+			spellEnd = step + 1 + int(rng.ExpFloat64()*days*24/hours)
+		}
+		if !wet || rng.Float64() >= stormsPerHr*(0.8+0.4*winter)*hours {
+			continue
+		}
+
+		length := max(1, int(rng.ExpFloat64()*(stormHours+5*winter)/hours))
+		peak := rng.ExpFloat64() * (peakMMPerHr + 1.5*(1-winter))
+		for k := 0; k < length && step+k < steps; k++ {
+			// storms build to their peak halfway through and tail off
+			w.intensity[step+k] += peak * hours * math.Sin(math.Pi*(float64(k)+0.5)/float64(length))
+		}
+	}
+	return w
+}
+
+// stationRainfall draws a station's readings from the regional weather. Each station gets more
+// or less of every storm depending on its exposure, sees it a little earlier or later, and
+// reports it in whole tips of its gauge's bucket.
+func (w *weather) stationRainfall(rng *rand.Rand) []float64 {
+	exposure := 0.7 + 0.6*rng.Float64()
+	shift := rng.IntN(5) - 2
+
+	levels := make([]float64, len(w.intensity))
+	var bucket float64
+	for step := range levels {
+		src := step - shift
+		if src < 0 || src >= len(w.intensity) || w.intensity[src] == 0 {
+			continue
+		}
+		bucket += w.intensity[src] * exposure * math.Exp(0.35*rng.NormFloat64()-0.06)
+		tips := math.Floor(bucket / bucketMM)
+		levels[step] = math.Round(tips*bucketMM*10) / 10
+		bucket -= tips * bucketMM
+	}
+	return levels
+}
+
+func (w *weather) time(step int) time.Time {
+	return w.cfg.Start.Add(time.Duration(step) * w.cfg.Interval)
+}
+
+// winterness is 1 in mid January and 0 in mid July
+func winterness(t time.Time) float64 {
+	return (1 + math.Cos(2*math.Pi*(float64(t.YearDay())-15)/365.25)) / 2
+}
@@ -0,0 +1,102 @@
+package synth
+
+import (
+	"context"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/repository"
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+)
+
+// DatasetWriter collects the series into a dataset, to serve from memory or write as fixture files
+type DatasetWriter struct {
+	Dataset inmemory.Dataset
+}
+
+func (w *DatasetWriter) WriteRainfall(ctx context.Context, station domain.Station, readings []domain.Measurement) error {
+	w.Dataset.AddRainfall(station, readings)
+	return nil
+}
+
+func (w *DatasetWriter) WriteRiver(ctx context.Context, gauge domain.Gauge, readings []domain.Measurement) error {
+	w.Dataset.AddRiver(gauge, readings)
+	return nil
+}
+
+// ImportWriter bulk loads the series, adding each station before its readings. Readings already
+// stored are kept, and the gauges have to exist.
+type ImportWriter struct {
+	repo repository.ImportRepository
+	// Imported totals the readings that were new
+	Imported int64
+}
+
+func NewImportWriter(repo repository.ImportRepository) *ImportWriter {
+	return &ImportWriter{repo: repo}
+}
+
+func (w *ImportWriter) WriteRainfall(ctx context.Context, station domain.Station, readings []domain.Measurement) error {
+	_, err := w.repo.ImportStations(ctx, func(add func(domain.Station) error) error {
+		return add(station)
+	})
+	if err != nil {
+		return err
+	}
+
+	result, err := w.repo.ImportRainfall(ctx, func(add func(stationID string, m domain.Measurement) error) error {
+		for _, m := range readings {
+			if err := add(station.ID, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	w.Imported += result.Imported
+	return err
+}
+
+func (w *ImportWriter) WriteRiver(ctx context.Context, gauge domain.Gauge, readings []domain.Measurement) error {
+	result, err := w.repo.ImportRiverLevels(ctx, gauge.Name, func(add func(domain.Measurement) error) error {
+		for _, m := range readings {
+			if err := add(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	w.Imported += result.Imported
+	return err
+}
+
+// RepositoryWriter upserts the series through the API's repositories, in batches so no one
+// transaction holds years of readings. The stations and gauges have to exist.
+type RepositoryWriter struct {
+	River    repository.RiverRepository
+	Rainfall repository.RainfallRepository
+}
+
+// writeBatchSize is how many readings go into each upsert
+const writeBatchSize = 10000
+
+func (w RepositoryWriter) WriteRainfall(ctx context.Context, station domain.Station, readings []domain.Measurement) error {
+	return inBatches(readings, func(batch []domain.Measurement) error {
+		return w.Rainfall.UpsertReadingsByStation(ctx, station.Name, batch)
+	})
+}
+
+func (w RepositoryWriter) WriteRiver(ctx context.Context, gauge domain.Gauge, readings []domain.Measurement) error {
+	return inBatches(readings, func(batch []domain.Measurement) error {
+		return w.River.UpsertReadings(ctx, gauge.Name, batch)
+	})
+}
+
+func inBatches(readings []domain.Measurement, fn func([]domain.Measurement) error) error {
+	for len(readings) > 0 {
+		n := min(len(readings), writeBatchSize)
+		if err := fn(readings[:n]); err != nil {
+			return err
+		}
+		readings = readings[n:]
+	}
+	return nil
+}
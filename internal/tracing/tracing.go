@@ -0,0 +1,69 @@
+// Package tracing sets up OpenTelemetry tracing for the API and holds the span attributes its
+// handlers and repositories share
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// span attributes describing what a request or query worked on
+const (
+	StationKey  = attribute.Key("flood.station")
+	GaugeKey    = attribute.Key("flood.gauge")
+	PageKey     = attribute.Key("flood.page")
+	PageSizeKey = attribute.Key("flood.pagesize")
+	RowCountKey = attribute.Key("flood.row_count")
+)
+
+// ExporterEnv picks where spans are sent: otlp, console or none
+const ExporterEnv = "OTEL_TRACES_EXPORTER"
+
+// Setup installs the global tracer provider with the exporter named by OTEL_TRACES_EXPORTER. The otlp
+// exporter sends over HTTP and is configured by the standard OTEL_EXPORTER_OTLP_* variables, and console
+// prints spans to stdout for local debugging. With none, or the variable unset, spans are never recorded.
+// The returned shutdown flushes spans not yet exported.
+func Setup(ctx context.Context, service string) (shutdown func(context.Context) error, err error) {
+	shutdown = func(context.Context) error { return nil }
+
+	var exporter sdktrace.SpanExporter
+	switch name := strings.ToLower(os.Getenv(ExporterEnv)); name {
+	case "", "none":
+		return shutdown, nil
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "console", "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return shutdown, fmt.Errorf("unknown %s %q, expected otlp, console or none", ExporterEnv, name)
+	}
+	if err != nil {
+		return shutdown, fmt.Errorf("create exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service)),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return shutdown, fmt.Errorf("describe resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	t.Run("records nothing without an exporter", func(t *testing.T) {
+		t.Setenv(ExporterEnv, "")
+		shutdown, err := Setup(context.Background(), "flood-api")
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("rejects unknown exporters", func(t *testing.T) {
+		t.Setenv(ExporterEnv, "zipkin")
+		_, err := Setup(context.Background(), "flood-api")
+		assert.ErrorContains(t, err, `unknown OTEL_TRACES_EXPORTER "zipkin"`)
+	})
+
+	t.Run("installs a provider for the console exporter", func(t *testing.T) {
+		previous := otel.GetTracerProvider()
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+		t.Setenv(ExporterEnv, "console")
+
+		shutdown, err := Setup(context.Background(), "flood-api")
+		require.NoError(t, err)
+		assert.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+		assert.NoError(t, shutdown(context.Background()))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	router := chi.NewRouter()
+	router.Use(Middleware)
+	router.Get("/rainfall/{station}", func(w http.ResponseWriter, r *http.Request) {
+		// handlers see the request's span in their context
+		assert.True(t, trace.SpanContextFromContext(r.Context()).IsValid())
+		w.Write([]byte("ok"))
+	})
+	router.Get("/broken", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/rainfall/catcleugh", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "GET /rainfall/{station}", spans[0].Name())
+	assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Contains(t, spans[0].Attributes(), semconv.HTTPRoute("/rainfall/{station}"))
+	assert.Contains(t, spans[0].Attributes(), semconv.HTTPResponseStatusCode(http.StatusOK))
+
+	assert.Equal(t, "GET /broken", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
@@ -0,0 +1,7 @@
+--
+-- Migration 001 (down): Drop the initial schema
+--
+
+DROP TABLE IF EXISTS public.stationnames;
+DROP TABLE IF EXISTS public.riverlevels;
+DROP TABLE IF EXISTS public.rainfalls;
@@ -0,0 +1,6 @@
+--
+-- Migration 002 (down): Drop the performance indexes
+--
+
+DROP INDEX IF EXISTS public.idx_rainfalls_station_timestamp;
+DROP INDEX IF EXISTS public.idx_riverlevels_timestamp;
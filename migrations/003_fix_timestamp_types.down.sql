@@ -0,0 +1,38 @@
+--
+-- Migration 003 (down): Convert timestamp columns back to text
+-- Dropping the text columns on the way up also dropped migration 002's indexes,
+-- so they are recreated on the text columns
+--
+
+ALTER TABLE public.rainfalls 
+ADD COLUMN timestamp_old text;
+
+ALTER TABLE public.riverlevels 
+ADD COLUMN timestamp_old text;
+
+UPDATE public.rainfalls 
+SET timestamp_old = timestamp::text;
+
+UPDATE public.riverlevels 
+SET timestamp_old = timestamp::text;
+
+ALTER TABLE public.rainfalls 
+DROP COLUMN timestamp;
+
+ALTER TABLE public.rainfalls 
+RENAME COLUMN timestamp_old TO timestamp;
+
+ALTER TABLE public.riverlevels 
+DROP COLUMN timestamp;
+
+ALTER TABLE public.riverlevels 
+RENAME COLUMN timestamp_old TO timestamp;
+
+CREATE INDEX IF NOT EXISTS idx_rainfalls_station_timestamp 
+ON public.rainfalls (stationid, timestamp);
+
+CREATE INDEX IF NOT EXISTS idx_riverlevels_timestamp 
+ON public.riverlevels (timestamp);
+
+ANALYZE public.rainfalls;
+ANALYZE public.riverlevels;
@@ -0,0 +1,16 @@
+--
+-- Migration 004 (down): Drop the NOT NULL constraints
+--
+
+ALTER TABLE public.rainfalls 
+ALTER COLUMN timestamp DROP NOT NULL,
+ALTER COLUMN stationid DROP NOT NULL,
+ALTER COLUMN level DROP NOT NULL;
+
+ALTER TABLE public.riverlevels 
+ALTER COLUMN timestamp DROP NOT NULL,
+ALTER COLUMN level DROP NOT NULL;
+
+ALTER TABLE public.stationnames 
+ALTER COLUMN id DROP NOT NULL,
+ALTER COLUMN name DROP NOT NULL;
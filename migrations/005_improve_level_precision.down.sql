@@ -0,0 +1,13 @@
+--
+-- Migration 005 (down): Convert level columns back to real (float32)
+-- Levels lose any precision beyond what real can hold
+--
+
+ALTER TABLE public.rainfalls 
+ALTER COLUMN level TYPE real;
+
+ALTER TABLE public.riverlevels 
+ALTER COLUMN level TYPE real;
+
+ANALYZE public.rainfalls;
+ANALYZE public.riverlevels;
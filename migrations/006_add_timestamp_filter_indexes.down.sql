@@ -0,0 +1,6 @@
+--
+-- Migration 006 (down): Drop the timestamp filter indexes
+--
+
+DROP INDEX IF EXISTS public.idx_riverlevels_timestamp_desc;
+DROP INDEX IF EXISTS public.idx_rainfalls_station_timestamp_desc;
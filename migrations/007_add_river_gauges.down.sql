@@ -0,0 +1,23 @@
+--
+-- Migration 007 (down): Remove the river gauge dimension
+-- River levels had no gauge before, so readings from gauges other than
+-- Rede Bridge are deleted rather than mixed into its series
+--
+
+DELETE FROM public.riverlevels 
+WHERE gaugeid <> 'rede-bridge';
+
+CREATE INDEX IF NOT EXISTS idx_riverlevels_timestamp 
+ON public.riverlevels (timestamp);
+
+CREATE INDEX IF NOT EXISTS idx_riverlevels_timestamp_desc 
+ON public.riverlevels (timestamp DESC);
+
+DROP INDEX IF EXISTS public.idx_riverlevels_gauge_timestamp;
+
+ALTER TABLE public.riverlevels 
+DROP COLUMN gaugeid;
+
+DROP TABLE IF EXISTS public.rivergauges;
+
+ANALYZE public.riverlevels;
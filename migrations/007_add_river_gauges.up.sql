@@ -0,0 +1,36 @@
+--
+-- Migration 007: Add a river gauge dimension to river levels
+-- Every existing reading comes from Rede Bridge, so it becomes the first gauge
+-- and the other gauges can be loaded alongside it
+--
+
+-- The /river/latest and /river/aggregate routes would shadow gauges named latest or aggregate
+CREATE TABLE public.rivergauges (
+    id text NOT NULL PRIMARY KEY,
+    name text NOT NULL UNIQUE CHECK (name NOT IN ('latest', 'aggregate')),
+    river text NOT NULL
+);
+
+INSERT INTO public.rivergauges (id, name, river)
+VALUES ('rede-bridge', 'rede-bridge', 'River Rede');
+
+ALTER TABLE public.riverlevels 
+ADD COLUMN gaugeid text;
+
+UPDATE public.riverlevels 
+SET gaugeid = 'rede-bridge';
+
+ALTER TABLE public.riverlevels 
+ALTER COLUMN gaugeid SET NOT NULL;
+
+-- Readings are now always filtered by gauge, so replace the timestamp indexes with one led by it
+-- This speeds up queries like: WHERE gaugeid = $1 AND timestamp >= $2 ORDER BY timestamp ASC
+-- and scans backwards for the latest reading
+CREATE INDEX IF NOT EXISTS idx_riverlevels_gauge_timestamp 
+ON public.riverlevels (gaugeid, timestamp);
+
+DROP INDEX IF EXISTS public.idx_riverlevels_timestamp;
+DROP INDEX IF EXISTS public.idx_riverlevels_timestamp_desc;
+
+ANALYZE public.rivergauges;
+ANALYZE public.riverlevels;
@@ -0,0 +1,41 @@
+--
+-- Migration 008 (down): Drop the unique constraints on readings
+-- The duplicates moved aside on the way up are put back. The rainfall lookup
+-- index isn't recreated as migration 003 had already dropped it
+--
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_riverlevels_gauge_timestamp 
+ON public.riverlevels (gaugeid, timestamp);
+
+ALTER TABLE public.rainfalls
+DROP CONSTRAINT IF EXISTS rainfalls_stationid_timestamp_key;
+
+ALTER TABLE public.riverlevels
+DROP CONSTRAINT IF EXISTS riverlevels_gaugeid_timestamp_key;
+
+-- A database migrated before the audit tables existed has none to put back
+CREATE TABLE IF NOT EXISTS public.rainfalls_duplicates (
+    LIKE public.rainfalls,
+    removed_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS public.riverlevels_duplicates (
+    LIKE public.riverlevels,
+    removed_at timestamptz NOT NULL DEFAULT now()
+);
+
+INSERT INTO public.rainfalls (stationid, timestamp, level)
+SELECT stationid, timestamp, level FROM public.rainfalls_duplicates;
+
+INSERT INTO public.riverlevels (gaugeid, timestamp, level)
+SELECT gaugeid, timestamp, level FROM public.riverlevels_duplicates;
+
+DROP TABLE public.rainfalls_duplicates;
+DROP TABLE public.riverlevels_duplicates;
+
+COMMIT;
+
+ANALYZE public.rainfalls;
+ANALYZE public.riverlevels;
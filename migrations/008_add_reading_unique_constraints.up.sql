@@ -0,0 +1,61 @@
+--
+-- Migration 008: Make readings unique per station or gauge and timestamp
+-- Lets writes upsert with ON CONFLICT so retried batches and repeated polls are safe
+--
+-- Readings that would break the constraints are moved to rainfalls_duplicates and
+-- riverlevels_duplicates rather than deleted, so nothing is lost without a trace.
+-- Of each set of duplicates the highest level is kept, which is deterministic and
+-- errs towards the flood. Compare the audit tables with what was kept before
+-- dropping them.
+--
+
+BEGIN;
+
+CREATE TABLE public.rainfalls_duplicates (
+    LIKE public.rainfalls,
+    removed_at timestamptz NOT NULL DEFAULT now()
+);
+
+WITH ranked AS (
+    SELECT ctid, row_number() OVER (PARTITION BY stationid, timestamp ORDER BY level DESC) AS n
+    FROM public.rainfalls
+), removed AS (
+    DELETE FROM public.rainfalls r
+    USING ranked
+    WHERE r.ctid = ranked.ctid AND ranked.n > 1
+    RETURNING r.stationid, r.timestamp, r.level
+)
+INSERT INTO public.rainfalls_duplicates (stationid, timestamp, level)
+SELECT stationid, timestamp, level FROM removed;
+
+CREATE TABLE public.riverlevels_duplicates (
+    LIKE public.riverlevels,
+    removed_at timestamptz NOT NULL DEFAULT now()
+);
+
+WITH ranked AS (
+    SELECT ctid, row_number() OVER (PARTITION BY gaugeid, timestamp ORDER BY level DESC) AS n
+    FROM public.riverlevels
+), removed AS (
+    DELETE FROM public.riverlevels r
+    USING ranked
+    WHERE r.ctid = ranked.ctid AND ranked.n > 1
+    RETURNING r.gaugeid, r.timestamp, r.level
+)
+INSERT INTO public.riverlevels_duplicates (gaugeid, timestamp, level)
+SELECT gaugeid, timestamp, level FROM removed;
+
+-- The constraints' indexes cover the same columns as the existing lookup indexes, so replace them
+ALTER TABLE public.rainfalls
+ADD CONSTRAINT rainfalls_stationid_timestamp_key UNIQUE (stationid, timestamp);
+
+ALTER TABLE public.riverlevels
+ADD CONSTRAINT riverlevels_gaugeid_timestamp_key UNIQUE (gaugeid, timestamp);
+
+DROP INDEX IF EXISTS public.idx_rainfalls_station_timestamp;
+DROP INDEX IF EXISTS public.idx_riverlevels_gauge_timestamp;
+
+COMMIT;
+
+ANALYZE public.rainfalls;
+ANALYZE public.riverlevels;
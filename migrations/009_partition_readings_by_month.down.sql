@@ -0,0 +1,52 @@
+--
+-- Migration 009 (down): Return rainfalls and riverlevels to plain tables
+-- Every monthly partition is dropped along with the partitioned table once its
+-- readings have been copied back. Both tables are converted in one transaction, so
+-- a failure leaves them partitioned.
+--
+
+BEGIN;
+
+ALTER TABLE public.rainfalls RENAME TO rainfalls_partitioned;
+
+ALTER TABLE public.rainfalls_partitioned
+DROP CONSTRAINT rainfalls_stationid_timestamp_key;
+
+CREATE TABLE public.rainfalls (
+    stationid text NOT NULL,
+    level double precision NOT NULL,
+    "timestamp" timestamp NOT NULL,
+    CONSTRAINT rainfalls_stationid_timestamp_key UNIQUE (stationid, "timestamp")
+);
+
+INSERT INTO public.rainfalls (stationid, level, "timestamp")
+SELECT stationid, level, "timestamp"
+FROM public.rainfalls_partitioned;
+
+DROP TABLE public.rainfalls_partitioned;
+
+CREATE INDEX IF NOT EXISTS idx_rainfalls_station_timestamp_desc
+ON public.rainfalls (stationid, timestamp DESC);
+
+ALTER TABLE public.riverlevels RENAME TO riverlevels_partitioned;
+
+ALTER TABLE public.riverlevels_partitioned
+DROP CONSTRAINT riverlevels_gaugeid_timestamp_key;
+
+CREATE TABLE public.riverlevels (
+    level double precision NOT NULL,
+    "timestamp" timestamp NOT NULL,
+    gaugeid text NOT NULL,
+    CONSTRAINT riverlevels_gaugeid_timestamp_key UNIQUE (gaugeid, "timestamp")
+);
+
+INSERT INTO public.riverlevels (level, "timestamp", gaugeid)
+SELECT level, "timestamp", gaugeid
+FROM public.riverlevels_partitioned;
+
+DROP TABLE public.riverlevels_partitioned;
+
+ANALYZE public.rainfalls;
+ANALYZE public.riverlevels;
+
+COMMIT;
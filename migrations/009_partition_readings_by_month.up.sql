@@ -0,0 +1,70 @@
+--
+-- Migration 009: Range partition rainfalls and riverlevels by month
+-- Date filtered queries only read the months they cover, and old months can be
+-- detached or dropped whole. Each table gets a default partition, which the
+-- readings are copied into here. flood-api migrate up then moves them into monthly
+-- partitions, and the server creates the partitions for coming months ahead of time.
+--
+-- The unique constraints serve every station or gauge lookup in either direction,
+-- so the descending index from migration 006 is dropped with the old table. A BRIN
+-- index on timestamp replaces it for scans across every station or gauge, and
+-- stays tiny as readings are written in time order.
+--
+-- Both tables are converted in one transaction, so a failure leaves them as they
+-- were. Force version 8 to clear the dirty flag, then run it again.
+--
+
+BEGIN;
+
+ALTER TABLE public.rainfalls RENAME TO rainfalls_unpartitioned;
+
+ALTER TABLE public.rainfalls_unpartitioned
+DROP CONSTRAINT rainfalls_stationid_timestamp_key;
+
+CREATE TABLE public.rainfalls (
+    stationid text NOT NULL,
+    level double precision NOT NULL,
+    "timestamp" timestamp NOT NULL,
+    CONSTRAINT rainfalls_stationid_timestamp_key UNIQUE (stationid, "timestamp")
+) PARTITION BY RANGE ("timestamp");
+
+CREATE TABLE public.rainfalls_default PARTITION OF public.rainfalls DEFAULT;
+
+CREATE INDEX idx_rainfalls_timestamp_brin
+ON public.rainfalls USING brin ("timestamp");
+
+INSERT INTO public.rainfalls (stationid, level, "timestamp")
+SELECT stationid, level, "timestamp"
+FROM public.rainfalls_unpartitioned
+ORDER BY "timestamp";
+
+DROP TABLE public.rainfalls_unpartitioned;
+
+ALTER TABLE public.riverlevels RENAME TO riverlevels_unpartitioned;
+
+ALTER TABLE public.riverlevels_unpartitioned
+DROP CONSTRAINT riverlevels_gaugeid_timestamp_key;
+
+CREATE TABLE public.riverlevels (
+    level double precision NOT NULL,
+    "timestamp" timestamp NOT NULL,
+    gaugeid text NOT NULL,
+    CONSTRAINT riverlevels_gaugeid_timestamp_key UNIQUE (gaugeid, "timestamp")
+) PARTITION BY RANGE ("timestamp");
+
+CREATE TABLE public.riverlevels_default PARTITION OF public.riverlevels DEFAULT;
+
+CREATE INDEX idx_riverlevels_timestamp_brin
+ON public.riverlevels USING brin ("timestamp");
+
+INSERT INTO public.riverlevels (level, "timestamp", gaugeid)
+SELECT level, "timestamp", gaugeid
+FROM public.riverlevels_unpartitioned
+ORDER BY "timestamp";
+
+DROP TABLE public.riverlevels_unpartitioned;
+
+ANALYZE public.rainfalls;
+ANALYZE public.riverlevels;
+
+COMMIT;
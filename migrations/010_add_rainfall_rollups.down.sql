@@ -0,0 +1,7 @@
+--
+-- Migration 010 (down): Drop the rainfall rollups
+--
+
+DROP TABLE IF EXISTS public.rainfall_rollup_pending;
+DROP TABLE IF EXISTS public.rainfall_daily;
+DROP TABLE IF EXISTS public.rainfall_hourly;
@@ -0,0 +1,50 @@
+--
+-- Migration 010: Add hourly and daily rainfall rollups
+-- Aggregates over months or years of 15 minute readings read every raw row, so
+-- rainfall_hourly and rainfall_daily keep each station's totals, extremes and
+-- reading counts per hour and per day. Means are derived from the total and count.
+--
+-- Writes record the hours they touch in rainfall_rollup_pending, in the same
+-- transaction as the readings, and a refresh after ingestion or on a schedule
+-- recomputes just those hours and their days. Aggregates covering a pending hour
+-- are answered from the raw readings until it has been refreshed.
+--
+
+CREATE TABLE public.rainfall_hourly (
+    stationid text NOT NULL,
+    hour timestamp NOT NULL,
+    total double precision NOT NULL,
+    maximum double precision NOT NULL,
+    minimum double precision NOT NULL,
+    sample_count bigint NOT NULL,
+    PRIMARY KEY (stationid, hour)
+);
+
+CREATE TABLE public.rainfall_daily (
+    stationid text NOT NULL,
+    day timestamp NOT NULL,
+    total double precision NOT NULL,
+    maximum double precision NOT NULL,
+    minimum double precision NOT NULL,
+    sample_count bigint NOT NULL,
+    PRIMARY KEY (stationid, day)
+);
+
+CREATE TABLE public.rainfall_rollup_pending (
+    stationid text NOT NULL,
+    hour timestamp NOT NULL,
+    PRIMARY KEY (stationid, hour)
+);
+
+INSERT INTO public.rainfall_hourly (stationid, hour, total, maximum, minimum, sample_count)
+SELECT stationid, date_trunc('hour', "timestamp"), SUM(level), MAX(level), MIN(level), COUNT(*)
+FROM public.rainfalls
+GROUP BY 1, 2;
+
+INSERT INTO public.rainfall_daily (stationid, day, total, maximum, minimum, sample_count)
+SELECT stationid, date_trunc('day', hour), SUM(total), MAX(maximum), MIN(minimum), SUM(sample_count)
+FROM public.rainfall_hourly
+GROUP BY 1, 2;
+
+ANALYZE public.rainfall_hourly;
+ANALYZE public.rainfall_daily;
@@ -0,0 +1,226 @@
+// Package migrations embeds the schema migrations so the binaries and the integration tests
+// apply the same files, tracking the applied version in schema_migrations
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration is one embedded migration and whether the database has it applied
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Migrator applies the embedded migrations to a database.
+// Statements are run one at a time like psql -f, as CREATE INDEX CONCURRENTLY can't run in a
+// transaction, so migrations are split on semicolons and their comments must not contain any.
+// Migration 001 is the challenge's pg_dump kept as it was, so it is run whole instead.
+type Migrator struct {
+	db *sql.DB
+	m  *migrate.Migrate
+}
+
+// New opens its own connection pool, as migration 001 clears the search_path of the session it runs in
+func New(dbURL string) (*Migrator, error) {
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := iofs.New(files, ".")
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	split, err := postgres.WithInstance(db, &postgres.Config{MultiStatementEnabled: true})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	whole, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		split.Close()
+		db.Close()
+		return nil, err
+	}
+	driver := &initialWholeDriver{Driver: split, whole: whole}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Migrator{db: db, m: m}, nil
+}
+
+// initialWholeDriver runs migration 001 up through whole, in one exec, and everything else
+// through the embedded driver, which splits on semicolons
+type initialWholeDriver struct {
+	database.Driver
+	whole   database.Driver
+	initial bool // the migration about to run is 001 up
+}
+
+// SetVersion marks the version a migration is about to take the database to as dirty before
+// running it, which is when the migration is known
+func (d *initialWholeDriver) SetVersion(version int, dirty bool) error {
+	if dirty {
+		current, _, err := d.Driver.Version()
+		if err != nil {
+			return err
+		}
+		d.initial = current == database.NilVersion && version == 1
+	}
+	return d.Driver.SetVersion(version, dirty)
+}
+
+func (d *initialWholeDriver) Run(migration io.Reader) error {
+	if d.initial {
+		return d.whole.Run(migration)
+	}
+	return d.Driver.Run(migration)
+}
+
+func (d *initialWholeDriver) Close() error {
+	return errors.Join(d.Driver.Close(), d.whole.Close())
+}
+
+// Up applies every pending migration. A database migrated before schema_migrations existed
+// has the tables but no version, and must be marked with Force rather than migrated from scratch.
+func (m *Migrator) Up() error {
+	version, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		var exists bool
+		if err := m.db.QueryRow("SELECT to_regclass('public.stationnames') IS NOT NULL").Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("database has tables but no migration version, force the version it was migrated to first")
+		}
+	}
+
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down reverts the given number of applied migrations
+func (m *Migrator) Down(steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+	if err := m.m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Force records version as applied without running anything, to adopt a database migrated by hand
+// or clear the dirty flag left by a failed migration once it has been fixed
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Version returns the applied version, 0 when no migration has been applied.
+// dirty is set when the last migration failed part way through.
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Status lists every embedded migration against the applied version
+func (m *Migrator) Status() ([]Migration, error) {
+	version, _, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Applied = list[i].Version <= version
+	}
+	return list, nil
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// CheckVersion returns an error unless every embedded migration has been applied to db cleanly. It reads
+// schema_migrations itself, so it can be run on the API's pool rather than opening one like New.
+func CheckVersion(ctx context.Context, db *sql.DB) error {
+	list, err := List()
+	if err != nil {
+		return err
+	}
+	latest := list[len(list)-1].Version
+
+	var version uint
+	var dirty bool
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no migrations applied, want version %d", latest)
+	}
+	if err != nil {
+		return fmt.Errorf("read migration version: %w", err)
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("migration %d failed part way through", version)
+	case version != latest:
+		return fmt.Errorf("migrated to version %d, want %d", version, latest)
+	}
+	return nil
+}
+
+// List returns the embedded migrations in version order
+func List() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Migration, 0, len(names))
+	for _, name := range names {
+		parsed, err := source.Parse(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		list = append(list, Migration{Version: parsed.Version, Name: parsed.Identifier})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
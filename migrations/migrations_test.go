@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestList(t *testing.T) {
+	list, err := List()
+	require.NoError(t, err)
+	require.NotEmpty(t, list)
+
+	for i, m := range list {
+		assert.Equal(t, uint(i+1), m.Version, "versions must be contiguous from 001")
+		assert.False(t, m.Applied)
+
+		_, err := fs.Stat(files, fmt.Sprintf("%03d_%s.down.sql", m.Version, m.Name))
+		assert.NoError(t, err, "%03d_%s has no down migration", m.Version, m.Name)
+	}
+	assert.Equal(t, "initial_schema", list[0].Name)
+}
+
+// Every migration but 001 up is split into statements on every semicolon, so one in a comment would
+// break them apart
+func TestNoSemicolonsInComments(t *testing.T) {
+	names, err := fs.Glob(files, "*.sql")
+	require.NoError(t, err)
+
+	for _, name := range names {
+		if name == "001_initial_schema.up.sql" {
+			continue
+		}
+		content, err := fs.ReadFile(files, name)
+		require.NoError(t, err)
+
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for line := 1; scanner.Scan(); line++ {
+			if _, comment, ok := strings.Cut(scanner.Text(), "--"); ok {
+				assert.NotContains(t, comment, ";", "%s:%d", name, line)
+			}
+		}
+	}
+}
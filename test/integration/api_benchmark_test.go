@@ -15,9 +15,11 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 
 	"github.com/oliverslade/flood-api/internal/api"
 	postgresrepo "github.com/oliverslade/flood-api/internal/repository/postgres"
+	"github.com/oliverslade/flood-api/internal/synth"
 	"github.com/oliverslade/flood-api/test/integration/testutil"
 )
 
@@ -45,9 +47,9 @@ func BenchmarkIntegrationAPI(b *testing.B) {
 		{"River_LargePage", "/river?pagesize=100"},
 		{"River_WithDateFilter", "/river?start=2024-01-01&pagesize=50"},
 		{"River_LastPage", "/river?page=1000&pagesize=10"},
-		{"Rainfall_DefaultPage", "/rainfall/benchmark-station"},
-		{"Rainfall_LargePage", "/rainfall/benchmark-station?pagesize=100"},
-		{"Rainfall_WithDateFilter", "/rainfall/benchmark-station?start=2024-01-01&pagesize=50"},
+		{"Rainfall_DefaultPage", "/rainfall/catcleugh"},
+		{"Rainfall_LargePage", "/rainfall/catcleugh?pagesize=100"},
+		{"Rainfall_WithDateFilter", "/rainfall/catcleugh?start=2024-01-01&pagesize=50"},
 	}
 	
 	for _, bm := range benchmarks {
@@ -65,6 +67,23 @@ func BenchmarkIntegrationAPI(b *testing.B) {
 			}
 		})
 	}
+	
+	// Keyset equivalent of River_LastPage: seek to the same position via a cursor instead of an offset
+	b.Run("River_CursorLastPage", func(b *testing.B) {
+		from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Add(9980 * time.Hour).Format(time.RFC3339)
+		seed := mustGETBench(b, ctx, fmt.Sprintf("%s/river?from=%s&pagesize=10", server.URL, from))
+		if seed.NextCursor == "" {
+			b.Fatal("Expected next cursor")
+		}
+		
+		url := fmt.Sprintf("%s/river?cursor=%s&pagesize=10", server.URL, seed.NextCursor)
+		b.ReportAllocs()
+		b.ResetTimer()
+		
+		for i := 0; i < b.N; i++ {
+			mustGETBench(b, ctx, url)
+		}
+	})
 }
 
 // createBenchmarkServer sets up HTTP server for benchmarks
@@ -77,10 +96,39 @@ func createBenchmarkServer(b *testing.B) *httptest.Server {
 	rainfallRepo := postgresrepo.NewRainfallRepo(testDB)
 	rainfallHandler := api.NewRainfallHandler(rainfallRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
 	
+	stationRepo := postgresrepo.NewStationRepo(testDB)
+	stationHandler := api.NewStationHandler(stationRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	
 	router := chi.NewRouter()
-	router.Use(api.TimeoutMiddleware)
-	router.Get("/river", riverHandler.GetReadings)
-	router.Get("/rainfall/{station}", rainfallHandler.GetReadingsByStation)
+	router.Group(func(r chi.Router) {
+		r.Use(api.Timeout(5 * time.Second))
+		r.Get("/river", riverHandler.GetReadings)
+		r.Get("/river/aggregate", riverHandler.GetAggregates)
+		r.Get("/river/latest", riverHandler.GetLatestReading)
+		r.Get("/river/{gauge}", riverHandler.GetReadings)
+		r.Get("/river/{gauge}/aggregate", riverHandler.GetAggregates)
+		r.Get("/river/{gauge}/latest", riverHandler.GetLatestReading)
+		r.Get("/rainfall", rainfallHandler.GetReadingsByStations)
+		r.Get("/rainfall/latest", rainfallHandler.GetLatestReadings)
+		r.Get("/rainfall/{station}", rainfallHandler.GetReadingsByStation)
+		r.Get("/rainfall/{station}/aggregate", rainfallHandler.GetAggregatesByStation)
+		r.Get("/rainfall/{station}/latest", rainfallHandler.GetLatestReadingByStation)
+		r.Get("/stations", stationHandler.ListStations)
+		r.Get("/stations/{station}", stationHandler.GetStation)
+	})
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.Compress(5, "application/json", "text/csv", "application/x-ndjson"))
+		r.Get("/export/river", riverHandler.ExportReadings)
+		r.Get("/export/river/{gauge}", riverHandler.ExportReadings)
+		r.Get("/export/rainfall/{station}", rainfallHandler.ExportReadingsByStation)
+	})
+	router.Group(func(r chi.Router) {
+		r.Use(api.Timeout(5 * time.Second))
+		r.Use(api.RequireBearerToken(testWriteToken))
+		r.Post("/river/readings", riverHandler.PostReadings)
+		r.Post("/river/{gauge}/readings", riverHandler.PostReadings)
+		r.Post("/rainfall/{station}/readings", rainfallHandler.PostReadingsByStation)
+	})
 	
 	return httptest.NewServer(router)
 }
@@ -117,11 +165,12 @@ func mustGETBench(b *testing.B, ctx context.Context, url string) testutil.APIRes
 	return result
 }
 
-// seedBenchmarkData creates enough data for meaningful benchmarks
+// seedBenchmarkData loads 18 months of synthetic 15-minute readings for every station and the
+// default gauge, so queries see realistic volumes and distributions
 func seedBenchmarkData(b *testing.B, db *sql.DB) {
 	b.Helper()
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 	
 	// Check if already seeded
@@ -134,68 +183,13 @@ func seedBenchmarkData(b *testing.B, db *sql.DB) {
 	// Clean first to avoid conflicts
 	cleanDB(b, db)
 	
-	tx, err := db.BeginTx(ctx, nil)
-	if err != nil {
-		b.Fatalf("Failed to begin transaction: %v", err)
+	cfg := synth.DefaultConfig()
+	cfg.Start = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg.End = cfg.Start.AddDate(0, 18, 0)
+	if err := synth.Generate(ctx, cfg, synth.NewImportWriter(postgresrepo.NewImportRepo(db))); err != nil {
+		b.Fatalf("Failed to seed benchmark data: %v", err)
 	}
-	defer tx.Rollback()
-	
-	// Insert benchmark station (simple INSERT, no ON CONFLICT for test schema)
-	_, err = tx.ExecContext(ctx,
-		"INSERT INTO stationnames (id, name) VALUES ($1, $2)",
-		"BENCH001", "benchmark-station",
-	)
-	if err != nil {
-		b.Fatalf("Failed to insert station: %v", err)
-	}
-	
-	// Batch insert 10k river readings
-	baseTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
-	for batch := 0; batch < 100; batch++ {
-		query := "INSERT INTO riverlevels (timestamp, level) VALUES "
-		args := make([]interface{}, 0, 200)
-		
-		for i := 0; i < 100; i++ {
-			idx := batch*100 + i
-			if i > 0 {
-				query += ", "
-			}
-			query += fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2)
-			args = append(args,
-				baseTime.Add(time.Duration(idx)*time.Hour).Format("2006-01-02T15:04:05"),
-				float64(idx%100)/10.0,
-			)
-		}
-		
-		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
-			b.Fatalf("Failed to insert river batch %d: %v", batch, err)
-		}
-	}
-	
-	// Batch insert 10k rainfall readings
-	for batch := 0; batch < 100; batch++ {
-		query := "INSERT INTO rainfalls (stationid, timestamp, level) VALUES "
-		args := make([]interface{}, 0, 300)
-		
-		for i := 0; i < 100; i++ {
-			idx := batch*100 + i
-			if i > 0 {
-				query += ", "
-			}
-			query += fmt.Sprintf("($%d, $%d, $%d)", i*3+1, i*3+2, i*3+3)
-			args = append(args,
-				"BENCH001",
-				baseTime.Add(time.Duration(idx)*time.Hour).Format("2006-01-02T15:04:05"),
-				float64(idx%50)/10.0,
-			)
-		}
-		
-		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
-			b.Fatalf("Failed to insert rainfall batch %d: %v", batch, err)
-		}
-	}
-	
-	if err := tx.Commit(); err != nil {
-		b.Fatalf("Failed to commit benchmark data: %v", err)
+	if _, err := postgresrepo.RefreshRollups(ctx, db); err != nil {
+		b.Fatalf("Failed to refresh rollups: %v", err)
 	}
 } 
\ No newline at end of file
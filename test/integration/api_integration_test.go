@@ -5,7 +5,6 @@ package integration
 import (
 	"context"
 	"database/sql"
-	"embed"
 	"fmt"
 	"io"
 	"log/slog"
@@ -16,23 +15,22 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/require"
 
 	"github.com/oliverslade/flood-api/internal/api"
 	postgresrepo "github.com/oliverslade/flood-api/internal/repository/postgres"
+	"github.com/oliverslade/flood-api/internal/repository/postgres/postgrestest"
+	"github.com/oliverslade/flood-api/migrations"
 	"github.com/oliverslade/flood-api/test/integration/testutil"
 )
 
-//go:embed testdata/migrations/*.sql
-var migrationFiles embed.FS
-
 // Test constants
 const (
 	testStationID   = "TEST001"
 	testStationName = "teststation"
+	testGaugeName   = "testgauge"
+	testWriteToken  = "test-write-token"
 )
 
 var (
@@ -43,9 +41,9 @@ var (
 // TestMain sets up shared container for all tests
 func TestMain(m *testing.M) {
 	// Get shared test database
-	testDB = testutil.GetTestDB(&testing.T{})
+	testDB = postgrestest.GetTestDB(&testing.T{})
 	
-	// Apply migrations using embedded files
+	// Apply the same embedded migrations as production
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	
@@ -58,7 +56,7 @@ func TestMain(m *testing.M) {
 	code := m.Run()
 	
 	// Cleanup
-	testutil.Cleanup()
+	postgrestest.Cleanup()
 	os.Exit(code)
 }
 
@@ -78,6 +76,10 @@ func TestFloodAPIIntegration(t *testing.T) {
 	t.Run("Rainfall Endpoints", func(t *testing.T) {
 		testRainfallEndpoints(t, ctx, server.URL)
 	})
+	
+	t.Run("Station Endpoints", func(t *testing.T) {
+		testStationEndpoints(t, ctx, server.URL)
+	})
 }
 
 // createTestServer sets up a complete HTTP server for black-box testing
@@ -95,11 +97,40 @@ func createTestServer(t *testing.T) *httptest.Server {
 	rainfallRepo := postgresrepo.NewRainfallRepo(testDB)
 	rainfallHandler := api.NewRainfallHandler(rainfallRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
 	
+	stationRepo := postgresrepo.NewStationRepo(testDB)
+	stationHandler := api.NewStationHandler(stationRepo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	
 	// Setup router exactly like production
 	router := chi.NewRouter()
-	router.Use(api.TimeoutMiddleware)
-	router.Get("/river", riverHandler.GetReadings)
-	router.Get("/rainfall/{station}", rainfallHandler.GetReadingsByStation)
+	router.Group(func(r chi.Router) {
+		r.Use(api.Timeout(5 * time.Second))
+		r.Get("/river", riverHandler.GetReadings)
+		r.Get("/river/aggregate", riverHandler.GetAggregates)
+		r.Get("/river/latest", riverHandler.GetLatestReading)
+		r.Get("/river/{gauge}", riverHandler.GetReadings)
+		r.Get("/river/{gauge}/aggregate", riverHandler.GetAggregates)
+		r.Get("/river/{gauge}/latest", riverHandler.GetLatestReading)
+		r.Get("/rainfall", rainfallHandler.GetReadingsByStations)
+		r.Get("/rainfall/latest", rainfallHandler.GetLatestReadings)
+		r.Get("/rainfall/{station}", rainfallHandler.GetReadingsByStation)
+		r.Get("/rainfall/{station}/aggregate", rainfallHandler.GetAggregatesByStation)
+		r.Get("/rainfall/{station}/latest", rainfallHandler.GetLatestReadingByStation)
+		r.Get("/stations", stationHandler.ListStations)
+		r.Get("/stations/{station}", stationHandler.GetStation)
+	})
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.Compress(5, "application/json", "text/csv", "application/x-ndjson"))
+		r.Get("/export/river", riverHandler.ExportReadings)
+		r.Get("/export/river/{gauge}", riverHandler.ExportReadings)
+		r.Get("/export/rainfall/{station}", rainfallHandler.ExportReadingsByStation)
+	})
+	router.Group(func(r chi.Router) {
+		r.Use(api.Timeout(5 * time.Second))
+		r.Use(api.RequireBearerToken(testWriteToken))
+		r.Post("/river/readings", riverHandler.PostReadings)
+		r.Post("/river/{gauge}/readings", riverHandler.PostReadings)
+		r.Post("/rainfall/{station}/readings", rainfallHandler.PostReadingsByStation)
+	})
 	
 	return httptest.NewServer(router)
 }
@@ -138,6 +169,53 @@ func testRiverEndpoints(t *testing.T, ctx context.Context, baseURL string) {
 		}
 	})
 	
+	t.Run("pagination metadata", func(t *testing.T) {
+		result := testutil.MustGET(t, ctx, fmt.Sprintf("%s/river?pagesize=2&count=true", baseURL))
+		require.Equal(t, int64(3), result.Total)
+		require.Equal(t, int64(2), result.TotalPages)
+	})
+	
+	t.Run("cursor pagination", func(t *testing.T) {
+		first := testutil.MustGET(t, ctx, fmt.Sprintf("%s/river?pagesize=2", baseURL))
+		require.Len(t, first.Readings, 2)
+		require.NotEmpty(t, first.NextCursor)
+		
+		second := testutil.MustGET(t, ctx, fmt.Sprintf("%s/river?pagesize=2&cursor=%s", baseURL, first.NextCursor))
+		expected := []testutil.Reading{
+			{Timestamp: "2024-01-01T02:00:00Z", Level: 2.5},
+		}
+		testutil.AssertReadingsEqual(t, expected, second.Readings)
+		require.Empty(t, second.NextCursor)
+	})
+	
+	t.Run("date range filtering", func(t *testing.T) {
+		result := testutil.MustGET(t, ctx, fmt.Sprintf("%s/river?from=2024-01-01T01:00:00Z&to=2024-01-01T02:00:00Z", baseURL))
+		require.Len(t, result.Readings, 1)
+		
+		expected := []testutil.Reading{
+			{Timestamp: "2024-01-01T01:00:00Z", Level: 2.0},
+		}
+		testutil.AssertReadingsEqual(t, expected, result.Readings)
+	})
+	
+	t.Run("latest reading", func(t *testing.T) {
+		var result struct {
+			Reading    testutil.Reading `json:"reading"`
+			AgeSeconds int64            `json:"age_seconds"`
+		}
+		testutil.MustGETInto(t, ctx, fmt.Sprintf("%s/river/latest", baseURL), &result)
+		require.Equal(t, "2024-01-01T02:00:00Z", result.Reading.Timestamp)
+		require.Greater(t, result.AgeSeconds, int64(0))
+	})
+	
+	t.Run("aggregation", func(t *testing.T) {
+		var result struct {
+			Buckets []testutil.Bucket `json:"buckets"`
+		}
+		testutil.MustGETInto(t, ctx, fmt.Sprintf("%s/river/aggregate?interval=day&fn=max", baseURL), &result)
+		require.Equal(t, []testutil.Bucket{{Start: "2024-01-01T00:00:00Z", Value: 2.5, Count: 3}}, result.Buckets)
+	})
+	
 	t.Run("error cases", func(t *testing.T) {
 		testCases := []struct {
 			name   string
@@ -146,6 +224,9 @@ func testRiverEndpoints(t *testing.T, ctx context.Context, baseURL string) {
 			{"Invalid page", "?page=invalid"},
 			{"Invalid pagesize", "?pagesize=invalid"},
 			{"Invalid start date", "?start=invalid"},
+			{"Invalid end date", "?end=invalid"},
+			{"End before start", "?start=2024-01-02&end=2024-01-01"},
+			{"Invalid cursor", "?cursor=invalid"},
 			{"Zero page", "?page=0"},
 			{"Negative page", "?page=-1"},
 			{"Zero pagesize", "?pagesize=0"},
@@ -159,6 +240,53 @@ func testRiverEndpoints(t *testing.T, ctx context.Context, baseURL string) {
 			})
 		}
 	})
+	
+	t.Run("export", func(t *testing.T) {
+		result := testutil.MustGET(t, ctx, fmt.Sprintf("%s/export/river", baseURL))
+		require.Len(t, result.Readings, 3)
+		
+		bounded := testutil.MustGET(t, ctx, fmt.Sprintf("%s/export/river?from=2024-01-01T01:00:00Z", baseURL))
+		expected := []testutil.Reading{
+			{Timestamp: "2024-01-01T01:00:00Z", Level: 2.0},
+			{Timestamp: "2024-01-01T02:00:00Z", Level: 2.5},
+		}
+		testutil.AssertReadingsEqual(t, expected, bounded.Readings)
+	})
+	
+	t.Run("export is gzipped", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/export/river?format=csv", baseURL), nil)
+		require.NoError(t, err)
+		
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		
+		// the transport asks for gzip itself and only sets Uncompressed when it decoded the body
+		require.True(t, resp.Uncompressed)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "timestamp,level,gauge\n2024-01-01T00:00:00Z,1.5,rede-bridge\n2024-01-01T01:00:00Z,2,rede-bridge\n2024-01-01T02:00:00Z,2.5,rede-bridge\n", string(body))
+	})
+	
+	t.Run("gauges", func(t *testing.T) {
+		result := testutil.MustGET(t, ctx, fmt.Sprintf("%s/river/%s?count=true", baseURL, testGaugeName))
+		expected := []testutil.Reading{
+			{Timestamp: "2024-01-01T00:00:00Z", Level: 0.3, Gauge: testGaugeName},
+			{Timestamp: "2024-01-01T01:00:00Z", Level: 0.4, Gauge: testGaugeName},
+		}
+		testutil.AssertReadingsEqual(t, expected, result.Readings)
+		require.Equal(t, int64(2), result.Total)
+		
+		alias := testutil.MustGET(t, ctx, fmt.Sprintf("%s/river/rede-bridge", baseURL))
+		require.Len(t, alias.Readings, 3)
+		require.Equal(t, "rede-bridge", alias.Readings[0].Gauge)
+		
+		exported := testutil.MustGET(t, ctx, fmt.Sprintf("%s/export/river/%s", baseURL, testGaugeName))
+		testutil.AssertReadingsEqual(t, expected, exported.Readings)
+		
+		testutil.ExpectHTTPError(t, ctx, fmt.Sprintf("%s/river/non-existent", baseURL), http.StatusNotFound)
+		testutil.ExpectHTTPError(t, ctx, fmt.Sprintf("%s/river/non-existent/latest", baseURL), http.StatusNotFound)
+	})
 }
 
 func testRainfallEndpoints(t *testing.T, ctx context.Context, baseURL string) {
@@ -192,6 +320,27 @@ func testRainfallEndpoints(t *testing.T, ctx context.Context, baseURL string) {
 		}
 	})
 	
+	t.Run("date range filtering", func(t *testing.T) {
+		result := testutil.MustGET(t, ctx, fmt.Sprintf("%s/rainfall/%s?start=2024-01-01&end=2024-01-01", baseURL, testStationName))
+		require.Len(t, result.Readings, 3)
+		
+		result = testutil.MustGET(t, ctx, fmt.Sprintf("%s/rainfall/%s?end=2023-12-31", baseURL, testStationName))
+		require.Len(t, result.Readings, 0)
+	})
+	
+	t.Run("aggregation", func(t *testing.T) {
+		var result struct {
+			Buckets []testutil.Bucket `json:"buckets"`
+		}
+		testutil.MustGETInto(t, ctx, fmt.Sprintf("%s/rainfall/%s/aggregate?interval=hour&fn=sum&from=2024-01-01T01:00:00Z", baseURL, testStationName), &result)
+		require.Equal(t, []testutil.Bucket{
+			{Start: "2024-01-01T01:00:00Z", Value: 0.8, Count: 1},
+			{Start: "2024-01-01T02:00:00Z", Value: 1.2, Count: 1},
+		}, result.Buckets)
+		
+		testutil.ExpectHTTPError(t, ctx, fmt.Sprintf("%s/rainfall/non-existent/aggregate?interval=day&fn=sum", baseURL), http.StatusNotFound)
+	})
+	
 	t.Run("error cases", func(t *testing.T) {
 		testCases := []struct {
 			name   string
@@ -200,6 +349,9 @@ func testRainfallEndpoints(t *testing.T, ctx context.Context, baseURL string) {
 			{"Invalid page", "?page=invalid"},
 			{"Invalid pagesize", "?pagesize=invalid"},
 			{"Invalid start date", "?start=invalid"},
+			{"Invalid end date", "?end=invalid"},
+			{"End before start", "?start=2024-01-02&end=2024-01-01"},
+			{"Invalid cursor", "?cursor=invalid"},
 			{"Zero page", "?page=0"},
 			{"Negative page", "?page=-1"},
 			{"Zero pagesize", "?pagesize=0"},
@@ -214,30 +366,106 @@ func testRainfallEndpoints(t *testing.T, ctx context.Context, baseURL string) {
 		}
 	})
 	
+	t.Run("latest reading", func(t *testing.T) {
+		var single struct {
+			Reading testutil.Reading `json:"reading"`
+		}
+		testutil.MustGETInto(t, ctx, fmt.Sprintf("%s/rainfall/%s/latest", baseURL, testStationName), &single)
+		require.Equal(t, "2024-01-01T02:00:00Z", single.Reading.Timestamp)
+		require.Equal(t, testStationName, single.Reading.Station)
+		
+		var all struct {
+			Readings []struct {
+				Reading testutil.Reading `json:"reading"`
+			} `json:"readings"`
+		}
+		testutil.MustGETInto(t, ctx, fmt.Sprintf("%s/rainfall/latest", baseURL), &all)
+		require.Len(t, all.Readings, 1)
+		require.Equal(t, single.Reading, all.Readings[0].Reading)
+	})
+	
+	t.Run("pagination metadata", func(t *testing.T) {
+		result := testutil.MustGET(t, ctx, fmt.Sprintf("%s/rainfall/%s?from=2024-01-01T01:00:00Z&count=true", baseURL, testStationName))
+		require.Equal(t, int64(2), result.Total)
+		require.Equal(t, int64(1), result.TotalPages)
+	})
+	
 	t.Run("non-existent station", func(t *testing.T) {
 		url := fmt.Sprintf("%s/rainfall/non-existent", baseURL)
 		testutil.ExpectHTTPError(t, ctx, url, http.StatusNotFound)
 	})
+	
+	t.Run("export", func(t *testing.T) {
+		result := testutil.MustGET(t, ctx, fmt.Sprintf("%s/export/rainfall/%s", baseURL, testStationName))
+		require.Len(t, result.Readings, 3)
+		for _, r := range result.Readings {
+			testutil.ValidateReading(t, r, testStationName)
+		}
+		
+		testutil.ExpectHTTPError(t, ctx, fmt.Sprintf("%s/export/rainfall/non-existent", baseURL), http.StatusNotFound)
+	})
+	
+	t.Run("multiple stations", func(t *testing.T) {
+		result := testutil.MustGET(t, ctx, fmt.Sprintf("%s/rainfall?station=%s&count=true", baseURL, testStationName))
+		require.Len(t, result.Readings, 3)
+		require.Equal(t, int64(3), result.Total)
+		
+		all := testutil.MustGET(t, ctx, fmt.Sprintf("%s/rainfall?station=*&from=2024-01-01T01:00:00Z", baseURL))
+		expected := []testutil.Reading{
+			{Timestamp: "2024-01-01T01:00:00Z", Level: 0.8, Station: testStationName},
+			{Timestamp: "2024-01-01T02:00:00Z", Level: 1.2, Station: testStationName},
+		}
+		testutil.AssertReadingsEqual(t, expected, all.Readings)
+		
+		testutil.ExpectHTTPError(t, ctx, fmt.Sprintf("%s/rainfall?station=%s&station=non-existent", baseURL, testStationName), http.StatusBadRequest)
+	})
 }
 
-// applyTestMigrations runs migrations on the test database
+func testStationEndpoints(t *testing.T, ctx context.Context, baseURL string) {
+	t.Run("list stations", func(t *testing.T) {
+		var result struct {
+			Stations []testutil.Station `json:"stations"`
+		}
+		testutil.MustGETInto(t, ctx, fmt.Sprintf("%s/stations", baseURL), &result)
+		require.Len(t, result.Stations, 1)
+		
+		expected := testutil.Station{
+			ID:           testStationID,
+			Name:         testStationName,
+			FirstReading: "2024-01-01T00:00:00Z",
+			LastReading:  "2024-01-01T02:00:00Z",
+			ReadingCount: 3,
+		}
+		require.Equal(t, expected, result.Stations[0])
+	})
+	
+	t.Run("get station", func(t *testing.T) {
+		var result struct {
+			Station testutil.Station `json:"station"`
+		}
+		testutil.MustGETInto(t, ctx, fmt.Sprintf("%s/stations/%s", baseURL, testStationName), &result)
+		require.Equal(t, testStationID, result.Station.ID)
+		require.Equal(t, int64(3), result.Station.ReadingCount)
+	})
+	
+	t.Run("non-existent station", func(t *testing.T) {
+		url := fmt.Sprintf("%s/stations/non-existent", baseURL)
+		testutil.ExpectHTTPError(t, ctx, url, http.StatusNotFound)
+	})
+}
+
+// applyTestMigrations runs the embedded migrations on the test database, as the server does at startup
 func applyTestMigrations(ctx context.Context) error {
 	// Get connection string from shared test infrastructure
-	connStr := testutil.GetTestDBConnString()
-	
-	// Apply migrations from embedded files
-	source, err := iofs.New(migrationFiles, "testdata/migrations")
-	if err != nil {
-		return fmt.Errorf("failed to create migration source: %w", err)
-	}
+	connStr := postgrestest.GetTestDBConnString()
 	
-	m, err := migrate.NewWithSourceInstance("iofs", source, connStr)
+	migrator, err := migrations.New(connStr)
 	if err != nil {
 		return fmt.Errorf("failed to create migrator: %w", err)
 	}
-	defer m.Close()
+	defer migrator.Close()
 	
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+	if err := migrator.Up(); err != nil {
 		return fmt.Errorf("failed to apply migrations: %w", err)
 	}
 	
@@ -255,9 +483,16 @@ func cleanDB(t testing.TB, db *sql.DB) {
 	_, err := db.ExecContext(ctx, "DELETE FROM rainfalls")
 	require.NoError(t, err)
 	
+	_, err = db.ExecContext(ctx, "TRUNCATE rainfall_hourly, rainfall_daily, rainfall_rollup_pending")
+	require.NoError(t, err)
+	
 	_, err = db.ExecContext(ctx, "DELETE FROM riverlevels")
 	require.NoError(t, err)
 	
+	// Keep the default gauge added by migration 007
+	_, err = db.ExecContext(ctx, "DELETE FROM rivergauges WHERE id <> 'rede-bridge'")
+	require.NoError(t, err)
+	
 	_, err = db.ExecContext(ctx, "DELETE FROM stationnames")
 	require.NoError(t, err)
 }
@@ -275,13 +510,21 @@ func seedTestData(t testing.TB, db *sql.DB, stationID, stationName string, baseT
 	)
 	require.NoError(t, err)
 	
-	// Batch insert river levels
+	_, err = db.ExecContext(ctx,
+		"INSERT INTO rivergauges (id, name, river) VALUES ($1, $1, 'Test River')",
+		testGaugeName,
+	)
+	require.NoError(t, err)
+	
+	// Batch insert river levels, mostly for the default gauge served by /river
 	_, err = db.ExecContext(ctx, `
-		INSERT INTO riverlevels (timestamp, level) VALUES 
-		($1, 1.5), ($2, 2.0), ($3, 2.5)`,
+		INSERT INTO riverlevels (gaugeid, timestamp, level) VALUES 
+		('rede-bridge', $1, 1.5), ('rede-bridge', $2, 2.0), ('rede-bridge', $3, 2.5),
+		($4, $1, 0.3), ($4, $2, 0.4)`,
 		baseTime,
 		baseTime.Add(1*time.Hour),
 		baseTime.Add(2*time.Hour),
+		testGaugeName,
 	)
 	require.NoError(t, err)
 	
@@ -294,4 +537,7 @@ func seedTestData(t testing.TB, db *sql.DB, stationID, stationName string, baseT
 		stationID, baseTime.Add(2*time.Hour),
 	)
 	require.NoError(t, err)
+	
+	// Plain SQL inserts don't mark their hours for a refresh, so roll them up from scratch
+	require.NoError(t, postgresrepo.RebuildRollups(ctx, db))
 } 
\ No newline at end of file
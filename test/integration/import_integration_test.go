@@ -0,0 +1,84 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	"github.com/oliverslade/flood-api/internal/importer"
+	postgresrepo "github.com/oliverslade/flood-api/internal/repository/postgres"
+)
+
+func TestImportIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cleanDB(t, testDB)
+	seedTestData(t, testDB, testStationID, testStationName, baseTime)
+
+	dir := t.TempDir()
+	writeCSV := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	src := &importer.CSVSource{
+		// the seeded station is skipped, the nameless one rejected
+		StationsPath: writeCSV("stationnames.csv", "id,name\n"+testStationID+","+testStationName+"\nIMPORT001,importstation\nIMPORT002,\n"),
+		// +1h is already seeded with a different level and must not be overwritten, UNKNOWN isn't a station
+		RainfallPath: writeCSV("rainfalls.csv", "stationid,timestamp,level\n"+
+			testStationID+",2024-01-01T01:00:00,9.9\n"+
+			testStationID+",2024-01-01T05:00:00,0.1\n"+
+			"IMPORT001,2024-01-01 05:00:00+00,0.2\n"+
+			"IMPORT001,2024-01-01 05:00:00+00,0.2\n"+
+			"UNKNOWN,2024-01-01T05:00:00,0.3\n"+
+			"IMPORT001,not a time,0.4\n"),
+		RiverLevelsPath: writeCSV("riverlevels.csv", "timestamp,level\n2024-01-01T00:00:00,9.9\n2024-01-01T05:00:00,0.6\n"),
+	}
+
+	imp := importer.New(postgresrepo.NewImportRepo(testDB), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	countRows := func(t *testing.T, query string, args ...any) int {
+		var n int
+		require.NoError(t, testDB.QueryRowContext(ctx, query, args...).Scan(&n))
+		return n
+	}
+
+	t.Run("stores only new rows", func(t *testing.T) {
+		report, err := imp.Import(ctx, src, testGaugeName)
+		require.NoError(t, err)
+
+		assert.Equal(t, domain.ImportResult{Imported: 1, Skipped: 1, Rejected: 1}, report.Stations)
+		assert.Equal(t, domain.ImportResult{Imported: 2, Skipped: 2, Rejected: 2}, report.Rainfall)
+		assert.Equal(t, domain.ImportResult{Imported: 1, Skipped: 1}, report.RiverLevels)
+
+		assert.Equal(t, 1, countRows(t, "SELECT COUNT(*) FROM stationnames WHERE id = 'IMPORT001' AND name = 'importstation'"))
+		assert.Equal(t, 1, countRows(t, "SELECT COUNT(*) FROM rainfalls WHERE stationid = 'IMPORT001' AND timestamp = $1", baseTime.Add(5*time.Hour)))
+		assert.Equal(t, 0, countRows(t, "SELECT COUNT(*) FROM rainfalls WHERE level = 9.9"))
+		assert.Equal(t, 0, countRows(t, "SELECT COUNT(*) FROM riverlevels WHERE level = 9.9"))
+		assert.Equal(t, 1, countRows(t, "SELECT COUNT(*) FROM riverlevels WHERE gaugeid = $1 AND timestamp = $2", testGaugeName, baseTime.Add(5*time.Hour)))
+	})
+
+	t.Run("importing again skips everything", func(t *testing.T) {
+		report, err := imp.Import(ctx, src, testGaugeName)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(0), report.Stations.Imported+report.Rainfall.Imported+report.RiverLevels.Imported)
+		assert.Equal(t, int64(4), report.Rainfall.Skipped)
+	})
+
+	t.Run("unknown gauge", func(t *testing.T) {
+		_, err := imp.Import(ctx, src, "non-existent-gauge")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
@@ -0,0 +1,106 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/config"
+	"github.com/oliverslade/flood-api/internal/ingest"
+	postgresrepo "github.com/oliverslade/flood-api/internal/repository/postgres"
+)
+
+// defraPayload renders a Defra readings response for one measure, ignoring since so every
+// poll repeats readings the database already has
+func defraPayload(measure string, readings map[time.Time]float64) string {
+	items := ""
+	for ts, value := range readings {
+		if items != "" {
+			items += ","
+		}
+		items += fmt.Sprintf(`{"dateTime":%q,"measure":"http://environment.data.gov.uk/flood-monitoring/id/measures/%s","value":%v}`,
+			ts.Format(time.RFC3339), measure, value)
+	}
+	return `{"items":[` + items + `]}`
+}
+
+func TestIngestIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cleanDB(t, testDB)
+	seedTestData(t, testDB, testStationID, testStationName, baseTime)
+
+	payloads := map[string]string{
+		// the reading at +2h is already seeded with a different level and must not be duplicated or overwritten
+		"/id/stations/" + testStationID + "/readings": defraPayload(testStationID+"-rainfall-tipping_bucket_raingauge-t-15_min-mm", map[time.Time]float64{
+			baseTime.Add(2 * time.Hour): 9.9,
+			baseTime.Add(3 * time.Hour): 1.6,
+		}),
+		"/id/stations/22009/readings": defraPayload("22009-level-stage-i-15_min-m", map[time.Time]float64{
+			baseTime.Add(1 * time.Hour): 9.9,
+			baseTime.Add(2 * time.Hour): 0.5,
+		}),
+	}
+	defra := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, ok := payloads[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, payload)
+	}))
+	defer defra.Close()
+
+	ingester := ingest.New(
+		ingest.NewClient(defra.URL, defra.Client()),
+		postgresrepo.NewIngestRepo(testDB),
+		[]config.Gauge{{Name: testGaugeName, Reference: "22009"}},
+		ingest.DefaultLookback,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+
+	countRows := func(t *testing.T, query string, args ...any) int {
+		var n int
+		require.NoError(t, testDB.QueryRowContext(ctx, query, args...).Scan(&n))
+		return n
+	}
+
+	t.Run("stores only new readings", func(t *testing.T) {
+		inserted, err := ingester.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), inserted)
+
+		assert.Equal(t, 4, countRows(t, "SELECT COUNT(*) FROM rainfalls WHERE stationid = $1", testStationID))
+		assert.Equal(t, 3, countRows(t, "SELECT COUNT(*) FROM riverlevels WHERE gaugeid = $1", testGaugeName))
+		assert.Equal(t, 1, countRows(t, "SELECT COUNT(*) FROM rainfalls WHERE stationid = $1 AND timestamp = $2 AND level = 1.6", testStationID, baseTime.Add(3*time.Hour)))
+		assert.Equal(t, 0, countRows(t, "SELECT COUNT(*) FROM rainfalls WHERE level = 9.9"))
+		assert.Equal(t, 0, countRows(t, "SELECT COUNT(*) FROM riverlevels WHERE level = 9.9"))
+	})
+
+	t.Run("repeating a poll is a no-op", func(t *testing.T) {
+		inserted, err := ingester.RunOnce(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), inserted)
+
+		assert.Equal(t, 4, countRows(t, "SELECT COUNT(*) FROM rainfalls WHERE stationid = $1", testStationID))
+		assert.Equal(t, 3, countRows(t, "SELECT COUNT(*) FROM riverlevels WHERE gaugeid = $1", testGaugeName))
+	})
+
+	t.Run("unknown gauge", func(t *testing.T) {
+		repo := postgresrepo.NewIngestRepo(testDB)
+		_, err := repo.GetLatestRiverTimestamp(ctx, "non-existent-gauge")
+		assert.Error(t, err)
+	})
+}
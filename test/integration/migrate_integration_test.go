@@ -0,0 +1,136 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/constants"
+	"github.com/oliverslade/flood-api/internal/repository/postgres/postgrestest"
+	"github.com/oliverslade/flood-api/migrations"
+)
+
+func TestMigrateIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cleanDB(t, testDB)
+
+	all, err := migrations.List()
+	require.NoError(t, err)
+	latest := all[len(all)-1].Version
+
+	migrator, err := migrations.New(postgrestest.GetTestDBConnString())
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	// whatever happens, leave the database migrated for the other tests
+	defer func() {
+		require.NoError(t, migrator.Up())
+	}()
+
+	tableExists := func(t *testing.T, name string) bool {
+		var exists bool
+		require.NoError(t, testDB.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", "public."+name).Scan(&exists))
+		return exists
+	}
+
+	t.Run("migrated to the latest version", func(t *testing.T) {
+		version, dirty, err := migrator.Version()
+		require.NoError(t, err)
+		assert.Equal(t, latest, version)
+		assert.False(t, dirty)
+
+		status, err := migrator.Status()
+		require.NoError(t, err)
+		require.Len(t, status, len(all))
+		for _, m := range status {
+			assert.True(t, m.Applied, m.Name)
+		}
+		assert.NoError(t, migrations.CheckVersion(ctx, testDB))
+	})
+
+	t.Run("gauges can't take the names of the static river routes", func(t *testing.T) {
+		for _, name := range constants.ReservedGaugeNames {
+			_, err := testDB.ExecContext(ctx, "INSERT INTO rivergauges (id, name, river) VALUES ($1, $1, 'River Rede')", name)
+			assert.ErrorContains(t, err, "rivergauges_name_check", name)
+		}
+	})
+
+	t.Run("down one step", func(t *testing.T) {
+		require.NoError(t, migrator.Down(1))
+
+		version, _, err := migrator.Version()
+		require.NoError(t, err)
+		assert.Equal(t, latest-1, version)
+
+		status, err := migrator.Status()
+		require.NoError(t, err)
+		assert.False(t, status[len(status)-1].Applied)
+		assert.ErrorContains(t, migrations.CheckVersion(ctx, testDB), "want")
+	})
+
+	t.Run("down to nothing and back up", func(t *testing.T) {
+		require.NoError(t, migrator.Down(int(latest)))
+
+		version, _, err := migrator.Version()
+		require.NoError(t, err)
+		assert.Equal(t, uint(0), version)
+		assert.False(t, tableExists(t, "stationnames"))
+		assert.False(t, tableExists(t, "rivergauges"))
+
+		require.NoError(t, migrator.Up())
+		version, _, err = migrator.Version()
+		require.NoError(t, err)
+		assert.Equal(t, latest, version)
+		assert.True(t, tableExists(t, "rivergauges"))
+	})
+
+	t.Run("moves duplicate readings aside rather than deleting them", func(t *testing.T) {
+		require.NoError(t, migrator.Down(int(latest-7)))
+		ts := time.Date(2019, 3, 10, 9, 0, 0, 0, time.UTC)
+		for _, level := range []float64{0.2, 0.5, 0.2} {
+			_, err := testDB.ExecContext(ctx, "INSERT INTO public.rainfalls (stationid, timestamp, level) VALUES ('dup', $1, $2)", ts, level)
+			require.NoError(t, err)
+		}
+
+		levels := func(t *testing.T, table string) []float64 {
+			rows, err := testDB.QueryContext(ctx, "SELECT level FROM public."+table+" WHERE stationid = 'dup' ORDER BY level")
+			require.NoError(t, err)
+			defer rows.Close()
+			var levels []float64
+			for rows.Next() {
+				var level float64
+				require.NoError(t, rows.Scan(&level))
+				levels = append(levels, level)
+			}
+			require.NoError(t, rows.Err())
+			return levels
+		}
+
+		require.NoError(t, migrator.Up())
+		assert.Equal(t, []float64{0.5}, levels(t, "rainfalls"), "the highest level is kept")
+		assert.Equal(t, []float64{0.2, 0.2}, levels(t, "rainfalls_duplicates"))
+
+		require.NoError(t, migrator.Down(int(latest-7)))
+		assert.Equal(t, []float64{0.2, 0.2, 0.5}, levels(t, "rainfalls"), "down puts them back")
+		assert.False(t, tableExists(t, "rainfalls_duplicates"))
+
+		_, err := testDB.ExecContext(ctx, "DELETE FROM public.rainfalls WHERE stationid = 'dup'")
+		require.NoError(t, err)
+		require.NoError(t, migrator.Up())
+	})
+
+	t.Run("refuses to migrate tables without a version", func(t *testing.T) {
+		require.NoError(t, migrator.Force(-1))
+		assert.Error(t, migrator.Up())
+
+		require.NoError(t, migrator.Force(int(latest)))
+		require.NoError(t, migrator.Up())
+	})
+}
@@ -0,0 +1,121 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	postgresrepo "github.com/oliverslade/flood-api/internal/repository/postgres"
+)
+
+func TestPartitionIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cleanDB(t, testDB)
+	_, err := testDB.ExecContext(ctx, "INSERT INTO stationnames (id, name) VALUES ($1, $2)", testStationID, testStationName)
+	require.NoError(t, err)
+
+	// partitionOf returns the partition holding a reading
+	partitionOf := func(t *testing.T, table, keyColumn, key string, ts time.Time) string {
+		var name string
+		require.NoError(t, testDB.QueryRowContext(ctx,
+			"SELECT tableoid::regclass::text FROM "+table+" WHERE "+keyColumn+" = $1 AND timestamp = $2", key, ts).Scan(&name))
+		return name
+	}
+	countRows := func(t *testing.T, table string) int {
+		var n int
+		require.NoError(t, testDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	t.Run("reading tables are partitioned", func(t *testing.T) {
+		for _, table := range []string{"rainfalls", "riverlevels"} {
+			var kind string
+			require.NoError(t, testDB.QueryRowContext(ctx, "SELECT relkind::text FROM pg_class WHERE oid = $1::regclass", "public."+table).Scan(&kind))
+			assert.Equal(t, "p", kind, table)
+		}
+	})
+
+	t.Run("moves readings out of the default partition", func(t *testing.T) {
+		march := time.Date(2019, 3, 10, 9, 0, 0, 0, time.UTC)
+		april := time.Date(2019, 4, 2, 9, 0, 0, 0, time.UTC)
+		for _, ts := range []time.Time{march, april} {
+			_, err := testDB.ExecContext(ctx, "INSERT INTO rainfalls (stationid, timestamp, level) VALUES ($1, $2, 0.2)", testStationID, ts)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, "rainfalls_default", partitionOf(t, "rainfalls", "stationid", testStationID, march))
+
+		require.NoError(t, postgresrepo.PartitionReadings(ctx, testDB, time.Date(2019, 4, 15, 0, 0, 0, 0, time.UTC)))
+
+		assert.Equal(t, "rainfalls_2019_03", partitionOf(t, "rainfalls", "stationid", testStationID, march))
+		assert.Equal(t, "rainfalls_2019_04", partitionOf(t, "rainfalls", "stationid", testStationID, april))
+		assert.Equal(t, 0, countRows(t, "rainfalls_default"))
+		assert.Equal(t, 0, countRows(t, "rainfalls_2019_06"), "the coming months are created ahead of their readings")
+	})
+
+	t.Run("creates the partitions for the coming months", func(t *testing.T) {
+		require.NoError(t, postgresrepo.CreatePartitions(ctx, testDB, time.Date(2019, 8, 31, 23, 45, 0, 0, time.UTC)))
+
+		ts := time.Date(2019, 9, 1, 6, 0, 0, 0, time.UTC)
+		inserted, err := postgresrepo.NewIngestRepo(testDB).InsertRiverReadings(ctx, "rede-bridge", []domain.Measurement{{Timestamp: ts, Level: 0.4}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), inserted)
+
+		assert.Equal(t, "riverlevels_2019_09", partitionOf(t, "riverlevels", "gaugeid", "rede-bridge", ts))
+		assert.Equal(t, 0, countRows(t, "riverlevels_2019_10"))
+		assert.Equal(t, 0, countRows(t, "rainfalls_2019_10"))
+	})
+
+	t.Run("writes leave months without a partition in the default", func(t *testing.T) {
+		stray := time.Date(2019, 11, 5, 12, 0, 0, 0, time.UTC)
+		require.NoError(t, postgresrepo.NewRiverRepo(testDB).UpsertReadings(ctx, "rede-bridge", []domain.Measurement{{Timestamp: stray, Level: 0.5}}))
+		assert.Equal(t, "riverlevels_default", partitionOf(t, "riverlevels", "gaugeid", "rede-bridge", stray))
+
+		// the scheduled job moves them once the month comes into range
+		require.NoError(t, postgresrepo.CreatePartitions(ctx, testDB, time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, "riverlevels_2019_11", partitionOf(t, "riverlevels", "gaugeid", "rede-bridge", stray))
+		assert.Equal(t, 0, countRows(t, "riverlevels_default"))
+	})
+
+	t.Run("ingests create the partitions for the months they write", func(t *testing.T) {
+		ts := time.Date(2020, 2, 14, 6, 0, 0, 0, time.UTC)
+		repo := postgresrepo.NewIngestRepo(testDB)
+
+		_, err := repo.InsertRiverReadings(ctx, "rede-bridge", []domain.Measurement{{Timestamp: ts, Level: 0.6}})
+		require.NoError(t, err)
+		_, err = repo.InsertRainfallReadings(ctx, testStationID, []domain.Measurement{{Timestamp: ts, Level: 0.1}})
+		require.NoError(t, err)
+
+		assert.Equal(t, "riverlevels_2020_02", partitionOf(t, "riverlevels", "gaugeid", "rede-bridge", ts))
+		assert.Equal(t, "rainfalls_2020_02", partitionOf(t, "rainfalls", "stationid", testStationID, ts))
+		assert.Equal(t, 0, countRows(t, "riverlevels_2020_03"), "the month after the batch is created ahead of its readings")
+	})
+
+	t.Run("date filters only scan the months they cover", func(t *testing.T) {
+		rows, err := testDB.QueryContext(ctx, `EXPLAIN SELECT timestamp, level FROM rainfalls
+WHERE stationid = $1 AND timestamp >= '2019-04-01' AND timestamp < '2019-05-01' ORDER BY timestamp LIMIT 12`, testStationID)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var plan []string
+		for rows.Next() {
+			var line string
+			require.NoError(t, rows.Scan(&line))
+			plan = append(plan, line)
+		}
+		require.NoError(t, rows.Err())
+
+		joined := strings.Join(plan, "\n")
+		assert.Contains(t, joined, "rainfalls_2019_04")
+		assert.NotContains(t, joined, "rainfalls_2019_03")
+		assert.NotContains(t, joined, "rainfalls_default")
+	})
+}
@@ -0,0 +1,27 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/oliverslade/flood-api/internal/repository/inmemory"
+	"github.com/oliverslade/flood-api/internal/repository/postgres"
+	"github.com/oliverslade/flood-api/internal/repository/repositorytest"
+)
+
+// TestRepositoryConformance checks the Postgres repositories agree with the other backends
+func TestRepositoryConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T, data inmemory.Dataset) repositorytest.Repositories {
+		cleanDB(t, testDB)
+
+		repos := repositorytest.Repositories{
+			River:    postgres.NewRiverRepo(testDB),
+			Rainfall: postgres.NewRainfallRepo(testDB),
+			Station:  postgres.NewStationRepo(testDB),
+		}
+		repositorytest.Load(t, testDB, func(n int) string { return fmt.Sprintf("$%d", n) }, repos, data)
+		return repos
+	}, repositorytest.Seed())
+}
@@ -0,0 +1,129 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	postgresrepo "github.com/oliverslade/flood-api/internal/repository/postgres"
+)
+
+func TestRollupIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cleanDB(t, testDB)
+	_, err := testDB.ExecContext(ctx, "INSERT INTO stationnames (id, name) VALUES ($1, $2)", testStationID, testStationName)
+	require.NoError(t, err)
+
+	rainfallRepo := postgresrepo.NewRainfallRepo(testDB)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var readings []domain.Measurement
+	for i := 0; i < 2*24*4; i++ {
+		readings = append(readings, domain.Measurement{Timestamp: day.Add(time.Duration(i) * 15 * time.Minute), Level: float64(i%4) * 0.2})
+	}
+	require.NoError(t, rainfallRepo.UpsertReadingsByStation(ctx, testStationName, readings))
+
+	aggregate := func(t *testing.T, interval string, fn string, start, end *time.Time) []domain.AggregateBucket {
+		buckets, err := rainfallRepo.GetAggregatesByStation(ctx, domain.GetRainfallAggregateParams{
+			StationName:     testStationName,
+			AggregateParams: domain.AggregateParams{Interval: interval, Func: fn, StartDate: start, EndDate: end},
+		})
+		require.NoError(t, err)
+		return buckets
+	}
+	countRows := func(t *testing.T, table string) int {
+		var n int
+		require.NoError(t, testDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	raw := map[string][]domain.AggregateBucket{
+		domain.IntervalHour: aggregate(t, domain.IntervalHour, domain.AggregateSum, nil, nil),
+		domain.IntervalDay:  aggregate(t, domain.IntervalDay, domain.AggregateMean, nil, nil),
+	}
+	require.Len(t, raw[domain.IntervalHour], 48)
+	require.Len(t, raw[domain.IntervalDay], 2)
+
+	t.Run("writes mark their hours pending until a refresh", func(t *testing.T) {
+		assert.Equal(t, 48, countRows(t, "rainfall_rollup_pending"))
+		assert.Equal(t, 0, countRows(t, "rainfall_hourly"))
+
+		refreshed, err := postgresrepo.RefreshRollups(ctx, testDB)
+		require.NoError(t, err)
+		assert.Equal(t, int64(48), refreshed)
+
+		assert.Equal(t, 0, countRows(t, "rainfall_rollup_pending"))
+		assert.Equal(t, 48, countRows(t, "rainfall_hourly"))
+		assert.Equal(t, 2, countRows(t, "rainfall_daily"))
+	})
+
+	t.Run("refreshed rollups answer like the raw readings", func(t *testing.T) {
+		fns := map[string]string{domain.IntervalHour: domain.AggregateSum, domain.IntervalDay: domain.AggregateMean}
+		for interval, want := range raw {
+			got := aggregate(t, interval, fns[interval], nil, nil)
+			require.Len(t, got, len(want), interval)
+			for i := range got {
+				assert.Equal(t, want[i].Start, got[i].Start, interval)
+				assert.Equal(t, want[i].Count, got[i].Count, interval)
+				// sums may add the same readings in a different order
+				assert.InDelta(t, want[i].Value, got[i].Value, 1e-9, interval)
+			}
+		}
+	})
+
+	t.Run("aligned bounds are answered from the rollups", func(t *testing.T) {
+		// readings removed behind the repository's back only drop out of the raw answer
+		_, err := testDB.ExecContext(ctx, "DELETE FROM rainfalls WHERE stationid = $1 AND timestamp < $2", testStationID, day.Add(24*time.Hour))
+		require.NoError(t, err)
+
+		start, end := day, day.Add(48*time.Hour)
+		assert.Len(t, aggregate(t, domain.IntervalDay, domain.AggregateSum, &start, &end), 2)
+		assert.Len(t, aggregate(t, domain.IntervalHour, domain.AggregateSum, &start, &end), 48)
+
+		unaligned := day.Add(30 * time.Minute)
+		assert.Len(t, aggregate(t, domain.IntervalHour, domain.AggregateSum, &unaligned, &end), 24)
+
+		require.NoError(t, postgresrepo.RebuildRollups(ctx, testDB))
+		assert.Len(t, aggregate(t, domain.IntervalDay, domain.AggregateSum, &start, &end), 1)
+	})
+
+	t.Run("pending hours fall back to the raw readings", func(t *testing.T) {
+		late := day.Add(47*time.Hour + 45*time.Minute)
+		require.NoError(t, rainfallRepo.UpsertReadingsByStation(ctx, testStationName, []domain.Measurement{{Timestamp: late, Level: 10}}))
+
+		buckets := aggregate(t, domain.IntervalDay, domain.AggregateMax, nil, nil)
+		require.Len(t, buckets, 1)
+		assert.Equal(t, 10.0, buckets[0].Value)
+
+		_, err := postgresrepo.RefreshRollups(ctx, testDB)
+		require.NoError(t, err)
+		var maximum float64
+		require.NoError(t, testDB.QueryRowContext(ctx, "SELECT maximum FROM rainfall_daily WHERE stationid = $1", testStationID).Scan(&maximum))
+		assert.Equal(t, 10.0, maximum)
+	})
+
+	t.Run("ingest and import mark their hours pending", func(t *testing.T) {
+		next := day.Add(72 * time.Hour)
+		inserted, err := postgresrepo.NewIngestRepo(testDB).InsertRainfallReadings(ctx, testStationID, []domain.Measurement{{Timestamp: next, Level: 0.4}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), inserted)
+
+		_, err = postgresrepo.NewImportRepo(testDB).ImportRainfall(ctx, func(add func(string, domain.Measurement) error) error {
+			return add(testStationID, domain.Measurement{Timestamp: next.Add(2 * time.Hour), Level: 0.6})
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, countRows(t, "rainfall_rollup_pending"))
+
+		require.NoError(t, postgresrepo.NewIngestRepo(testDB).RefreshRollups(ctx))
+		assert.Equal(t, 0, countRows(t, "rainfall_rollup_pending"))
+		start, end := next, next.Add(24*time.Hour)
+		assert.Len(t, aggregate(t, domain.IntervalHour, domain.AggregateSum, &start, &end), 2)
+	})
+}
@@ -20,7 +20,10 @@ var (
 
 // APIResponse represents the standard readings response
 type APIResponse struct {
-	Readings []Reading `json:"readings"`
+	Readings   []Reading `json:"readings"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Total      int64     `json:"total,omitempty"`
+	TotalPages int64     `json:"total_pages,omitempty"`
 }
 
 // Reading represents a measurement reading
@@ -28,6 +31,23 @@ type Reading struct {
 	Timestamp string  `json:"timestamp"`
 	Level     float64 `json:"level"`
 	Station   string  `json:"station,omitempty"`
+	Gauge     string  `json:"gauge,omitempty"`
+}
+
+// Station represents a station summary
+type Station struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	FirstReading string `json:"first_reading"`
+	LastReading  string `json:"last_reading"`
+	ReadingCount int64  `json:"reading_count"`
+}
+
+// Bucket represents an aggregated time bucket
+type Bucket struct {
+	Start string  `json:"start"`
+	Value float64 `json:"value"`
+	Count int64   `json:"count"`
 }
 
 var HTTPClient = &http.Client{
@@ -58,6 +78,27 @@ func MustGET(tb testing.TB, ctx context.Context, url string) APIResponse {
 	return result
 }
 
+// MustGETInto performs a GET request and decodes the JSON response into dest
+func MustGETInto(tb testing.TB, ctx context.Context, url string, dest interface{}) {
+	tb.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	require.NoError(tb, err)
+
+	resp, err := HTTPClient.Do(req)
+	require.NoError(tb, err)
+	defer resp.Body.Close()
+
+	require.Equal(tb, http.StatusOK, resp.StatusCode, "URL: %s", url)
+	assert.Equal(tb, "application/json", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(tb, err)
+
+	err = json.Unmarshal(body, dest)
+	require.NoError(tb, err)
+}
+
 // ExpectHTTPError performs a GET and expects a specific status code
 func ExpectHTTPError(tb testing.TB, ctx context.Context, url string, expectedStatus int) {
 	tb.Helper()
@@ -109,5 +150,8 @@ func AssertReadingsEqual(tb testing.TB, expected []Reading, actual []Reading) {
 		if exp.Station != "" {
 			assert.Equal(tb, exp.Station, act.Station, "Reading %d station", i)
 		}
+		if exp.Gauge != "" {
+			assert.Equal(tb, exp.Gauge, act.Gauge, "Reading %d gauge", i)
+		}
 	}
 }
@@ -0,0 +1,66 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/oliverslade/flood-api/internal/domain"
+	postgresrepo "github.com/oliverslade/flood-api/internal/repository/postgres"
+	"github.com/oliverslade/flood-api/internal/tracing"
+)
+
+func TestQuerySpanIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cleanDB(t, testDB)
+	seedTestData(t, testDB, testStationID, testStationName, baseTime)
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	// rowCount returns the row count on the span of the named query
+	rowCount := func(t *testing.T, name string) int64 {
+		for _, span := range recorder.Ended() {
+			if span.Name() != name {
+				continue
+			}
+			for _, attr := range span.Attributes() {
+				if attr.Key == tracing.RowCountKey {
+					return attr.Value.AsInt64()
+				}
+			}
+			t.Fatalf("span %s has no row count", name)
+		}
+		t.Fatalf("no span named %s", name)
+		return 0
+	}
+
+	t.Run("query spans count the rows returned", func(t *testing.T) {
+		_, err := postgresrepo.NewRiverRepo(testDB).GetReadings(ctx, domain.GetRiverParams{
+			GaugeName:         "rede-bridge",
+			GetReadingsParams: domain.GetReadingsParams{Pagination: domain.PaginationParams{Page: 1, PageSize: 2}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), rowCount(t, "GetRiverReadings"))
+	})
+
+	t.Run("exec spans count the rows affected", func(t *testing.T) {
+		_, err := postgresrepo.NewIngestRepo(testDB).InsertRiverReadings(ctx, "rede-bridge", []domain.Measurement{
+			{Timestamp: baseTime, Level: 9.9},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), rowCount(t, "InsertRiverReading"), "the reading already exists")
+	})
+}
@@ -0,0 +1,86 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oliverslade/flood-api/test/integration/testutil"
+)
+
+func TestWriteIntegration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	server := createTestServer(t)
+	defer server.Close()
+
+	post := func(t *testing.T, path, token, body string) int {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("rainfall upsert is safe to retry", func(t *testing.T) {
+		// 01:00 already exists with level 0.8, 03:00 is new
+		body := `{"readings":[{"timestamp":"2024-01-01T01:00:00Z","level":0.9},{"timestamp":"2024-01-01T03:00:00Z","level":1.4}]}`
+		for attempt := 0; attempt < 2; attempt++ {
+			require.Equal(t, http.StatusOK, post(t, "/rainfall/"+testStationName+"/readings", testWriteToken, body))
+		}
+
+		result := testutil.MustGET(t, ctx, fmt.Sprintf("%s/rainfall/%s", server.URL, testStationName))
+		expected := []testutil.Reading{
+			{Timestamp: "2024-01-01T00:00:00Z", Level: 0.5},
+			{Timestamp: "2024-01-01T01:00:00Z", Level: 0.9},
+			{Timestamp: "2024-01-01T02:00:00Z", Level: 1.2},
+			{Timestamp: "2024-01-01T03:00:00Z", Level: 1.4},
+		}
+		testutil.AssertReadingsEqual(t, expected, result.Readings)
+	})
+
+	t.Run("river upsert for a gauge", func(t *testing.T) {
+		body := `{"readings":[{"timestamp":"2024-01-01T02:00:00+01:00","level":0.35}]}`
+		for attempt := 0; attempt < 2; attempt++ {
+			require.Equal(t, http.StatusOK, post(t, "/river/"+testGaugeName+"/readings", testWriteToken, body))
+		}
+
+		result := testutil.MustGET(t, ctx, fmt.Sprintf("%s/river/%s", server.URL, testGaugeName))
+		expected := []testutil.Reading{
+			{Timestamp: "2024-01-01T00:00:00Z", Level: 0.3},
+			{Timestamp: "2024-01-01T01:00:00Z", Level: 0.35},
+		}
+		testutil.AssertReadingsEqual(t, expected, result.Readings)
+
+		// the default gauge is untouched
+		require.Len(t, testutil.MustGET(t, ctx, fmt.Sprintf("%s/river", server.URL)).Readings, 3)
+	})
+
+	t.Run("requires the write token", func(t *testing.T) {
+		body := `{"readings":[{"timestamp":"2024-01-01T05:00:00Z","level":1.0}]}`
+		require.Equal(t, http.StatusUnauthorized, post(t, "/river/readings", "", body))
+		require.Equal(t, http.StatusUnauthorized, post(t, "/river/readings", "wrong", body))
+		require.Len(t, testutil.MustGET(t, ctx, fmt.Sprintf("%s/river", server.URL)).Readings, 3)
+	})
+
+	t.Run("error cases", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, post(t, "/rainfall/non-existent/readings", testWriteToken, `{"readings":[{"timestamp":"2024-01-01T05:00:00Z","level":1.0}]}`))
+		require.Equal(t, http.StatusNotFound, post(t, "/river/non-existent/readings", testWriteToken, `{"readings":[{"timestamp":"2024-01-01T05:00:00Z","level":1.0}]}`))
+		require.Equal(t, http.StatusBadRequest, post(t, "/river/readings", testWriteToken, `{"readings":[{"timestamp":"2024-01-01T05:00:00Z","level":-1.0}]}`))
+		require.Equal(t, http.StatusBadRequest, post(t, "/river/readings", testWriteToken, `{"readings":[{"timestamp":"01/01/2024","level":1.0}]}`))
+	})
+}